@@ -0,0 +1,454 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+// Package clustertest launches local Redis clusters made of real redis-server instances to test code against topology changes.
+// It assigns the slots, attaches the replicas and provides helpers to stop nodes, trigger failovers and migrate slots.
+package clustertest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+// DefaultMasters defines the default number of masters of a cluster.
+var DefaultMasters = 3
+
+// DefaultTimeout defines the default time waited for the nodes of a cluster to agree on its configuration.
+var DefaultTimeout = 30 * time.Second
+
+// Options defines the layout of a cluster.
+// Each instance is assigned a port starting at Port, which is chosen at random when 0, and also uses the port 10000 above for the cluster bus.
+// Binary is the path of redis-server and defaults to the REDIS environment variable or redis-server in the PATH.
+// Config is added to the configuration of every instance.
+type Options struct {
+	Masters  int
+	Replicas int
+	Port     int
+	Binary   string
+	Config   map[string]string
+	Timeout  time.Duration
+}
+
+// Node defines an instance of a cluster.
+type Node struct {
+	ID   string
+	Port int
+
+	config map[string]string
+	db     *redis.DB
+	conn   *redis.Conn
+}
+
+// Address returns the address of the node to use with the client e.g. tcp://127.0.0.1:6379.
+func (node *Node) Address() string {
+	return fmt.Sprintf("tcp://127.0.0.1:%d", node.Port)
+}
+
+// Conn returns a connection to the node or nil when stopped.
+func (node *Node) Conn() *redis.Conn {
+	return node.conn
+}
+
+// Cluster implements a local Redis cluster.
+type Cluster struct {
+	options Options
+	root    string
+	nodes   []*Node
+}
+
+// New launches a cluster and waits until every slot is served.
+// Slots are split evenly between masters, each with the specified number of replicas.
+func New(options Options) (result *Cluster, err error) {
+	if 0 == options.Masters {
+		options.Masters = DefaultMasters
+	}
+
+	if 0 == options.Timeout {
+		options.Timeout = DefaultTimeout
+	}
+
+	if 0 == options.Port {
+		options.Port = rand.New(rand.NewSource(time.Now().UnixNano())).Intn(10000) + 20000
+	}
+
+	root, err := ioutil.TempDir("", "redis-cluster")
+	if err != nil {
+		return
+	}
+
+	cluster := &Cluster{
+		options: options,
+		root:    root,
+	}
+
+	defer func() {
+		cluster.Close()
+	}()
+
+	size := options.Masters * (1 + options.Replicas)
+	for i := 0; i < size; i++ {
+		node := &Node{
+			Port: options.Port + i,
+		}
+
+		dir := fmt.Sprintf("%s/%d", root, node.Port)
+		if err = os.Mkdir(dir, os.ModePerm); err != nil {
+			return
+		}
+
+		node.config = map[string]string{
+			"port":                 fmt.Sprintf("%d", node.Port),
+			"dir":                  dir,
+			"cluster-enabled":      "yes",
+			"cluster-config-file":  dir + "/nodes.conf",
+			"cluster-node-timeout": "2000",
+		}
+
+		for key, value := range options.Config {
+			node.config[key] = value
+		}
+
+		cluster.nodes = append(cluster.nodes, node)
+		if err = cluster.Start(node); err != nil {
+			return
+		}
+
+		var reply interface{}
+		if reply, err = node.conn.Do("CLUSTER", "MYID"); err != nil {
+			return
+		}
+
+		node.ID = string(reply.([]byte))
+
+		if i != 0 {
+			if err = expectOK(node.conn.Do("CLUSTER", "MEET", "127.0.0.1", options.Port)); err != nil {
+				return
+			}
+		}
+	}
+
+	// allocate the slots to the masters
+	k := 16384 / options.Masters
+	for i := 0; i < options.Masters; i++ {
+		last := k*i + k
+		if i == options.Masters-1 {
+			last = 16384
+		}
+
+		args := []interface{}{"ADDSLOTS"}
+		for j := k * i; j != last; j++ {
+			args = append(args, j)
+		}
+
+		if err = expectOK(cluster.nodes[i].conn.Do("CLUSTER", args...)); err != nil {
+			return
+		}
+	}
+
+	// attach the replicas once they know about their master
+	for i := options.Masters; i < size; i++ {
+		master := cluster.nodes[(i-options.Masters)%options.Masters]
+		err = cluster.until(func() error {
+			return expectOK(cluster.nodes[i].conn.Do("CLUSTER", "REPLICATE", master.ID))
+		})
+
+		if err != nil {
+			return
+		}
+	}
+
+	if err = cluster.Wait(); err != nil {
+		return
+	}
+
+	result, cluster = cluster, nil
+	return
+}
+
+// Dial returns a client connected to the cluster.
+func (cluster *Cluster) Dial() *redis.Client {
+	return &redis.Client{
+		Address: cluster.Addresses(),
+	}
+}
+
+// Addresses returns the addresses of every node of the cluster.
+func (cluster *Cluster) Addresses() (result []string) {
+	for _, node := range cluster.Nodes() {
+		result = append(result, node.Address())
+	}
+
+	return
+}
+
+// Nodes returns every node of the cluster, including the stopped ones.
+func (cluster *Cluster) Nodes() []*Node {
+	return append([]*Node(nil), cluster.nodes...)
+}
+
+// Masters returns the running nodes that currently serve slots.
+func (cluster *Cluster) Masters() (result []*Node, err error) {
+	ranges, err := cluster.slots()
+	if err != nil {
+		return
+	}
+
+	ids := make(map[string]bool)
+	for _, item := range ranges {
+		ids[item.Master.ID] = true
+	}
+
+	for _, node := range cluster.Nodes() {
+		if ids[node.ID] {
+			result = append(result, node)
+		}
+	}
+
+	return
+}
+
+// Replicas returns the running nodes replicating the master.
+func (cluster *Cluster) Replicas(master *Node) (result []*Node, err error) {
+	ranges, err := cluster.slots()
+	if err != nil {
+		return
+	}
+
+	ids := make(map[string]bool)
+	for _, item := range ranges {
+		if item.Master.ID == master.ID {
+			for _, replica := range item.Replicas {
+				ids[replica.ID] = true
+			}
+		}
+	}
+
+	for _, node := range cluster.Nodes() {
+		if ids[node.ID] {
+			result = append(result, node)
+		}
+	}
+
+	return
+}
+
+// Owner returns the master serving the slot.
+func (cluster *Cluster) Owner(slot int) (result *Node, err error) {
+	ranges, err := cluster.slots()
+	if err != nil {
+		return
+	}
+
+	for _, item := range ranges {
+		if item.First <= slot && slot <= item.Last {
+			for _, node := range cluster.Nodes() {
+				if node.ID == item.Master.ID {
+					result = node
+					return
+				}
+			}
+		}
+	}
+
+	err = fmt.Errorf("slot %d not served", slot)
+	return
+}
+
+// Start launches the instance of a stopped node, which joins the cluster again with its previous configuration.
+func (cluster *Cluster) Start(node *Node) (err error) {
+	if node.db != nil {
+		return
+	}
+
+	config := make(map[string]string)
+	for key, value := range node.config {
+		config[key] = value
+	}
+
+	db, err := redis.New(cluster.options.Binary, config)
+	if err != nil {
+		return
+	}
+
+	node.db, node.conn = db, db.Dial()
+	return
+}
+
+// Stop terminates the instance of the node to simulate a crash.
+// Its replicas are promoted by the cluster once the node timeout elapses.
+func (cluster *Cluster) Stop(node *Node) {
+	if node.db == nil {
+		return
+	}
+
+	node.conn.Close()
+	node.db.Close()
+	node.db, node.conn = nil, nil
+}
+
+// Failover promotes a replica of the master and waits until the cluster agrees on the new configuration.
+// It returns the new master.
+func (cluster *Cluster) Failover(master *Node) (result *Node, err error) {
+	replicas, err := cluster.Replicas(master)
+	if err != nil {
+		return
+	}
+
+	if len(replicas) == 0 {
+		err = fmt.Errorf("node %s has no replica", master.ID)
+		return
+	}
+
+	replica := replicas[0]
+	if err = expectOK(replica.conn.Do("CLUSTER", "FAILOVER")); err != nil {
+		return
+	}
+
+	err = cluster.until(func() error {
+		role, err := replica.conn.Role()
+		if err == nil && role != "master" {
+			err = fmt.Errorf("node %s is still a %s", replica.ID, role)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return
+	}
+
+	if err = cluster.Wait(); err != nil {
+		return
+	}
+
+	result = replica
+	return
+}
+
+// Migrate moves the slots with their keys to the master and waits until the cluster agrees on the new configuration.
+func (cluster *Cluster) Migrate(slots []int, to *Node) (err error) {
+	owners := make(map[string][]int)
+	for _, slot := range slots {
+		owner, err := cluster.Owner(slot)
+		if err != nil {
+			return err
+		}
+
+		if owner != to {
+			owners[owner.ID] = append(owners[owner.ID], slot)
+		}
+	}
+
+	client := cluster.Dial()
+	defer client.Close()
+
+	for id, items := range owners {
+		if err = client.Reshard(id, to.ID, items, redis.ReshardOptions{}); err != nil {
+			return
+		}
+	}
+
+	err = cluster.Wait()
+	return
+}
+
+// Wait blocks until every running node reports the cluster as ok with the same slots.
+func (cluster *Cluster) Wait() error {
+	return cluster.until(func() (err error) {
+		var last []redis.ClusterSlotRange
+		for _, node := range cluster.Nodes() {
+			if node.conn == nil {
+				continue
+			}
+
+			reply, err := node.conn.Do("CLUSTER", "INFO")
+			if err != nil {
+				return err
+			}
+
+			if !strings.Contains(string(reply.([]byte)), "cluster_state:ok") {
+				return fmt.Errorf("node %s reports the cluster as failed", node.ID)
+			}
+
+			ranges, err := node.conn.ClusterSlots()
+			if err != nil {
+				return err
+			}
+
+			sortRanges(ranges)
+			if last != nil && !reflect.DeepEqual(last, ranges) {
+				return fmt.Errorf("node %s disagrees on the slots", node.ID)
+			}
+
+			last = ranges
+		}
+
+		return
+	})
+}
+
+// Close stops every node and removes their data.
+func (cluster *Cluster) Close() {
+	if cluster == nil {
+		return
+	}
+
+	for _, node := range cluster.Nodes() {
+		cluster.Stop(node)
+	}
+
+	os.RemoveAll(cluster.root)
+}
+
+// slots returns the slots as seen by the first running node.
+func (cluster *Cluster) slots() (result []redis.ClusterSlotRange, err error) {
+	for _, node := range cluster.Nodes() {
+		if node.conn != nil {
+			result, err = node.conn.ClusterSlots()
+			return
+		}
+	}
+
+	err = fmt.Errorf("no node running")
+	return
+}
+
+// until calls f every 100ms until it succeeds or the timeout of the cluster elapses.
+func (cluster *Cluster) until(f func() error) (err error) {
+	deadline := time.Now().Add(cluster.options.Timeout)
+	for {
+		if err = f(); err == nil || time.Now().After(deadline) {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// sortRanges orders the ranges and their replicas, which nodes may list in any order.
+func sortRanges(ranges []redis.ClusterSlotRange) {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].First < ranges[j].First
+	})
+
+	for _, item := range ranges {
+		sort.Slice(item.Replicas, func(i, j int) bool {
+			return item.Replicas[i].ID < item.Replicas[j].ID
+		})
+	}
+}
+
+func expectOK(reply interface{}, err error) error {
+	if err == nil && reply != redis.OK {
+		err = fmt.Errorf("unexpected reply '%v'", reply)
+	}
+
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package clustertest
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+func TestCluster(t *testing.T) {
+	path := os.Getenv("REDIS")
+	if path == "" {
+		path = "redis-server"
+	}
+
+	if _, err := exec.LookPath(path); err != nil {
+		t.Skip("redis-server isn't available")
+	}
+
+	cluster, err := New(Options{Replicas: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer cluster.Close()
+
+	client := cluster.Dial()
+	defer client.Close()
+
+	if _, err := client.Do("SET", "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	slot := redis.Slot("foo")
+	master, err := cluster.Owner(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	promoted, err := cluster.Failover(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if owner, err := cluster.Owner(slot); err != nil || owner != promoted {
+		t.Fatal(owner, err)
+	}
+
+	masters, err := cluster.Masters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var target *redis.Conn
+	for _, node := range masters {
+		if node != promoted {
+			target = node.Conn()
+			if err := cluster.Migrate([]int{slot}, node); err != nil {
+				t.Fatal(err)
+			}
+
+			break
+		}
+	}
+
+	if result, err := client.Do("GET", "foo"); err != nil || string(result.([]byte)) != "bar" {
+		t.Fatal(result, err)
+	}
+
+	if result, err := target.Do("CLUSTER", "COUNTKEYSINSLOT", slot); err != nil || result != int64(1) {
+		t.Fatal(result, err)
+	}
+}
@@ -0,0 +1,520 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+// Package rdb implements a decoder of the RDB snapshots written by Redis with SAVE or sent to replicas on a full resynchronization.
+// It iterates over the keys of a snapshot with their value and expiry.
+// Strings, lists, sets, sorted sets and hashes are supported in all their encodings but streams and module types are not.
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrCorrupted is returned when the snapshot holds an invalid encoding.
+var ErrCorrupted = errors.New("corrupted snapshot")
+
+// Type defines the type of a value.
+type Type int
+
+const (
+	String Type = iota
+	List
+	Set
+	SortedSet
+	Hash
+)
+
+// Member defines an element of a sorted set.
+type Member struct {
+	Member []byte
+	Score  float64
+}
+
+// Entry defines a key of a database and its value.
+// Values are []byte for strings, [][]byte for lists and sets, []Member for sorted sets and map[string][]byte for hashes.
+// Expiry is zero when the key doesn't expire.
+type Entry struct {
+	DB     int
+	Key    []byte
+	Type   Type
+	Value  interface{}
+	Expiry time.Time
+}
+
+// opcodes of the snapshot
+const (
+	opSlotInfo     = 0xF4
+	opFunction     = 0xF5
+	opModuleAux    = 0xF7
+	opIdle         = 0xF8
+	opFreq         = 0xF9
+	opAux          = 0xFA
+	opResizeDB     = 0xFB
+	opExpireTimeMs = 0xFC
+	opExpireTime   = 0xFD
+	opSelectDB     = 0xFE
+	opEOF          = 0xFF
+)
+
+// encodings of the values
+const (
+	typeString         = 0
+	typeList           = 1
+	typeSet            = 2
+	typeSortedSet      = 3
+	typeHash           = 4
+	typeSortedSet2     = 5
+	typeHashZipmap     = 9
+	typeListZiplist    = 10
+	typeSetIntset      = 11
+	typeSortedZiplist  = 12
+	typeHashZiplist    = 13
+	typeListQuicklist  = 14
+	typeHashListpack   = 16
+	typeSortedListpack = 17
+	typeListQuicklist2 = 18
+	typeSetListpack    = 20
+)
+
+// Decoder reads the entries of a snapshot.
+type Decoder struct {
+	// Version of the snapshot format once the header is read.
+	Version int
+
+	// Aux holds the auxiliary fields read so far like the version of Redis that wrote the snapshot.
+	Aux map[string]string
+
+	reader *bufio.Reader
+	header bool
+	db     int
+}
+
+// NewDecoder creates a decoder of the snapshot read from the specified source.
+func NewDecoder(reader io.Reader) (result *Decoder) {
+	result = &Decoder{
+		Aux:    make(map[string]string),
+		reader: bufio.NewReader(reader),
+	}
+
+	return
+}
+
+// Decode returns the next entry of the snapshot or io.EOF once all entries are read.
+func (decoder *Decoder) Decode() (entry Entry, err error) {
+	if !decoder.header {
+		if err = decoder.readHeader(); err != nil {
+			return
+		}
+	}
+
+	for {
+		var op byte
+		if op, err = decoder.reader.ReadByte(); err != nil {
+			err = unexpected(err)
+			return
+		}
+
+		switch op {
+		case opEOF:
+			// the checksum that follows isn't verified
+			err = io.EOF
+			return
+		case opSelectDB:
+			var n uint64
+			n, err = decoder.length()
+			decoder.db = int(n)
+		case opResizeDB:
+			if _, err = decoder.length(); err == nil {
+				_, err = decoder.length()
+			}
+		case opSlotInfo:
+			for i := 0; i < 3 && err == nil; i++ {
+				_, err = decoder.length()
+			}
+		case opAux:
+			var key, value []byte
+			if key, err = decoder.string(); err == nil {
+				if value, err = decoder.string(); err == nil {
+					decoder.Aux[string(key)] = string(value)
+				}
+			}
+		case opFunction:
+			_, err = decoder.string()
+		case opIdle:
+			_, err = decoder.length()
+		case opFreq:
+			_, err = decoder.reader.ReadByte()
+		case opExpireTimeMs:
+			var data []byte
+			if data, err = decoder.read(8); err == nil {
+				ms := int64(binary.LittleEndian.Uint64(data))
+				entry.Expiry = time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+			}
+		case opExpireTime:
+			var data []byte
+			if data, err = decoder.read(4); err == nil {
+				entry.Expiry = time.Unix(int64(int32(binary.LittleEndian.Uint32(data))), 0)
+			}
+		case opModuleAux:
+			err = fmt.Errorf("unsupported module data")
+		default:
+			entry.DB = decoder.db
+			if entry.Key, err = decoder.string(); err == nil {
+				entry.Type, entry.Value, err = decoder.value(op)
+			}
+
+			err = unexpected(err)
+			return
+		}
+
+		if err != nil {
+			err = unexpected(err)
+			return
+		}
+	}
+}
+
+// DecodeDump returns the value serialized by DUMP, which is also the format of the values written by the export of a client.
+// The version and checksum that end the payload aren't verified.
+func DecodeDump(payload []byte) (t Type, value interface{}, err error) {
+	if len(payload) < 11 {
+		err = ErrCorrupted
+		return
+	}
+
+	decoder := NewDecoder(bytes.NewReader(payload[1 : len(payload)-10]))
+	if t, value, err = decoder.value(payload[0]); err != nil {
+		err = unexpected(err)
+	}
+
+	return
+}
+
+func (decoder *Decoder) readHeader() (err error) {
+	data, err := decoder.read(9)
+	if err != nil {
+		return
+	}
+
+	if string(data[:5]) != "REDIS" {
+		err = fmt.Errorf("invalid snapshot header '%q'", data)
+		return
+	}
+
+	if decoder.Version, err = strconv.Atoi(string(data[5:])); err != nil {
+		err = fmt.Errorf("invalid snapshot version '%q'", data[5:])
+		return
+	}
+
+	decoder.header = true
+	return
+}
+
+// value reads the value of a key and converts its encoding to its type.
+func (decoder *Decoder) value(encoding byte) (t Type, value interface{}, err error) {
+	switch encoding {
+	case typeString:
+		t = String
+		value, err = decoder.string()
+	case typeList, typeSet:
+		t = List
+		if encoding == typeSet {
+			t = Set
+		}
+
+		var n uint64
+		if n, err = decoder.length(); err != nil {
+			return
+		}
+
+		items := make([][]byte, 0, capacity(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var item []byte
+			item, err = decoder.string()
+			items = append(items, item)
+		}
+
+		value = items
+	case typeSortedSet, typeSortedSet2:
+		t = SortedSet
+		var n uint64
+		if n, err = decoder.length(); err != nil {
+			return
+		}
+
+		members := make([]Member, 0, capacity(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var member Member
+			if member.Member, err = decoder.string(); err != nil {
+				break
+			}
+
+			if encoding == typeSortedSet {
+				member.Score, err = decoder.score()
+			} else {
+				var data []byte
+				data, err = decoder.read(8)
+				if err == nil {
+					member.Score = math.Float64frombits(binary.LittleEndian.Uint64(data))
+				}
+			}
+
+			members = append(members, member)
+		}
+
+		value = members
+	case typeHash:
+		t = Hash
+		var n uint64
+		if n, err = decoder.length(); err != nil {
+			return
+		}
+
+		fields := make(map[string][]byte, capacity(n))
+		for i := uint64(0); i < n && err == nil; i++ {
+			var field, item []byte
+			if field, err = decoder.string(); err == nil {
+				item, err = decoder.string()
+				fields[string(field)] = item
+			}
+		}
+
+		value = fields
+	case typeHashZipmap:
+		t = Hash
+		var data []byte
+		if data, err = decoder.string(); err == nil {
+			value, err = zipmap(data)
+		}
+	case typeListZiplist, typeSetIntset, typeSortedZiplist, typeHashZiplist, typeHashListpack, typeSortedListpack, typeSetListpack:
+		var data []byte
+		if data, err = decoder.string(); err != nil {
+			return
+		}
+
+		var items [][]byte
+		switch encoding {
+		case typeSetIntset:
+			items, err = intset(data)
+		case typeListZiplist, typeSortedZiplist, typeHashZiplist:
+			items, err = ziplist(data)
+		default:
+			items, err = listpack(data)
+		}
+
+		if err != nil {
+			return
+		}
+
+		switch encoding {
+		case typeListZiplist:
+			t, value = List, items
+		case typeSetIntset, typeSetListpack:
+			t, value = Set, items
+		case typeSortedZiplist, typeSortedListpack:
+			t = SortedSet
+			value, err = members(items)
+		default:
+			t = Hash
+			value, err = pairs(items)
+		}
+	case typeListQuicklist, typeListQuicklist2:
+		t = List
+		var n uint64
+		if n, err = decoder.length(); err != nil {
+			return
+		}
+
+		var items [][]byte
+		for i := uint64(0); i < n && err == nil; i++ {
+			// nodes of the second version are either a single plain element or a listpack
+			container := uint64(2)
+			if encoding == typeListQuicklist2 {
+				if container, err = decoder.length(); err != nil {
+					break
+				}
+			}
+
+			var data []byte
+			if data, err = decoder.string(); err != nil {
+				break
+			}
+
+			var node [][]byte
+			switch {
+			case container == 1:
+				node = [][]byte{data}
+			case encoding == typeListQuicklist:
+				node, err = ziplist(data)
+			default:
+				node, err = listpack(data)
+			}
+
+			items = append(items, node...)
+		}
+
+		value = items
+	default:
+		err = fmt.Errorf("unsupported value encoding %d", encoding)
+	}
+
+	return
+}
+
+// read returns the next n bytes.
+func (decoder *Decoder) read(n int) (data []byte, err error) {
+	data = make([]byte, n)
+	_, err = io.ReadFull(decoder.reader, data)
+	return
+}
+
+// encodedLength reads a length or the special encoding of a string.
+func (decoder *Decoder) encodedLength() (n uint64, special bool, err error) {
+	b, err := decoder.reader.ReadByte()
+	if err != nil {
+		return
+	}
+
+	switch b >> 6 {
+	case 0:
+		n = uint64(b & 0x3F)
+	case 1:
+		var next byte
+		next, err = decoder.reader.ReadByte()
+		n = uint64(b&0x3F)<<8 | uint64(next)
+	case 2:
+		var data []byte
+		switch b {
+		case 0x80:
+			data, err = decoder.read(4)
+			if err == nil {
+				n = uint64(binary.BigEndian.Uint32(data))
+			}
+		case 0x81:
+			data, err = decoder.read(8)
+			if err == nil {
+				n = binary.BigEndian.Uint64(data)
+			}
+		default:
+			err = ErrCorrupted
+		}
+	default:
+		n, special = uint64(b&0x3F), true
+	}
+
+	return
+}
+
+// length reads a length.
+func (decoder *Decoder) length() (n uint64, err error) {
+	n, special, err := decoder.encodedLength()
+	if err == nil && special {
+		err = ErrCorrupted
+	}
+
+	return
+}
+
+// string reads a string that may be an integer or compressed.
+func (decoder *Decoder) string() (result []byte, err error) {
+	n, special, err := decoder.encodedLength()
+	if err != nil {
+		return
+	}
+
+	if !special {
+		if n > math.MaxInt32 {
+			err = ErrCorrupted
+			return
+		}
+
+		return decoder.read(int(n))
+	}
+
+	var data []byte
+	switch n {
+	case 0:
+		if data, err = decoder.read(1); err == nil {
+			result = strconv.AppendInt(nil, int64(int8(data[0])), 10)
+		}
+	case 1:
+		if data, err = decoder.read(2); err == nil {
+			result = strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(data))), 10)
+		}
+	case 2:
+		if data, err = decoder.read(4); err == nil {
+			result = strconv.AppendInt(nil, int64(int32(binary.LittleEndian.Uint32(data))), 10)
+		}
+	case 3:
+		var compressed, size uint64
+		if compressed, err = decoder.length(); err != nil {
+			return
+		}
+
+		if size, err = decoder.length(); err != nil {
+			return
+		}
+
+		if compressed > math.MaxInt32 || size > math.MaxInt32 {
+			err = ErrCorrupted
+			return
+		}
+
+		if data, err = decoder.read(int(compressed)); err == nil {
+			result, err = lzf(data, int(size))
+		}
+	default:
+		err = ErrCorrupted
+	}
+
+	return
+}
+
+// score reads the score of a sorted set written as text.
+func (decoder *Decoder) score() (result float64, err error) {
+	n, err := decoder.reader.ReadByte()
+	if err != nil {
+		return
+	}
+
+	switch n {
+	case 253:
+		result = math.NaN()
+	case 254:
+		result = math.Inf(1)
+	case 255:
+		result = math.Inf(-1)
+	default:
+		var data []byte
+		if data, err = decoder.read(int(n)); err == nil {
+			result, err = strconv.ParseFloat(string(data), 64)
+		}
+	}
+
+	return
+}
+
+// capacity bounds the preallocation of a collection whose declared length can't be trusted.
+func capacity(n uint64) int {
+	if n > 1024 {
+		return 1024
+	}
+
+	return int(n)
+}
+
+// unexpected reports a snapshot that ends early.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// snapshot builds a snapshot from raw bytes and short strings.
+func snapshot(items ...interface{}) io.Reader {
+	var buffer bytes.Buffer
+	buffer.WriteString("REDIS0011")
+
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			buffer.WriteByte(byte(len(item)))
+			buffer.WriteString(item)
+		case []byte:
+			buffer.Write(item)
+		case int:
+			buffer.WriteByte(byte(item))
+		case uint64:
+			binary.Write(&buffer, binary.LittleEndian, item)
+		case uint32:
+			binary.Write(&buffer, binary.LittleEndian, item)
+		}
+	}
+
+	return &buffer
+}
+
+func TestDecoder(t *testing.T) {
+	r := snapshot(
+		opAux, "redis-ver", "7.2.0",
+		opSelectDB, 1,
+		opResizeDB, 2, 0,
+		opExpireTimeMs, uint64(1500000000123), typeString, "a", "hello",
+		typeString, "n", []byte{0xC0, 0x7B},
+		typeString, "z", []byte{0xC3, 6, 6, 0x02, 'a', 'b', 'c', 0x20, 0x02},
+		typeHash, "h", 1, "f", "v",
+		typeSetIntset, "s", 12, uint32(2), uint32(2), []byte{1, 0, 0xFF, 0xFF},
+		typeSortedListpack, "zs", 20, uint32(20), []byte{4, 0, 0x81, 'm', 2, 0x83, '1', '.', '5', 4, 0x81, 'n', 2, 0x02, 1, 0xFF},
+		typeListQuicklist2, "l", 2, 1, "x", 2, 13, uint32(13), []byte{2, 0, 0x81, 'y', 2, 0xDF, 0xFF, 2, 0xFF},
+		typeListZiplist, "zl", 16, uint32(16), uint32(13), []byte{2, 0, 0, 1, 'p', 3, 0xF6, 0xFF},
+		opExpireTime, uint32(1500000000), typeString, "e", "1",
+		opEOF, uint64(0),
+	)
+
+	expected := []string{
+		"1 a 0 hello 1500000000123",
+		"1 n 0 123 0",
+		"1 z 0 abcabc 0",
+		"1 h 4 map[f:v] 0",
+		"1 s 2 [1 -1] 0",
+		"1 zs 3 [m=1.5 n=2] 0",
+		"1 l 1 [x y -1] 0",
+		"1 zl 1 [p 5] 0",
+		"1 e 0 1 1500000000000",
+	}
+
+	decoder := NewDecoder(r)
+	for _, text := range expected {
+		entry, err := decoder.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expiry := int64(0)
+		if !entry.Expiry.IsZero() {
+			expiry = entry.Expiry.UnixNano() / int64(time.Millisecond)
+		}
+
+		value := entry.Value
+		if members, ok := value.([]Member); ok {
+			scores := make([]string, len(members))
+			for i, member := range members {
+				scores[i] = fmt.Sprintf("%s=%g", member.Member, member.Score)
+			}
+
+			value = scores
+		}
+
+		if s := fmt.Sprintf("%d %s %d %s %d", entry.DB, entry.Key, entry.Type, value, expiry); s != text {
+			t.Fatalf("%q vs. %q", s, text)
+		}
+	}
+
+	if _, err := decoder.Decode(); err != io.EOF {
+		t.Fatal(err)
+	}
+
+	if decoder.Version != 11 || decoder.Aux["redis-ver"] != "7.2.0" {
+		t.Fatal(decoder.Version, decoder.Aux)
+	}
+
+	if kind, value, err := DecodeDump([]byte{typeString, 2, 'h', 'i', 11, 0, 1, 2, 3, 4, 5, 6, 7, 8}); kind != String || string(value.([]byte)) != "hi" || err != nil {
+		t.Fatal(kind, value, err)
+	}
+
+	// truncated snapshots are reported
+	if _, err := NewDecoder(snapshot(typeString, "a")).Decode(); err != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+}
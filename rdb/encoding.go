@@ -0,0 +1,314 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package rdb
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// cursor walks over the bytes of a compact encoding and remembers when it goes out of bounds.
+type cursor struct {
+	data []byte
+	err  error
+}
+
+func (c *cursor) next(n int) (result []byte) {
+	if c.err != nil || n < 0 || n > len(c.data) {
+		c.err = ErrCorrupted
+		return
+	}
+
+	result, c.data = c.data[:n], c.data[n:]
+	return
+}
+
+// byte returns the next byte or the end marker of the encodings when out of bounds.
+func (c *cursor) byte() byte {
+	if b := c.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0xFF
+}
+
+func (c *cursor) uint16() uint16 {
+	if b := c.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+func (c *cursor) uint32() uint32 {
+	if b := c.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (c *cursor) uint64() uint64 {
+	if b := c.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+// int24 reads a signed integer stored in 3 bytes.
+func (c *cursor) int24() int64 {
+	if b := c.next(3); b != nil {
+		return int64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8)
+	}
+
+	return 0
+}
+
+func itoa(k int64) []byte {
+	return strconv.AppendInt(nil, k, 10)
+}
+
+// lzf decompresses the data into exactly n bytes.
+func lzf(data []byte, n int) (result []byte, err error) {
+	result = make([]byte, 0, n)
+	c := &cursor{data: data}
+
+	for len(c.data) != 0 && c.err == nil {
+		ctrl := int(c.byte())
+
+		// literal run
+		if ctrl < 32 {
+			result = append(result, c.next(ctrl+1)...)
+			continue
+		}
+
+		// back reference
+		k := ctrl >> 5
+		if k == 7 {
+			k += int(c.byte())
+		}
+
+		ref := len(result) - (ctrl&0x1F)<<8 - int(c.byte()) - 1
+		if ref < 0 {
+			c.err = ErrCorrupted
+			break
+		}
+
+		// the reference may overlap what is being copied
+		for i := 0; i < k+2; i++ {
+			result = append(result, result[ref+i])
+		}
+	}
+
+	if err = c.err; err == nil && len(result) != n {
+		err = ErrCorrupted
+	}
+
+	return
+}
+
+// ziplist returns the items of a ziplist with integers converted to text.
+func ziplist(data []byte) (items [][]byte, err error) {
+	c := &cursor{data: data}
+	c.next(10)
+
+	for c.err == nil {
+		// the length of the previous entry
+		prev := c.byte()
+		if prev == 0xFF {
+			break
+		}
+
+		if prev == 0xFE {
+			c.next(4)
+		}
+
+		b := c.byte()
+		switch {
+		case b>>6 == 0:
+			items = append(items, c.next(int(b&0x3F)))
+		case b>>6 == 1:
+			items = append(items, c.next(int(b&0x3F)<<8|int(c.byte())))
+		case b>>6 == 2:
+			if length := c.next(4); length != nil {
+				items = append(items, c.next(int(binary.BigEndian.Uint32(length))))
+			}
+		case b == 0xC0:
+			items = append(items, itoa(int64(int16(c.uint16()))))
+		case b == 0xD0:
+			items = append(items, itoa(int64(int32(c.uint32()))))
+		case b == 0xE0:
+			items = append(items, itoa(int64(c.uint64())))
+		case b == 0xF0:
+			items = append(items, itoa(c.int24()))
+		case b == 0xFE:
+			items = append(items, itoa(int64(int8(c.byte()))))
+		case b >= 0xF1 && b <= 0xFD:
+			items = append(items, itoa(int64(b&0x0F)-1))
+		default:
+			c.err = ErrCorrupted
+		}
+	}
+
+	err = c.err
+	return
+}
+
+// listpack returns the items of a listpack with integers converted to text.
+func listpack(data []byte) (items [][]byte, err error) {
+	c := &cursor{data: data}
+	c.next(6)
+
+	for c.err == nil {
+		n := len(c.data)
+
+		b := c.byte()
+		if b == 0xFF {
+			break
+		}
+
+		switch {
+		case b&0x80 == 0:
+			items = append(items, itoa(int64(b&0x7F)))
+		case b&0xC0 == 0x80:
+			items = append(items, c.next(int(b&0x3F)))
+		case b&0xE0 == 0xC0:
+			k := int64(b&0x1F)<<8 | int64(c.byte())
+			if k >= 1<<12 {
+				k -= 1 << 13
+			}
+
+			items = append(items, itoa(k))
+		case b&0xF0 == 0xE0:
+			items = append(items, c.next(int(b&0x0F)<<8|int(c.byte())))
+		case b == 0xF0:
+			items = append(items, c.next(int(c.uint32())))
+		case b == 0xF1:
+			items = append(items, itoa(int64(int16(c.uint16()))))
+		case b == 0xF2:
+			items = append(items, itoa(c.int24()))
+		case b == 0xF3:
+			items = append(items, itoa(int64(int32(c.uint32()))))
+		case b == 0xF4:
+			items = append(items, itoa(int64(c.uint64())))
+		default:
+			c.err = ErrCorrupted
+		}
+
+		// skip the length of the entry stored backward at its end
+		size := n - len(c.data)
+		switch {
+		case size < 128:
+			c.next(1)
+		case size < 16384:
+			c.next(2)
+		case size < 2097152:
+			c.next(3)
+		case size < 268435456:
+			c.next(4)
+		default:
+			c.next(5)
+		}
+	}
+
+	err = c.err
+	return
+}
+
+// intset returns the integers of an intset converted to text.
+func intset(data []byte) (items [][]byte, err error) {
+	c := &cursor{data: data}
+	size := int(c.uint32())
+	n := int(c.uint32())
+
+	if size != 2 && size != 4 && size != 8 || n > len(c.data)/size {
+		err = ErrCorrupted
+		return
+	}
+
+	items = make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		switch size {
+		case 2:
+			items = append(items, itoa(int64(int16(c.uint16()))))
+		case 4:
+			items = append(items, itoa(int64(int32(c.uint32()))))
+		default:
+			items = append(items, itoa(int64(c.uint64())))
+		}
+	}
+
+	err = c.err
+	return
+}
+
+// zipmap returns the fields of a hash encoded as a zipmap.
+func zipmap(data []byte) (fields map[string][]byte, err error) {
+	c := &cursor{data: data}
+	c.next(1)
+
+	length := func() int {
+		switch b := c.byte(); b {
+		case 0xFE:
+			return int(c.uint32())
+		case 0xFF:
+			return -1
+		default:
+			return int(b)
+		}
+	}
+
+	fields = make(map[string][]byte)
+	for c.err == nil {
+		n := length()
+		if n < 0 {
+			break
+		}
+
+		field := c.next(n)
+		n = length()
+		free := int(c.byte())
+
+		fields[string(field)] = c.next(n)
+		c.next(free)
+	}
+
+	err = c.err
+	return
+}
+
+// members pairs the items of a compact sorted set.
+func members(items [][]byte) (result []Member, err error) {
+	if len(items)%2 != 0 {
+		err = ErrCorrupted
+		return
+	}
+
+	result = make([]Member, 0, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		var score float64
+		if score, err = strconv.ParseFloat(string(items[i+1]), 64); err != nil {
+			return
+		}
+
+		result = append(result, Member{items[i], score})
+	}
+
+	return
+}
+
+// pairs maps the items of a compact hash.
+func pairs(items [][]byte) (fields map[string][]byte, err error) {
+	if len(items)%2 != 0 {
+		err = ErrCorrupted
+		return
+	}
+
+	fields = make(map[string][]byte, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		fields[string(items[i])] = items[i+1]
+	}
+
+	return
+}
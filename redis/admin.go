@@ -0,0 +1,229 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SlotState defines the state of a slot changed by SetSlot.
+type SlotState string
+
+const (
+	// SlotImporting marks the slot as being imported from another node.
+	SlotImporting SlotState = "IMPORTING"
+
+	// SlotMigrating marks the slot as being migrated to another node.
+	SlotMigrating SlotState = "MIGRATING"
+
+	// SlotNode assigns the slot to another node.
+	SlotNode SlotState = "NODE"
+
+	// SlotStable clears any importing or migrating state of the slot.
+	SlotStable SlotState = "STABLE"
+)
+
+// Failover asks the replica with the specified ID to take over its master.
+// When forced, the replica doesn't wait for the agreement of its master.
+func (client *Client) Failover(nodeID string, force bool) (err error) {
+	_, node, err := client.node(nodeID)
+	if err != nil {
+		return
+	}
+
+	args := []interface{}{"FAILOVER"}
+	if force {
+		args = append(args, "FORCE")
+	}
+
+	err = expectOK(node.Do("CLUSTER", args...))
+	return
+}
+
+// Meet connects the node at the specified host:port address to the cluster.
+func (client *Client) Meet(address string) (err error) {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(address, "tcp://"))
+	if err != nil {
+		return
+	}
+
+	node, err := client.adminNode()
+	if err != nil {
+		return
+	}
+
+	err = expectOK(node.Do("CLUSTER", "MEET", host, port))
+	return
+}
+
+// Forget removes the node with the specified ID from the node table of every other node of the cluster.
+// Nodes that failed are reported with NodeErrors.
+func (client *Client) Forget(nodeID string) (err error) {
+	ids, err := client.clusterNodes()
+	if err != nil {
+		return
+	}
+
+	nodes := make(map[string]*Conn)
+	for id, name := range ids {
+		if id != nodeID {
+			nodes[name] = client.lookup(name)
+		}
+	}
+
+	_, err = each(nodes, func(name string, node *Conn) (interface{}, error) {
+		return nil, expectOK(node.Do("CLUSTER", "FORGET", nodeID))
+	})
+
+	return
+}
+
+// SetSlot changes the state of the slot on the node with the specified ID.
+// The target is the ID of the node importing, migrating or receiving the slot and is ignored when the slot becomes stable.
+func (client *Client) SetSlot(nodeID string, slot int, state SlotState, target string) (err error) {
+	_, node, err := client.node(nodeID)
+	if err != nil {
+		return
+	}
+
+	args := []interface{}{"SETSLOT", slot, string(state)}
+	if state != SlotStable {
+		args = append(args, target)
+	}
+
+	err = expectOK(node.Do("CLUSTER", args...))
+	return
+}
+
+// CountKeysInSlot returns the number of keys of the slot.
+func (client *Client) CountKeysInSlot(slot int) (count int64, err error) {
+	request := NewRequest("CLUSTER", "COUNTKEYSINSLOT", slot)
+	request.ForceSlot(slot)
+
+	if err = client.Send(request); err != nil {
+		return
+	}
+
+	result, _ := request.Result(0)
+	count, ok := result.(int64)
+	if !ok {
+		err = fmt.Errorf("unexpected CLUSTER COUNTKEYSINSLOT reply '%v'", result)
+	}
+
+	return
+}
+
+// GetKeysInSlot returns up to count keys of the slot.
+func (client *Client) GetKeysInSlot(slot, count int) (keys []string, err error) {
+	request := NewRequest("CLUSTER", "GETKEYSINSLOT", slot, count)
+	request.ForceSlot(slot)
+
+	if err = client.Send(request); err != nil {
+		return
+	}
+
+	result, _ := request.Result(0)
+	items, ok := result.([]interface{})
+	if !ok {
+		err = fmt.Errorf("unexpected CLUSTER GETKEYSINSLOT reply '%v'", result)
+		return
+	}
+
+	keys = make([]string, len(items))
+	for i, item := range items {
+		key, _ := item.([]byte)
+		keys[i] = string(key)
+	}
+
+	return
+}
+
+// node returns the address and connection of the node with the specified ID.
+func (client *Client) node(id string) (name string, node *Conn, err error) {
+	state := client.load()
+
+	name, ok := state.ids[id]
+	if !ok {
+		// the node may not serve any slot or its ID may not be reported by CLUSTER SLOTS
+		var ids map[string]string
+		if ids, err = client.clusterNodes(); err != nil {
+			return
+		}
+
+		if name, ok = ids[id]; !ok {
+			err = fmt.Errorf("unknown node ID '%s'", id)
+			return
+		}
+	}
+
+	node = client.lookup(name)
+	return
+}
+
+// adminNode returns the node serving the first slot or any known node while the slots aren't all served, e.g. when setting up the cluster.
+func (client *Client) adminNode() (node *Conn, err error) {
+	state := client.load()
+	if node = state.slots.get(0); node != nil {
+		return
+	}
+
+	for _, node = range state.nodes {
+		return
+	}
+
+	err = fmt.Errorf("no node serving slot %d", 0)
+	return
+}
+
+// clusterNodes returns the address of every node of the cluster indexed by ID.
+func (client *Client) clusterNodes() (ids map[string]string, err error) {
+	node, err := client.adminNode()
+	if err != nil {
+		return
+	}
+
+	reply, err := node.Do("CLUSTER", "NODES")
+	if err != nil {
+		return
+	}
+
+	text, ok := reply.([]byte)
+	if !ok {
+		err = fmt.Errorf("unexpected CLUSTER NODES reply '%v'", reply)
+		return
+	}
+
+	ids = parseClusterNodes(string(text))
+	return
+}
+
+func parseClusterNodes(text string) (ids map[string]string) {
+	ids = make(map[string]string)
+
+	// formatted as <id> <ip:port@cport> <flags> <master> <ping-sent> <pong-recv> <epoch> <link-state> <slot>...
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		addr := fields[1]
+		if i := strings.IndexAny(addr, "@,"); i >= 0 {
+			addr = addr[:i]
+		}
+
+		ids[fields[0]] = "tcp://" + addr
+	}
+
+	return
+}
+
+func expectOK(reply interface{}, err error) error {
+	if err == nil && reply != OK {
+		err = fmt.Errorf("unexpected reply '%v'", reply)
+	}
+
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseClusterNodes(t *testing.T) {
+	text := "07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected\n" +
+		"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001 myself,master - 0 0 1 connected 0-5460\n" +
+		"292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003,redis-c master - 0 1426238318243 3 connected 10923-16383\n"
+
+	expected := map[string]string{
+		"07c37dfeb235213a872192d90877d0cd55635b91": "tcp://127.0.0.1:30004",
+		"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca": "tcp://127.0.0.1:30001",
+		"292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f": "tcp://127.0.0.1:30003",
+	}
+
+	if result := parseClusterNodes(text); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %v", result)
+	}
+}
+
+func TestAdminNode(t *testing.T) {
+	db := new(mockDB)
+
+	// the cluster is partially covered
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: db, address: "tcp://127.0.0.1:7000"},
+		},
+	}
+
+	state.slots.fill(100, 16383, state.nodes["tcp://127.0.0.1:7000"])
+
+	client := &Client{
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	db.result.WriteString("+OK\r\n")
+	if err := client.Meet("127.0.0.1:7001"); err != nil {
+		t.Fatal(err)
+	}
+
+	client.state.Store(&mapping{shards: true})
+	if err := client.Meet("127.0.0.1:7001"); err == nil {
+		t.Fatal("expecting no node")
+	}
+
+	if _, err := client.clusterNodes(); err == nil {
+		t.Fatal("expecting no node")
+	}
+}
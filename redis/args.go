@@ -0,0 +1,190 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Args builds the arguments of a command from Go values with explicit rules.
+// Strings, byte slices, numbers and booleans are sent as they are, with booleans as 1 or 0.
+// Durations and times are sent in milliseconds, which is what the P-prefixed commands like PEXPIRE or PEXPIREAT expect, unless added with AddSeconds.
+// Pointers are followed and values implementing Marshaler are sent as they marshal themselves.
+// Anything else including nil is an error reported by Values, except with AddFlat.
+type Args struct {
+	values []interface{}
+	err    error
+}
+
+// NewArgs returns the arguments holding the values added with Add.
+func NewArgs(values ...interface{}) *Args {
+	return new(Args).Add(values...)
+}
+
+// Add appends each value as a single argument.
+func (args *Args) Add(values ...interface{}) *Args {
+	for _, value := range values {
+		args.add(value, time.Millisecond)
+	}
+
+	return args
+}
+
+// AddSeconds appends a duration or time as a whole number of seconds e.g. for EXPIRE or EXPIREAT.
+// Durations are rounded up so that a positive duration never becomes 0.
+func (args *Args) AddSeconds(value interface{}) *Args {
+	args.add(value, time.Second)
+	return args
+}
+
+// AddFlat appends the items of a slice, the keys and values of a map sorted by key or the names and values of the exported fields of a struct e.g. for HSET.
+// Fields are named by their redis tag, if any, which can also skip them with "-" or when they hold their zero value with ",omitempty".
+// Other values are appended like with Add.
+func (args *Args) AddFlat(value interface{}) *Args {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			args.add(value, time.Millisecond)
+			break
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			args.add(v.Index(i).Interface(), time.Millisecond)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i := range keys {
+			names[i] = fmt.Sprint(keys[i].Interface())
+		}
+
+		sort.Sort(byName{names, keys})
+		for _, key := range keys {
+			args.add(key.Interface(), time.Millisecond)
+			args.add(v.MapIndex(key).Interface(), time.Millisecond)
+		}
+	case reflect.Struct:
+		if _, ok := v.Interface().(time.Time); ok {
+			args.add(value, time.Millisecond)
+			break
+		}
+
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name, options := field.Name, ""
+			if tag := field.Tag.Get("redis"); tag != "" {
+				if i := strings.Index(tag, ","); i >= 0 {
+					tag, options = tag[:i], tag[i+1:]
+				}
+
+				if tag == "-" {
+					continue
+				}
+
+				if tag != "" {
+					name = tag
+				}
+			}
+
+			f := v.Field(i)
+			if options == "omitempty" && reflect.DeepEqual(f.Interface(), reflect.Zero(f.Type()).Interface()) {
+				continue
+			}
+
+			args.add(name, time.Millisecond)
+			args.add(f.Interface(), time.Millisecond)
+		}
+	default:
+		args.add(value, time.Millisecond)
+	}
+
+	return args
+}
+
+// Values returns the arguments or the first error.
+func (args *Args) Values() ([]interface{}, error) {
+	return args.values, args.err
+}
+
+func (args *Args) add(value interface{}, unit time.Duration) {
+	if args.err != nil {
+		return
+	}
+
+	var result interface{}
+	if result, args.err = argument(value, unit); args.err == nil {
+		args.values = append(args.values, result)
+	}
+}
+
+// argument returns the value as a type supported by the encoder.
+func argument(value interface{}, unit time.Duration) (interface{}, error) {
+	switch v := value.(type) {
+	case string, []byte, int, int64, float64, bool, Marshaler:
+		return value, nil
+	case time.Duration:
+		n := int64(v / unit)
+		if v%unit > 0 {
+			n++
+		}
+
+		return n, nil
+	case time.Time:
+		return v.UnixNano() / int64(unit), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(v.Uint()), nil
+	case reflect.Float32:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return argument(v.Elem().Interface(), unit)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot encode %T as a single argument", value)
+}
+
+// byName sorts the keys of a map by their formatted value.
+type byName struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (b byName) Len() int {
+	return len(b.names)
+}
+
+func (b byName) Less(i, j int) bool {
+	return b.names[i] < b.names[j]
+}
+
+func (b byName) Swap(i, j int) {
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
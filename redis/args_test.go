@@ -0,0 +1,65 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArgs(t *testing.T) {
+	type user struct {
+		Name    string
+		Age     uint8  `redis:"age"`
+		Email   string `redis:",omitempty"`
+		Secret  string `redis:"-"`
+		private int
+	}
+
+	at := time.Unix(1500000000, 0)
+	name := "bob"
+
+	values, err := NewArgs("key", &name, int32(3), 1.5, true, 1500*time.Millisecond, at).
+		AddSeconds(1500 * time.Millisecond).
+		AddSeconds(at).
+		AddFlat(user{Name: "alice", Age: 30, Secret: "x"}).
+		AddFlat(map[string]int{"b": 2, "a": 1}).
+		AddFlat([]string{"x", "y"}).
+		Values()
+
+	expected := []interface{}{
+		"key", "bob", int64(3), 1.5, true, int64(1500), int64(1500000000000),
+		int64(2), int64(1500000000),
+		"Name", "alice", "age", "30",
+		"a", 1, "b", 2,
+		"x", "y",
+	}
+
+	if err != nil || !reflect.DeepEqual(values, expected) {
+		t.Fatal(values, err)
+	}
+
+	if _, err := NewArgs("key", user{}).Values(); err == nil {
+		t.Fatal("expecting structs to require AddFlat")
+	}
+
+	if _, err := NewArgs(nil).Values(); err == nil {
+		t.Fatal("expecting nil to be rejected")
+	}
+
+	if _, err := new(Args).AddFlat(map[string]interface{}{"a": []int{1}}).Values(); err == nil {
+		t.Fatal("expecting nested values to be rejected")
+	}
+
+	// the encoder leaves the conversions to Args so that its encoding of other values is unchanged
+	values, err = NewArgs("key", 1500*time.Millisecond, uint16(7)).Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Marshal("PSETEX", values...)
+	if err != nil || string(data) != "*4\r\n$6\r\nPSETEX\r\n$3\r\nkey\r\n$4\r\n1500\r\n$1\r\n7\r\n" {
+		t.Fatal(string(data), err)
+	}
+}
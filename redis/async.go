@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+// finish marks the request as done and calls back the asynchronous sender if any.
+func (request *Request) finish() {
+	// the request belongs to the caller as soon as it is done
+	callback := request.callback
+	close(request.done)
+
+	if callback != nil {
+		callback()
+	}
+}
+
+// SendAsync sends the specified request to the Redis instance and calls back with its error once the reply is decoded.
+// It only blocks while the queue of the connection is full.
+// The callback runs on the goroutine reading the replies and must return quickly without waiting on the same node.
+func (conn *Conn) SendAsync(request *Request, callback func(err error)) {
+	conn.sendAsync(request, func(err error) {
+		if conn.Credentials == nil || !isAuthError(err) {
+			callback(err)
+			return
+		}
+
+		go func() {
+			callback(conn.reauthenticate(request))
+		}()
+	})
+}
+
+func (conn *Conn) sendAsync(request *Request, callback func(err error)) {
+	probe, err := conn.begin(request)
+	if err != nil {
+		callback(err)
+		return
+	}
+
+	start := conn.clock().Now()
+	request.callback = func() {
+		request.callback = nil
+		callback(conn.end(request, probe, start))
+	}
+
+	if err = conn.enqueue(request); err != nil {
+		request.callback = nil
+		request.err = err
+		callback(err)
+	}
+}
+
+// SendAsync sends the specified request without waiting for the reply and calls back with the result of its last command.
+// Requests sent straight to their node don't hold a goroutine while in flight: only those that are broadcast, hedged, streamed
+// or sent through middleware, as well as the ones that must be redirected or retried, continue in the background.
+// The callback may run on the goroutine reading the replies of the node and must return quickly.
+// Like with Send, the request must not be reused before the callback is called.
+func (client *Client) SendAsync(request *Request, callback func(result interface{}, err error)) {
+	done := func(err error) {
+		var result interface{}
+		if err == nil {
+			result = request.commands[len(request.commands)-1].result
+		}
+
+		callback(result, err)
+	}
+
+	state := client.load()
+	policy := client.keylessPolicy(request)
+
+	var node *Conn
+	slot := 0
+
+	sync := policy == KeylessBroadcast && request.Len() == 1 || client.Hedge != nil || len(client.Middleware) != 0 || client.Audit != nil || request.commands[0].stream
+	sync = sync || request.checkSlot() != nil || state.shards && (request.crossSlot() != nil || client.partition(state, request) != nil)
+	if !sync {
+		slot, node = client.target(state, policy, request)
+	}
+
+	if node == nil {
+		go func() {
+			done(client.Send(request))
+		}()
+
+		return
+	}
+
+	clock := client.clock()
+	start := clock.Now()
+	complete := func(node *Conn, err error) {
+		err = client.stale(slot, request, err)
+		client.observe(request, node, clock.Now().Sub(start))
+		if client.Shadow != nil {
+			client.Shadow.send(client.rand(), request)
+		}
+
+		done(err)
+	}
+
+	request.moved, request.redirect = false, false
+	request.routed = state
+	node.SendAsync(request, func(err error) {
+		if err == nil {
+			complete(node, nil)
+			return
+		}
+
+		go func() {
+			complete(client.resend(state, slot, policy, node, request, start, err))
+		}()
+	})
+}
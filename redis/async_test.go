@@ -0,0 +1,55 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestSendAsync(t *testing.T) {
+	db := new(mockDB)
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString(":1\r\n:2\r\n:3\r\n-ERR wrong\r\n")
+
+	type reply struct {
+		result interface{}
+		err    error
+	}
+
+	replies := make(chan reply, 4)
+	for i := 0; i < 4; i++ {
+		client.SendAsync(NewRequest("INCR", "a"), func(result interface{}, err error) {
+			replies <- reply{result, err}
+		})
+	}
+
+	for i := 1; i <= 3; i++ {
+		if r := <-replies; r.err != nil || r.result != int64(i) {
+			t.Fatal(r.result, r.err)
+		}
+	}
+
+	if r := <-replies; r.err == nil {
+		t.Fatal("expecting an error")
+	} else if _, ok := r.err.(ReplyError); !ok {
+		t.Fatal(r.err)
+	}
+
+	// the request is done once the connection called back
+	request := NewRequest("PING")
+	db.result.WriteString("+PONG\r\n")
+
+	errs := make(chan error)
+	client.Node("tcp://127.0.0.1:6379").SendAsync(request, func(err error) {
+		errs <- err
+	})
+
+	if err := <-errs; err != nil || request.commands[0].result != "PONG" {
+		t.Fatal(request.commands[0].result, err)
+	}
+}
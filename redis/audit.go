@@ -0,0 +1,197 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAuditPercentage defines the default percentage of the write commands that are audited.
+var DefaultAuditPercentage = 100.0
+
+// DefaultMaximumPendingAuditRecords defines the default number of audit records buffered before new ones are dropped.
+var DefaultMaximumPendingAuditRecords = 10000
+
+// DefaultCallerAnnotation defines the default annotation of the requests naming their caller in the audit records.
+var DefaultCallerAnnotation = "caller"
+
+// AuditRecord describes a write command sent by a client and whether it failed.
+type AuditRecord struct {
+	Time     time.Time
+	Command  string
+	Keys     []string
+	Caller   string
+	Node     string
+	Duration time.Duration
+	Err      error
+}
+
+// AuditSink is implemented to receive the audit records.
+// Records are passed one at a time in order on a separate goroutine.
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// Audit reports the write commands of a client to its Sink without blocking the requests.
+// Every request sent to a node is checked, including transactions, broadcasts, bulk loads and leased connections, and commands that aren't known are audited as writes.
+// Commands redirected to another node are audited once they reach it.
+// Only commands with a key matching one of the Patterns are audited when set, with the syntax of path.Match, along with those without keys like FLUSHALL.
+// The Percentage between 0 and 100 samples the commands and records are dropped when more than MaximumPendingRecords are waiting.
+type Audit struct {
+	Sink                  AuditSink
+	Patterns              []string
+	Percentage            float64
+	MaximumPendingRecords int
+
+	// CallerAnnotation is the annotation of the request naming its caller.
+	CallerAnnotation string
+
+	once    sync.Once
+	mu      sync.RWMutex
+	closed  bool
+	feed    chan AuditRecord
+	done    chan struct{}
+	dropped int64
+}
+
+// Dropped returns the number of audit records dropped because too many were pending.
+func (audit *Audit) Dropped() int64 {
+	return atomic.LoadInt64(&audit.dropped)
+}
+
+func (audit *Audit) initialize() {
+	pending := audit.MaximumPendingRecords
+	if 0 == pending {
+		pending = DefaultMaximumPendingAuditRecords
+	}
+
+	audit.feed = make(chan AuditRecord, pending)
+	audit.done = make(chan struct{})
+
+	go func() {
+		for record := range audit.feed {
+			audit.Sink.Audit(record)
+		}
+
+		close(audit.done)
+	}()
+}
+
+// record queues the audit records of the write commands of the request sent to the node until now.
+func (audit *Audit) record(r Rand, now time.Time, request *Request, node string, duration time.Duration) {
+	percentage := audit.Percentage
+	if 0 == percentage {
+		percentage = DefaultAuditPercentage
+	}
+
+	caller := audit.CallerAnnotation
+	if caller == "" {
+		caller = DefaultCallerAnnotation
+	}
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		name := strings.ToUpper(cmd.name)
+		if !writeCommands[name] && cmd.known() || IsRedirect(cmd.err) || !audit.matched(cmd) || r.Float64()*100 >= percentage {
+			continue
+		}
+
+		record := AuditRecord{
+			Time:     now,
+			Command:  name,
+			Caller:   request.annotations[caller],
+			Node:     node,
+			Duration: duration,
+			Err:      cmd.err,
+		}
+
+		if record.Err == nil {
+			record.Err = request.err
+		}
+
+		for _, j := range cmd.keys() {
+			record.Keys = append(record.Keys, argString(cmd.args[j]))
+		}
+
+		audit.once.Do(audit.initialize)
+		audit.send(record)
+	}
+}
+
+// send queues the record unless the audit is closed or too many records are pending.
+func (audit *Audit) send(record AuditRecord) {
+	audit.mu.RLock()
+	defer audit.mu.RUnlock()
+
+	if audit.closed {
+		return
+	}
+
+	select {
+	case audit.feed <- record:
+	default:
+		atomic.AddInt64(&audit.dropped, 1)
+	}
+}
+
+func (audit *Audit) matched(cmd *command) bool {
+	keys := cmd.keys()
+	if len(audit.Patterns) == 0 || len(keys) == 0 {
+		return true
+	}
+
+	for _, j := range keys {
+		key := argString(cmd.args[j])
+		for _, pattern := range audit.Patterns {
+			if matchKey(pattern, key) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// close waits for the pending records to be reported.
+func (audit *Audit) close() {
+	// make sure an audit that was never used won't start
+	audit.once.Do(func() {})
+
+	audit.mu.Lock()
+	closing := !audit.closed && audit.feed != nil
+	if closing {
+		close(audit.feed)
+	}
+
+	audit.closed = true
+	audit.mu.Unlock()
+
+	if closing {
+		<-audit.done
+	}
+}
+
+// AuditLog implements an AuditSink writing a line per record.
+type AuditLog struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+// Audit writes the record with the time, command, keys, caller, node, duration and outcome separated by spaces.
+func (sink *AuditLog) Audit(record AuditRecord) {
+	outcome := "OK"
+	if record.Err != nil {
+		outcome = fmt.Sprintf("%q", record.Err.Error())
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	fmt.Fprintf(sink.W, "%s %s %q %q %s %s %s\n", record.Time.UTC().Format(time.RFC3339Nano), record.Command, strings.Join(record.Keys, " "), record.Caller, record.Node, record.Duration, outcome)
+}
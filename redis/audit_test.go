@@ -0,0 +1,92 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	db := new(mockDB)
+	var buffer bytes.Buffer
+
+	client := &Client{
+		Audit: &Audit{
+			Sink:     &AuditLog{W: &buffer},
+			Patterns: []string{"user:*"},
+		},
+	}
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n$1\r\n1\r\n+OK\r\n-ERR wrong\r\n")
+
+	request := NewRequest("SET", "user:1", "a")
+	request.Annotate("caller", "signup")
+	client.Send(request)
+
+	client.Do("GET", "user:1")
+	client.Do("SET", "other", "1")
+	client.Do("DEL", "user:2")
+
+	// closing reports the pending records
+	client.Close()
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal(lines)
+	}
+
+	if !strings.Contains(lines[0], ` SET "user:1" "signup" tcp://127.0.0.1:6379 `) || !strings.HasSuffix(lines[0], " OK") {
+		t.Fatal(lines[0])
+	}
+
+	if !strings.Contains(lines[1], ` DEL "user:2" "" `) || !strings.HasSuffix(lines[1], `ERR wrong"`) {
+		t.Fatal(lines[1])
+	}
+}
+
+func TestAuditNodePaths(t *testing.T) {
+	db := new(mockDB)
+	var buffer bytes.Buffer
+
+	client := &Client{
+		Audit: &Audit{
+			Sink:     &AuditLog{W: &buffer},
+			Patterns: []string{"user:*"},
+		},
+		KeylessPolicies: map[string]KeylessPolicy{
+			"FLUSHALL": KeylessBroadcast,
+		},
+	}
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n+OK\r\n+OK\r\n+QUEUED\r\n*1\r\n:1\r\n")
+
+	// the broadcast looks for the masters of a cluster first
+	db.result.WriteString("-ERR This instance has cluster support disabled\r\n+OK\r\n")
+
+	client.Do("SELECT", 0)
+	client.Do("CLIENT", "SETNAME", "user:1")
+
+	client.Transaction("user:1", func(tx *Tx) error {
+		return tx.Queue("INCR", "user:1")
+	})
+
+	client.Do("FLUSHALL")
+	client.Close()
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], ` INCR "user:1" `) {
+		t.Fatal(lines)
+	}
+
+	if !strings.Contains(lines[1], ` FLUSHALL "" `) || !strings.HasSuffix(lines[1], " OK") {
+		t.Fatal(lines[1])
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultBreakerWindow defines the default number of requests over which the error rate of a node is measured.
+var DefaultBreakerWindow = 20
+
+// DefaultBreakerDuration defines the default duration a circuit stays open before probing the node.
+var DefaultBreakerDuration = 5 * time.Second
+
+// DefaultBreakerProbes defines the default number of requests that must succeed to close an open circuit.
+var DefaultBreakerProbes = 1
+
+// ErrCircuitOpen is returned without sending the request when the circuit breaker of the node is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Breaker configures the circuit breaker of a connection.
+// The circuit opens when the error rate over a window of requests reaches the threshold and requests then fail right away.
+// Once the duration has elapsed, probe requests are let through and the circuit closes when they all succeed.
+// Errors replied by Redis don't count as failures.
+type Breaker struct {
+	// Threshold is the error rate between 0 and 1 that opens the circuit.
+	// The breaker is disabled when left to 0.
+	Threshold float64
+	Window    int
+	Duration  time.Duration
+	Probes    int
+}
+
+type circuit struct {
+	mu     sync.Mutex
+	open   bool
+	until  time.Time
+	trials int
+	passed int
+	total  int
+	errors int
+}
+
+// allow returns true when the request can be sent and whether it is probing an open circuit.
+func (conn *Conn) allow() (ok, probe bool) {
+	if conn.Breaker.Threshold == 0 {
+		ok = true
+		return
+	}
+
+	c := &conn.circuit
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.open {
+		ok = true
+		return
+	}
+
+	if conn.clock().Now().Before(c.until) || c.trials >= conn.probes() {
+		return
+	}
+
+	c.trials++
+	ok, probe = true, true
+	return
+}
+
+// record updates the circuit with the outcome of a request.
+func (conn *Conn) record(probe, failed bool) {
+	if conn.Breaker.Threshold == 0 {
+		return
+	}
+
+	c := &conn.circuit
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.open {
+		// ignore requests that were in-flight when the circuit opened
+		if !probe {
+			return
+		}
+
+		if failed {
+			conn.trip()
+			return
+		}
+
+		if c.passed++; c.passed >= conn.probes() {
+			c.open = false
+		}
+
+		return
+	}
+
+	c.total++
+	if failed {
+		c.errors++
+	}
+
+	window := conn.Breaker.Window
+	if 0 == window {
+		window = DefaultBreakerWindow
+	}
+
+	if c.total < window {
+		return
+	}
+
+	if float64(c.errors) >= conn.Breaker.Threshold*float64(c.total) {
+		conn.trip()
+	}
+
+	c.total, c.errors = 0, 0
+}
+
+func (conn *Conn) trip() {
+	duration := conn.Breaker.Duration
+	if 0 == duration {
+		duration = DefaultBreakerDuration
+	}
+
+	c := &conn.circuit
+	c.open = true
+	c.until = conn.clock().Now().Add(duration)
+	c.trials, c.passed = 0, 0
+}
+
+func (conn *Conn) probes() int {
+	if n := conn.Breaker.Probes; n != 0 {
+		return n
+	}
+
+	return DefaultBreakerProbes
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{
+		Breaker: Breaker{
+			Threshold: 0.5,
+			Window:    2,
+			Duration:  time.Millisecond,
+		},
+		db: db,
+	}
+
+	defer conn.Close()
+
+	db.err = fmt.Errorf("failure")
+	for i := 0; i < 2; i++ {
+		if _, err := conn.Do("PING"); err == nil || err == ErrCircuitOpen {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := conn.Do("PING"); err != ErrCircuitOpen {
+		t.Fatal(err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	db.result.WriteString("+PONG\r\n")
+	db.err = nil
+	if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+		t.Fatal(err, result)
+	}
+
+	db.result.WriteString("+PONG\r\n")
+	if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+		t.Fatal(err, result)
+	}
+}
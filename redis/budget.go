@@ -0,0 +1,50 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// Budget bounds the time spent and the number of attempts made for a request across redirections, reconnections and retries.
+// Zero values leave the request unbounded except by the retry policy.
+// A single attempt, e.g. while reconnecting, is only bounded by the timeouts of the connection.
+type Budget struct {
+	Timeout         time.Duration
+	MaximumAttempts int
+}
+
+// BudgetError is returned with the last error when a request exhausted its budget before succeeding.
+type BudgetError struct {
+	Attempts int
+	Elapsed  time.Duration
+	Err      error
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("request budget exhausted after %d attempts in %s: %v", e.Attempts, e.Elapsed, e.Err)
+}
+
+// SetBudget overrides the budget of the client for the request e.g. to match the deadline of the caller.
+func (request *Request) SetBudget(budget Budget) {
+	request.budget = &budget
+}
+
+// budget returns the budget of the request.
+func (client *Client) budget(request *Request) Budget {
+	if request.budget != nil {
+		return *request.budget
+	}
+
+	return client.Budget
+}
+
+// exhausted returns true when another attempt after the delay would go over the budget.
+func (budget Budget) exhausted(attempt int, elapsed, delay time.Duration) bool {
+	if budget.MaximumAttempts != 0 && attempt >= budget.MaximumAttempts {
+		return true
+	}
+
+	return budget.Timeout != 0 && elapsed+delay >= budget.Timeout
+}
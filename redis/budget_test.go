@@ -0,0 +1,50 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	db := new(mockDB)
+	db.err = fmt.Errorf("failure")
+
+	attempts := 0
+	client := &Client{
+		MaximumConnectionRetries: 1,
+		RetryPolicy: RetryPolicyFunc(func(request *Request, attempt int, err error) (time.Duration, bool) {
+			attempts++
+			return time.Millisecond, true
+		}),
+		Budget: Budget{
+			MaximumAttempts: 3,
+		},
+	}
+
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	_, err := client.Do("GET", "key")
+	if e, ok := err.(*BudgetError); !ok || e.Attempts != 3 || attempts != 3 {
+		t.Fatal(attempts, err)
+	}
+
+	request := NewRequest("GET", "key")
+	request.SetBudget(Budget{
+		Timeout: 20 * time.Millisecond,
+	})
+
+	start := time.Now()
+	if err := client.Send(request); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	if _, ok := request.err.(*BudgetError); !ok || time.Since(start) >= time.Second {
+		t.Fatal(request.err, time.Since(start))
+	}
+}
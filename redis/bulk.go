@@ -0,0 +1,183 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultBulkBatchSize defines the default number of records pipelined to a node at once by a BulkLoader.
+var DefaultBulkBatchSize = 1000
+
+// DefaultMaximumConcurrentBatches defines the default number of batches sent concurrently by a BulkLoader.
+var DefaultMaximumConcurrentBatches = 16
+
+// Record defines a key and its value loaded with SET.
+// The key expires after TTL when it is set.
+type Record struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
+}
+
+// BulkLoader sets large numbers of keys by pipelining them in batches to the nodes that serve them.
+// Keys whose slot moved are sent again individually and follow the redirection.
+type BulkLoader struct {
+	Client *Client
+
+	BatchSize                int
+	MaximumConcurrentBatches int
+
+	// Progress is called after each batch is sent.
+	Progress func(BulkProgress)
+}
+
+// BulkProgress defines the outcome of a batch and the state of the load.
+type BulkProgress struct {
+	// Node is the address where the batch of Records was sent.
+	Node    string
+	Records int
+
+	// Failed is the number of records of the batch that weren't set and Err is the first failure.
+	Failed int
+	Err    error
+
+	Duration time.Duration
+
+	// Loaded is the number of records set so far and Rate is the number of records set per second.
+	Loaded int
+	Rate   float64
+}
+
+// bulkBatch holds the records sent to a node.
+type bulkBatch struct {
+	node    *Conn
+	request *Request
+}
+
+// Load sets the records received until the channel is closed.
+// It returns the number of records set and the first failure.
+func (loader *BulkLoader) Load(records <-chan Record) (loaded int, err error) {
+	return loader.LoadFunc(func() (record Record, ok bool) {
+		record, ok = <-records
+		return
+	})
+}
+
+// LoadFunc sets the records returned by next until it returns false.
+// It returns the number of records set and the first failure.
+func (loader *BulkLoader) LoadFunc(next func() (Record, bool)) (loaded int, err error) {
+	size := loader.BatchSize
+	if 0 == size {
+		size = DefaultBulkBatchSize
+	}
+
+	workers := loader.MaximumConcurrentBatches
+	if 0 == workers {
+		workers = DefaultMaximumConcurrentBatches
+	}
+
+	var mu sync.Mutex
+	start := time.Now()
+
+	feed := make(chan bulkBatch, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			for batch := range feed {
+				progress := loader.send(batch)
+
+				mu.Lock()
+				loaded += progress.Records - progress.Failed
+				if err == nil {
+					err = progress.Err
+				}
+
+				if loader.Progress != nil {
+					progress.Loaded = loaded
+					progress.Rate = float64(loaded) / time.Since(start).Seconds()
+					loader.Progress(progress)
+				}
+
+				mu.Unlock()
+			}
+
+			wg.Done()
+		}()
+	}
+
+	// group the records by node
+	pending := make(map[*Conn]*Request)
+	for {
+		record, ok := next()
+		if !ok {
+			break
+		}
+
+		node := loader.Client.serving(record.Key)
+		request := pending[node]
+		if request == nil {
+			request = new(Request)
+			pending[node] = request
+		}
+
+		if record.TTL > 0 {
+			request.Add("SET", record.Key, record.Value, "PX", int64(record.TTL/time.Millisecond))
+		} else {
+			request.Add("SET", record.Key, record.Value)
+		}
+
+		if request.Len() >= size {
+			delete(pending, node)
+			feed <- bulkBatch{node, request}
+		}
+	}
+
+	for node, request := range pending {
+		feed <- bulkBatch{node, request}
+	}
+
+	close(feed)
+	wg.Wait()
+	return
+}
+
+// send pipelines the batch to its node and sends the redirected records again through the client.
+func (loader *BulkLoader) send(batch bulkBatch) (progress BulkProgress) {
+	start := time.Now()
+	request := batch.request
+
+	progress.Records = request.Len()
+	if batch.node == nil {
+		loader.Client.Send(request)
+	} else {
+		progress.Node = batch.node.address
+		loader.Client.sendNode(batch.node, request)
+	}
+
+	// the whole batch is lost when the node couldn't reply
+	if _, ok := request.err.(ReplyError); request.err != nil && !ok {
+		progress.Failed, progress.Err = progress.Records, request.err
+		progress.Duration = time.Since(start)
+		return
+	}
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		if IsRedirect(cmd.err) {
+			cmd.err = loader.Client.Send(NewRequest(cmd.name, cmd.args...))
+		}
+
+		if cmd.err != nil {
+			if progress.Failed++; progress.Err == nil {
+				progress.Err = cmd.err
+			}
+		}
+	}
+
+	progress.Duration = time.Since(start)
+	return
+}
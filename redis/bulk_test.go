@@ -0,0 +1,51 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBulkLoader(t *testing.T) {
+	db := new(mockDB)
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n+OK\r\n+OK\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n+OK\r\n")
+
+	records := make(chan Record)
+	go func() {
+		for i := 0; i < 5; i++ {
+			records <- Record{Key: fmt.Sprint(i), Value: i, TTL: time.Minute}
+		}
+
+		close(records)
+	}()
+
+	batches, failed := 0, 0
+	loader := &BulkLoader{
+		Client:    client,
+		BatchSize: 2,
+		Progress: func(progress BulkProgress) {
+			batches++
+			failed += progress.Failed
+			if progress.Node != "tcp://127.0.0.1:6379" {
+				t.Error(progress.Node)
+			}
+		},
+	}
+
+	loaded, err := loader.Load(records)
+	if _, ok := err.(ReplyError); !ok {
+		t.Fatal(err)
+	}
+
+	if loaded != 4 || batches != 3 || failed != 1 {
+		t.Fatal(loaded, batches, failed)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultBusyTimeout defines the default time spent waiting for a busy node before giving up on the request.
+var DefaultBusyTimeout = 5 * time.Second
+
+// DefaultBusyRetryDelay defines the delay between the attempts made while a node is busy.
+var DefaultBusyRetryDelay = 50 * time.Millisecond
+
+// BusyPolicy defines what is done when a node replies BUSY because a script or a function runs for too long.
+type BusyPolicy int
+
+const (
+	// BusyFail returns the BUSY error right away.
+	BusyFail BusyPolicy = iota
+
+	// BusyWait sends the request again until the node is no longer busy or BusyTimeout is over.
+	BusyWait
+
+	// BusyKill stops the script with SCRIPT KILL, or the function with FUNCTION KILL, on a dedicated connection before waiting.
+	// Redis refuses to stop a script that already wrote to the database so it is then waited for as with BusyWait.
+	BusyKill
+)
+
+// IsBusy returns true when Redis replied that it is running a script or a function for too long to serve the command.
+func IsBusy(err error) bool {
+	e, ok := err.(ReplyError)
+	return ok && strings.HasPrefix(string(e), "BUSY ")
+}
+
+// busy returns true when every command was rejected because the node is busy i.e. none of them ran and the request can be sent again.
+func (request *Request) busy() bool {
+	for i := range request.commands {
+		if !IsBusy(request.commands[i].err) {
+			return false
+		}
+	}
+
+	return len(request.commands) != 0
+}
+
+// unwedge sends the request again to the busy node according to the busy policy of the client.
+// It returns the last error of the request once the node is no longer busy or the wait is over.
+func (client *Client) unwedge(node *Conn, request *Request, start time.Time) (err error) {
+	timeout := client.BusyTimeout
+	if 0 == timeout {
+		timeout = DefaultBusyTimeout
+	}
+
+	if budget := client.budget(request); budget.Timeout != 0 && budget.Timeout < timeout {
+		timeout = budget.Timeout
+	}
+
+	err = request.err
+	if client.BusyPolicy == BusyKill {
+		client.kill(node, err)
+	}
+
+	clock := client.clock()
+	for request.busy() && clock.Now().Sub(start)+DefaultBusyRetryDelay < timeout {
+		clock.Sleep(DefaultBusyRetryDelay)
+
+		request.moved, request.redirect = false, false
+		err = client.sendNode(node, request)
+	}
+
+	return
+}
+
+// kill stops the script or the function keeping the node busy on a dedicated connection.
+// The shared connection can't be used since its replies may be held behind a blocking command.
+func (client *Client) kill(node *Conn, busy error) {
+	command := "SCRIPT"
+	if strings.Contains(busy.Error(), "FUNCTION KILL") {
+		command = "FUNCTION"
+	}
+
+	conn := client.lease(node)
+	defer conn.Close()
+
+	// the script may have completed or written to the database in the meantime
+	conn.Do(command, "KILL")
+}
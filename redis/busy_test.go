@@ -0,0 +1,51 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBusy(t *testing.T) {
+	const busy = "-BUSY Redis is busy running a script. You can only call SCRIPT KILL or SHUTDOWN NOSAVE.\r\n"
+
+	db := new(mockDB)
+	db.result.WriteString(busy)
+
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	if _, err := client.Do("SET", "key", "value"); !IsBusy(err) {
+		t.Fatal(err)
+	}
+
+	client.BusyPolicy = BusyWait
+	db.result.WriteString(busy + busy + "+OK\r\n")
+
+	if _, err := client.Do("SET", "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// SCRIPT KILL is written on another connection before the request is sent again
+	client.BusyPolicy = BusyKill
+	db.result.WriteString(busy + "+OK\r\n")
+
+	writes := atomic.LoadInt32(&db.writes)
+	if _, err := client.Do("SET", "key", "value"); err != nil || atomic.LoadInt32(&db.writes) != writes+3 {
+		t.Fatal(err, atomic.LoadInt32(&db.writes)-writes)
+	}
+
+	client.BusyPolicy, client.BusyTimeout = BusyWait, 120*time.Millisecond
+	for i := 0; i < 10; i++ {
+		db.result.WriteString(busy)
+	}
+
+	if _, err := client.Do("SET", "key", "value"); !IsBusy(err) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"path"
+	"sync/atomic"
+	"time"
+)
+
+// Canary splits the requests between a primary and an alternate client e.g. to migrate gradually to a new cluster or to compare configurations.
+// Requests with a key matching one of the Patterns go to the alternate client while others go there with the Percentage between 0 and 100.
+// The percentage applies to slots so that the requests for a key, or keys sharing a {tag}, always go to the same client.
+// Patterns use the syntax of path.Match e.g. session:*.
+type Canary struct {
+	Primary    *Client
+	Alternate  *Client
+	Percentage float64
+	Patterns   []string
+
+	primary   canaryCounters
+	alternate canaryCounters
+}
+
+// CanaryStats holds the statistics of the requests sent to each client of a canary.
+type CanaryStats struct {
+	Primary   TargetStats
+	Alternate TargetStats
+}
+
+// TargetStats holds the number of requests sent to a client, how many failed and the total time spent waiting for them.
+type TargetStats struct {
+	Requests int64
+	Errors   int64
+	Duration time.Duration
+}
+
+type canaryCounters struct {
+	requests int64
+	errors   int64
+	duration int64
+}
+
+func (c *canaryCounters) stats() TargetStats {
+	return TargetStats{
+		Requests: atomic.LoadInt64(&c.requests),
+		Errors:   atomic.LoadInt64(&c.errors),
+		Duration: time.Duration(atomic.LoadInt64(&c.duration)),
+	}
+}
+
+// Do executes the specified command (with optional arguments) on the client selected for it and waits to decode the reply.
+func (canary *Canary) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := newRequest(name, args)
+	if err = canary.Send(request); err == nil {
+		result = request.commands[len(request.commands)-1].result
+	}
+
+	release(request)
+	return
+}
+
+// Send sends the specified request to the client selected for it and waits for the reply.
+func (canary *Canary) Send(request *Request) (err error) {
+	client, counters := canary.Primary, &canary.primary
+	if canary.selected(request) {
+		client, counters = canary.Alternate, &canary.alternate
+	}
+
+	clock := canary.Primary.clock()
+	start := clock.Now()
+	err = client.Send(request)
+
+	atomic.AddInt64(&counters.requests, 1)
+	atomic.AddInt64(&counters.duration, int64(clock.Now().Sub(start)))
+	if err != nil {
+		atomic.AddInt64(&counters.errors, 1)
+	}
+
+	return
+}
+
+// Stats returns the statistics of the requests sent to each client.
+func (canary *Canary) Stats() CanaryStats {
+	return CanaryStats{
+		Primary:   canary.primary.stats(),
+		Alternate: canary.alternate.stats(),
+	}
+}
+
+// selected returns true when the request is selected for the alternate client.
+func (canary *Canary) selected(request *Request) bool {
+	key, ok := request.firstKey()
+	if !ok {
+		return canary.Primary.rand().Float64()*100 < canary.Percentage
+	}
+
+	for _, pattern := range canary.Patterns {
+		if matchKey(pattern, key) {
+			return true
+		}
+	}
+
+	return float64(Slot(key))*100 < canary.Percentage*16384
+}
+
+// firstKey returns the key routing the request.
+func (request *Request) firstKey() (key string, ok bool) {
+	if len(request.key) != 0 {
+		return string(request.key), true
+	}
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		if keys := cmd.keys(); len(keys) != 0 {
+			return argString(cmd.args[keys[0]]), true
+		}
+	}
+
+	return
+}
+
+func matchKey(pattern, key string) bool {
+	ok, err := path.Match(pattern, key)
+	return ok && err == nil
+}
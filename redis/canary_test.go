@@ -0,0 +1,68 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestCanary(t *testing.T) {
+	canary := &Canary{
+		Primary:    new(Client),
+		Percentage: 50,
+		Patterns:   []string{"session:*"},
+	}
+
+	for _, item := range []struct {
+		request   *Request
+		alternate bool
+	}{
+		{NewRequest("GET", "session:1"), true},
+		{NewRequest("GET", "{a}.x"), Slot("a") < 8192},
+		{NewRequest("GET", "{b}.x"), Slot("b") < 8192},
+		{NewRequest("SET", "{b}.y", "1"), Slot("b") < 8192},
+	} {
+		if canary.selected(item.request) != item.alternate {
+			t.Fatal(item.request)
+		}
+	}
+
+	canary.Percentage = 0
+	if canary.selected(NewRequest("PING")) || !canary.selected(NewRequest("DEL", "session:1")) {
+		t.Fatal("unexpected split")
+	}
+}
+
+func TestCanaryStats(t *testing.T) {
+	var dbs []*mockDB
+	var clients []*Client
+	for i := 0; i < 2; i++ {
+		db := new(mockDB)
+		client := new(Client)
+		client.load()
+		client.nodes["tcp://127.0.0.1:6379"].db = db
+
+		defer client.Close()
+
+		dbs = append(dbs, db)
+		clients = append(clients, client)
+	}
+
+	canary := &Canary{
+		Primary:   clients[0],
+		Alternate: clients[1],
+		Patterns:  []string{"new:*"},
+	}
+
+	dbs[0].result.WriteString("$3\r\nold\r\n")
+	dbs[1].result.WriteString("$3\r\nnew\r\n-ERR failed\r\n")
+
+	for _, key := range []string{"a", "new:a", "new:b"} {
+		canary.Do("GET", key)
+	}
+
+	stats := canary.Stats()
+	if stats.Primary.Requests != 1 || stats.Primary.Errors != 0 || stats.Alternate.Requests != 2 || stats.Alternate.Errors != 1 {
+		t.Fatal(stats)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Chaos implements a middleware that injects faults in the requests sent to nodes for resilience testing.
+// Each rate is the probability that a request gets the fault and rates add up e.g. an error rate of 0.1 and a moved rate of 0.1 fail 20% of requests.
+type Chaos struct {
+	// Nodes lists the addresses of the nodes that get faults or every node when empty.
+	Nodes []string
+
+	// Latency is added before sending every request to the nodes.
+	Latency time.Duration
+
+	// ErrorRate fails requests without sending them as if the connection was dropped.
+	ErrorRate float64
+
+	// MovedRate and AskRate redirect requests to Redirect or back to the same node when empty.
+	MovedRate float64
+	AskRate   float64
+	Redirect  string
+
+	// PartialRate fails one of the commands of the request with an error after it was sent.
+	PartialRate float64
+}
+
+// ErrChaos is the error of the commands failed by PartialRate.
+var ErrChaos = ReplyError("ERR injected failure")
+
+// Send injects faults in the request when it is sent to one of the nodes.
+func (chaos *Chaos) Send(node *Conn, request *Request, next Sender) (err error) {
+	if !chaos.targets(node) {
+		return next.Send(request)
+	}
+
+	node.clock().Sleep(chaos.Latency)
+
+	p := node.rand().Float64()
+	if p -= chaos.ErrorRate; p < 0 {
+		err = chaos.fail(request, io.ErrUnexpectedEOF)
+		return
+	}
+
+	if p -= chaos.MovedRate; p < 0 {
+		err = chaos.redirect(node, request, "MOVED")
+		return
+	}
+
+	if p -= chaos.AskRate; p < 0 {
+		err = chaos.redirect(node, request, "ASK")
+		return
+	}
+
+	err = next.Send(request)
+
+	if p -= chaos.PartialRate; p < 0 && err == nil {
+		i := node.rand().Intn(len(request.commands))
+		request.commands[i].result, request.commands[i].err = nil, ErrChaos
+		request.err = ErrChaos
+		err = ErrChaos
+	}
+
+	return
+}
+
+func (chaos *Chaos) targets(node *Conn) bool {
+	if len(chaos.Nodes) == 0 {
+		return true
+	}
+
+	for _, address := range chaos.Nodes {
+		if address == node.address {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (chaos *Chaos) fail(request *Request, err error) error {
+	for i := range request.commands {
+		request.commands[i].result, request.commands[i].err = nil, err
+	}
+
+	request.err = err
+	return err
+}
+
+// redirect fails the request with a redirection like a node replying MOVED or ASK.
+func (chaos *Chaos) redirect(node *Conn, request *Request, kind string) error {
+	address := chaos.Redirect
+	if address == "" {
+		address = node.address
+	}
+
+	host := strings.TrimPrefix(address, "tcp://")
+	chaos.fail(request, ReplyError(fmt.Sprintf("%s %d %s", kind, request.slot(), host)))
+	request.moved = kind == "MOVED"
+	request.redirect = true
+	request.address = "tcp://" + host
+	return request.err
+}
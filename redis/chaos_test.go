@@ -0,0 +1,66 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	db := new(mockDB)
+	chaos := new(Chaos)
+
+	client := &Client{
+		MaximumRedirections: 2,
+		Middleware:          []Middleware{chaos},
+	}
+
+	defer client.Close()
+
+	client.load()
+	node := client.nodes["tcp://127.0.0.1:6379"]
+	node.db = db
+
+	// requests to other nodes are left alone
+	chaos.Nodes = []string{"tcp://127.0.0.1:7000"}
+	chaos.ErrorRate = 1
+	db.result.WriteString("+PONG\r\n")
+	if _, err := client.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	chaos.Nodes = nil
+	if _, err := client.Do("PING"); err != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+
+	// the redirections are followed until the client gives up
+	chaos.ErrorRate, chaos.AskRate = 0, 1
+	db.result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n")
+	if _, err := client.Do("GET", "foo"); !IsRedirect(err) {
+		t.Fatal(err)
+	}
+
+	chaos.AskRate, chaos.PartialRate, chaos.Latency = 0, 1, 10*time.Millisecond
+	db.result.WriteString("+OK\r\n+OK\r\n")
+
+	request := NewRequest("SET", "a", "1")
+	request.Add("SET", "a", "2")
+
+	start := time.Now()
+	if err := client.Send(request); err != ErrChaos {
+		t.Fatal(err)
+	}
+
+	if time.Since(start) < chaos.Latency {
+		t.Fatal("expecting some latency")
+	}
+
+	_, a := request.Result(0)
+	_, b := request.Result(1)
+	if (a == nil) == (b == nil) {
+		t.Fatal("expecting one of the commands to fail", a, b)
+	}
+}
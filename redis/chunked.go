@@ -0,0 +1,115 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io"
+)
+
+// DefaultChunkSize defines the default number of bytes sent with each command by SetChunked and AppendChunked.
+var DefaultChunkSize = 64 * 1024
+
+// SetChunked sets the value of the key to the content of the reader sent in chunks of DefaultChunkSize with SET and SETRANGE.
+// Other requests are sent between the chunks so that a large value doesn't hold the connection but they may see a partial value.
+// It returns the number of bytes written.
+func (client *Client) SetChunked(key string, r io.Reader) (n int64, err error) {
+	return client.writeChunked(key, r, func(offset int64, chunk []byte) (err error) {
+		if offset == 0 {
+			err = expectOK(client.Do("SET", key, chunk))
+		} else {
+			_, err = client.Do("SETRANGE", key, offset, chunk)
+		}
+
+		return
+	})
+}
+
+// AppendChunked appends the content of the reader to the value of the key in chunks of DefaultChunkSize with APPEND.
+// It returns the number of bytes appended.
+func (client *Client) AppendChunked(key string, r io.Reader) (n int64, err error) {
+	return client.writeChunked(key, r, func(offset int64, chunk []byte) (err error) {
+		if len(chunk) != 0 {
+			_, err = client.Do("APPEND", key, chunk)
+		}
+
+		return
+	})
+}
+
+// GetChunked returns a reader over the value of the key that reads chunks of the specified size, or DefaultChunkSize when 0, with GETRANGE.
+// A key that doesn't exist reads like an empty value and changes made while reading may be seen partially.
+func (client *Client) GetChunked(key string, chunkSize int) io.Reader {
+	if 0 == chunkSize {
+		chunkSize = DefaultChunkSize
+	}
+
+	return &chunkReader{
+		client: client,
+		key:    key,
+		buffer: make([]byte, chunkSize),
+	}
+}
+
+// writeChunked calls the function with each chunk of the reader and its offset, and at least once.
+func (client *Client) writeChunked(key string, r io.Reader, f func(offset int64, chunk []byte) error) (n int64, err error) {
+	buffer := make([]byte, DefaultChunkSize)
+	for {
+		k, e := io.ReadFull(r, buffer)
+		if e != nil && e != io.EOF && e != io.ErrUnexpectedEOF {
+			err = e
+			return
+		}
+
+		if k != 0 || n == 0 {
+			if err = f(n, buffer[:k]); err != nil {
+				return
+			}
+		}
+
+		if n += int64(k); e != nil {
+			return
+		}
+	}
+}
+
+// chunkReader reads a value with GETRANGE in chunks the size of its buffer, which is reused for each of them.
+type chunkReader struct {
+	client  *Client
+	key     string
+	offset  int64
+	buffer  []byte
+	pending []byte
+	err     error
+}
+
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.fetch()
+	}
+
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return
+}
+
+// fetch reads the next chunk and ends with io.EOF once a chunk is shorter than the buffer.
+func (r *chunkReader) fetch() {
+	size := int64(len(r.buffer))
+
+	request := NewRequest("GETRANGE", r.key, r.offset, r.offset+size-1)
+	request.Buffer(0, r.buffer)
+
+	if r.err = r.client.Send(request); r.err != nil {
+		return
+	}
+
+	result, _ := request.Result(0)
+	r.pending, _ = result.([]byte)
+	if r.offset += int64(len(r.pending)); int64(len(r.pending)) < size {
+		r.err = io.EOF
+	}
+}
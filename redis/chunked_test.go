@@ -0,0 +1,58 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChunked(t *testing.T) {
+	defer func(size int) {
+		DefaultChunkSize = size
+	}(DefaultChunkSize)
+
+	DefaultChunkSize = 4
+
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n:8\r\n:10\r\n")
+	if n, err := client.SetChunked("foo", strings.NewReader("0123456789")); err != nil || n != 10 {
+		t.Fatal(n, err)
+	}
+
+	if writes := atomic.LoadInt32(&db.writes); writes != 3 {
+		t.Fatal(writes)
+	}
+
+	db.result.WriteString("+OK\r\n")
+	if n, err := client.SetChunked("empty", strings.NewReader("")); err != nil || n != 0 {
+		t.Fatal(n, err)
+	}
+
+	db.result.WriteString(":14\r\n:16\r\n")
+	if n, err := client.AppendChunked("foo", strings.NewReader("abcdef")); err != nil || n != 6 {
+		t.Fatal(n, err)
+	}
+
+	db.result.WriteString("$4\r\n0123\r\n$4\r\n4567\r\n$2\r\n89\r\n")
+	data, err := ioutil.ReadAll(client.GetChunked("foo", 4))
+	if err != nil || !bytes.Equal(data, []byte("0123456789")) {
+		t.Fatal(string(data), err)
+	}
+
+	db.result.WriteString("$4\r\n0123\r\n$0\r\n\r\n")
+	data, err = ioutil.ReadAll(client.GetChunked("foo", 4))
+	if err != nil || string(data) != "0123" {
+		t.Fatal(string(data), err)
+	}
+}
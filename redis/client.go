@@ -3,11 +3,13 @@
 package redis
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"net"
-	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,193 +23,519 @@ var DefaultMaximumRedirections = 16
 // DefaultMaximumSlotUpdates defines the number of MOVED it takes for the client to request a full resync of the cluster state.
 var DefaultMaximumSlotUpdates = 4
 
+// DefaultReconfigureInterval defines the default minimum delay between two full resyncs of the cluster state.
+var DefaultReconfigureInterval = 100 * time.Millisecond
+
+// ErrThrottled is returned when a full resync of the cluster state is skipped because the last one is more recent than ReconfigureInterval.
+var ErrThrottled = errors.New("cluster state resynced too recently")
+
 // Client implements a client to the Redis database or cluster.
 // This client always starts as a normal connection and migrates to handling cluster transparently when required.
-// The first address is used to connect while the others can be used as alternatives in case of failure.
+// The addresses are dialed concurrently at first to connect with the first to reply while the others can be used as alternatives in case of failure.
 type Client struct {
 	Address                   []string
 	MaximumRedirections       int
 	MaximumSlotUpdates        int
+	ReconfigureInterval       time.Duration
 	MaximumConcurrentRequests int
 	MaximumPendingRequests    int
 	MaximumConnectionRetries  int
 	RetryTimeout              time.Duration
 
-	lua map[string]string
+	// MaximumNodeFailures is the number of consecutive failures after which a node is quarantined and probed every ProbeInterval.
+	MaximumNodeFailures int
+	ProbeInterval       time.Duration
+
+	// HealthCheckInterval optionally PINGs every known node at this interval to mark those failing as unhealthy.
+	HealthCheckInterval time.Duration
+
+	// VerifyRoles checks with ROLE that the masters of the cluster are still masters every time they connect and the mapping changes.
+	// The mapping is refreshed from the other nodes when a master was demoted instead of waiting for a redirection.
+	VerifyRoles bool
+
+	// TopologyQuorum is the number of nodes asked for the mapping of the cluster when it is refreshed.
+	// The mapping only changes when more than half of them agree, which prevents flapping when a partitioned node has a stale view.
+	// By default, a single node is asked to refresh it as fast as possible.
+	TopologyQuorum int
+
+	// DrainInterval is the delay between the checks of the pending requests of the nodes that left the cluster before closing them.
+	DrainInterval time.Duration
+
+	// Breaker configures the circuit breaker of each node.
+	Breaker Breaker
+
+	// FailFast returns ErrOverloaded instead of blocking when a node already has too many pending requests.
+	FailFast        bool
+	FailFastTimeout time.Duration
+
+	// MaximumOfflineRequests is the number of requests held by each node while it is unreachable.
+	MaximumOfflineRequests int
+	OfflineTimeout         time.Duration
+
+	// MaximumReplySize is the number of bytes of the largest reply accepted from a node.
+	MaximumReplySize int64
+
+	// StrictProtocol validates the framing of the replies of a node.
+	StrictProtocol bool
+
+	// NoEvict and NoTouch set the CLIENT NO-EVICT and CLIENT NO-TOUCH modes on every connection, which requires Redis 7.0 and 7.2.
+	NoEvict bool
+	NoTouch bool
+
+	// KeepWarmInterval optionally PINGs the connections idle for that long to keep them open.
+	KeepWarmInterval time.Duration
+
+	// PriorityWeight is the number of Interactive requests sent for each Batch request queued on a node.
+	PriorityWeight int
+
+	// MaximumBatchSize is the number of pending requests written at once to a node.
+	// FlushInterval optionally delays writing a request to give the next ones a chance to be written with it.
+	MaximumBatchSize int
+	FlushInterval    time.Duration
+
+	// TopologyStore optionally saves the mapping of the cluster to route requests right away when the client starts again.
+	TopologyStore TopologyStore
+
+	// Discovery optionally finds the addresses, which are resolved again every DiscoveryInterval or when it signals a change.
+	Discovery         Discovery
+	DiscoveryInterval time.Duration
+
+	// SeedDialDelay is the delay before dialing the next address when the previous ones haven't connected yet.
+	SeedDialDelay time.Duration
+
+	// DialTimeout, ReadTimeout and WriteTimeout optionally bound the time it takes to connect to, read from and write to a node.
+	// They are overridden by the options in the query of an address.
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// TLSConfig optionally encrypts the connections.
+	TLSConfig *tls.Config
+
+	// Dialer optionally replaces the network to connect to the named nodes e.g. with a simulation in tests.
+	// The timeouts, encryption and socket options of the client don't apply to its connections.
+	Dialer func(address string) (net.Conn, error)
+
+	// KeepAlive is the period of the TCP keep-alive probes detecting connections silently dropped by NAT gateways or load balancers.
+	// It defaults to the period of the Go runtime and a negative period disables them.
+	KeepAlive time.Duration
+
+	// DelayWrites disables TCP_NODELAY to let the kernel coalesce small writes.
+	DelayWrites bool
+
+	// ReadBufferSize and WriteBufferSize optionally set the size of the socket buffers.
+	ReadBufferSize  int
+	WriteBufferSize int
+
+	// Credentials optionally provides the credentials used to authenticate with each node.
+	Credentials CredentialsProvider
+
+	// RetryPolicy decides when failed requests are sent again unless overridden by RetryPolicies.
+	// It defaults to following up to MaximumRedirections redirections.
+	RetryPolicy   RetryPolicy
+	RetryPolicies map[string]RetryPolicy
+
+	// BusyPolicy defines what is done when a node is busy running a script with BusyTimeout bounding the wait.
+	BusyPolicy  BusyPolicy
+	BusyTimeout time.Duration
+
+	// Budget optionally bounds the time spent and the attempts made for each request unless overridden by the request.
+	Budget Budget
+
+	// KeylessPolicy defines where commands without keys are sent unless overridden by KeylessPolicies.
+	KeylessPolicy   KeylessPolicy
+	KeylessPolicies map[string]KeylessPolicy
+
+	// AdminAddress is the node used by the KeylessAdmin policy.
+	AdminAddress string
+
+	// Router optionally selects the node where requests are sent instead of the slot mapping of the cluster.
+	Router Router
+
+	// Middleware optionally intercepts the requests sent to each node with the first being the outermost.
+	Middleware []Middleware
+
+	// Shadow optionally replays requests against another client.
+	Shadow *Shadow
+
+	// Audit optionally reports the write commands.
+	Audit *Audit
+
+	// Hedge optionally sends read-only requests to a replica when the master is slow to reply.
+	Hedge *Hedge
+
+	// ReplicaReads sends read-only requests to the replica of the slot with the lowest latency.
+	ReplicaReads bool
+
+	// MaximumIdleTransactions is the number of idle connections kept for transactions on each node.
+	MaximumIdleTransactions int
+
+	// FailStaleReplies fails with ErrStaleReply the requests whose slot moved to another node before their reply arrived.
+	// Otherwise they are only flagged as Stale.
+	FailStaleReplies bool
+
+	// SplitPipelines sends the commands of a request concurrently to the nodes serving their keys instead of all of them to the node of the first key.
+	// Commands sent to different nodes may run in any order and requests holding a transaction are never split.
+	SplitPipelines bool
+
+	// CrossSlotFallback lets CopyKey and RenameKey move keys between slots with DUMP and RESTORE, which Redis refuses to do with COPY and RENAME.
+	CrossSlotFallback bool
+
+	// SlowCommand is called with the command, key, node, duration and annotations of each command of requests slower than SlowCommandThreshold.
+	SlowCommand          func(command, key, node string, duration time.Duration, annotations map[string]string)
+	SlowCommandThreshold time.Duration
+
+	// Clock and Rand optionally replace the time and the random numbers of the client to reproduce its behavior in tests.
+	Clock Clock
+	Rand  Rand
+
+	lua        map[string]string
+	turn       uint32
+	refreshing int32
+
+	state     atomic.Value
+	refreshed time.Time
+	mu        sync.Mutex
+	once      sync.Once
+	nodes     map[string]*Conn
+	replicas  map[string]*Conn
+	leases    map[*Conn][]*Conn
+	departed  []*Conn
 
-	state atomic.Value
-	mu    sync.Mutex
-	once  sync.Once
-	nodes map[string]*Conn
+	resolveMu   sync.Mutex
+	resolutions map[string]resolution
+
+	listeners []*topologyListener
+	changes   []topologyChange
+	notifying bool
+	draining  bool
+
+	latencies map[string]*Histogram
+	latencyMu sync.RWMutex
 }
 
 type mapping struct {
-	id     int64
-	missed int
-	shards bool
-	closed bool
-	nodes  map[string]*Conn
-	slots  [16384]*Conn
+	id       int64
+	missed   int
+	shards   bool
+	closed   bool
+	nodes    map[string]*Conn
+	replicas map[string]*Conn
+	ids      map[string]string
+	slots    slotTable
+
+	// config holds the connection settings of the nodes, which Reload replaces.
+	config *Client
+
+	// followers holds the replicas of each master node.
+	followers map[*Conn][]*Conn
 }
 
-func (client *Client) initialize() {
-	// by default it will try to connect to the local Redis
-	address := client.Address
-	if len(address) == 0 {
-		address = []string{"tcp://127.0.0.1:6379"}
+// start initializes the client when first used along with its background tasks.
+func (client *Client) start() {
+	if client.Discovery != nil {
+		if addresses, err := client.resolveAddresses(); err == nil {
+			client.Address = addresses
+		} else {
+			log.Println("discovery error:", err)
+		}
 	}
 
-	client.nodes = make(map[string]*Conn)
+	client.initialize()
 
-	// prepare to (lazy) connect with all the nodes
-	for i := range address {
-		client.nodes[address[i]] = client.connect(address[i])
+	// resolve the hostnames of the seeds to recognize them among the nodes of the cluster
+	client.prefetch()
+
+	if client.TopologyStore != nil {
+		client.listeners = append(client.listeners, &topologyListener{client.persist})
+	}
+
+	if client.HealthCheckInterval != 0 {
+		go client.monitor()
 	}
 
-	// create the initial state from the first address given in parameters
-	primary := client.nodes[address[0]]
-	state := &mapping{
-		nodes: client.nodes,
+	if client.Discovery != nil {
+		go client.discover()
 	}
+}
+
+func (client *Client) initialize() {
+	config := client.config()
+
+	var seeds []*Conn
+	client.nodes, seeds = client.seeds(config)
+	client.replicas = make(map[string]*Conn)
 
-	for i, n := 0, len(state.slots); i < n; i++ {
-		state.slots[i] = primary
+	// start from the last known mapping of the cluster when saved
+	var state *mapping
+	if client.TopologyStore != nil {
+		state = client.restore()
 	}
 
+	// otherwise create the initial state from the first address that can be reached
+	if state == nil {
+		primary := client.seed(seeds)
+		state = &mapping{
+			nodes: client.nodes,
+		}
+
+		state.slots.fill(0, 16383, primary)
+	}
+
+	state.config = config
 	client.state.Store(state)
 	return
 }
 
+// seeds prepares to (lazy) connect with all the addresses of the configuration.
+func (client *Client) seeds(config *Client) (nodes map[string]*Conn, seeds []*Conn) {
+	// by default it will try to connect to the local Redis
+	address := config.Address
+	if len(address) == 0 {
+		address = []string{"tcp://127.0.0.1:6379"}
+	}
+
+	nodes = make(map[string]*Conn)
+	for i := range address {
+		name := nodeName(address[i])
+		if nodes[name] == nil {
+			nodes[name] = client.dial(config, address[i])
+			seeds = append(seeds, nodes[name])
+		}
+	}
+
+	return
+}
+
 // Do executes the specified command (with optional arguments) to the Redis instance and waits to decode the reply.
 func (client *Client) Do(name string, args ...interface{}) (result interface{}, err error) {
-	request := NewRequest(name, args...)
+	request := newRequest(name, args)
 	if err = client.Send(request); err == nil {
 		result = request.commands[len(request.commands)-1].result
 	}
 
+	release(request)
 	return
 }
 
 // Send sends the specified request to the Redis instance and waits for the reply.
 func (client *Client) Send(request *Request) (err error) {
-	value := client.state.Load()
-	if value == nil {
-		client.once.Do(client.initialize)
-		value = client.state.Load()
+	if err = request.checkSlot(); err != nil {
+		request.err = err
+		return
 	}
 
-	state := value.(*mapping)
-	if state.closed {
-		log.Panicf("client closed")
+	state := client.load()
+	if state.shards {
+		if err = request.crossSlot(); err != nil {
+			request.err = err
+			return
+		}
 	}
 
-	// figure out where this request should be sent
-	slot := 0
+	policy := client.keylessPolicy(request)
+	if policy == KeylessBroadcast && request.Len() == 1 {
+		err = client.broadcast(request)
+		return
+	}
+
+	if parts := client.partition(state, request); parts != nil {
+		if err = client.split(state, request, parts); client.Shadow != nil {
+			client.Shadow.send(client.rand(), request)
+		}
+
+		return
+	}
+
+	slot, node := client.target(state, policy, request)
+
+	var replica *Conn
+	if client.Hedge != nil && request.node == "" && request.ReadOnly() && !request.commands[0].stream {
+		replica = client.replica(state, node)
+	}
+
+	if replica != nil {
+		err = client.hedge(request, replica, func(primary *Request) error {
+			return client.send(state, slot, policy, node, primary)
+		})
+	} else {
+		err = client.send(state, slot, policy, node, request)
+	}
+
+	if client.Shadow != nil {
+		client.Shadow.send(client.rand(), request)
+	}
+
+	return
+}
+
+// target figures out the slot of the request and the node where it should be sent.
+func (client *Client) target(state *mapping, policy KeylessPolicy, request *Request) (slot int, node *Conn) {
 	if state.shards {
 		slot = request.slot()
 	}
 
-	node := state.slots[slot]
+	node = client.route(state, slot, policy, request)
+	if request.node == "" {
+		node = client.healthy(state, node)
+	}
 
-	redirect := client.MaximumRedirections
-	if 0 == redirect {
-		redirect = DefaultMaximumRedirections
+	if client.ReplicaReads && request.node == "" && request.ReadOnly() {
+		if replica := client.replica(state, node); replica != nil {
+			node = replica
+		}
 	}
 
-	for i := 0; i < redirect; i++ {
-		if node == nil {
-			break
+	return
+}
+
+// send sends the request to the node and follows redirections or retries according to the retry policy.
+func (client *Client) send(state *mapping, slot int, policy KeylessPolicy, node *Conn, request *Request) (err error) {
+	clock := client.clock()
+	start := clock.Now()
+	if node != nil {
+		request.moved, request.redirect = false, false
+		err = client.sendNode(node, request)
+	}
+
+	node, err = client.resend(state, slot, policy, node, request, start, err)
+	err = client.stale(slot, request, err)
+	client.observe(request, node, clock.Now().Sub(start))
+	return
+}
+
+// resend follows redirections or retries according to the retry policy once the request failed on the node.
+// It returns the last node the request was sent to.
+// The request fails with BudgetError once another attempt would go over its budget.
+func (client *Client) resend(state *mapping, slot int, policy KeylessPolicy, node *Conn, request *Request, start time.Time, err error) (*Conn, error) {
+	retry := client.retryPolicy(request)
+	budget := client.budget(request)
+	clock := client.clock()
+
+	for attempt := 1; node != nil && err != nil; attempt++ {
+		if client.BusyPolicy != BusyFail && request.busy() {
+			if err = client.unwedge(node, request, start); err == nil {
+				break
+			}
 		}
 
-		if err = node.Send(request); err == nil {
-			break
+		if !request.redirect {
+			if _, ok := err.(ReplyError); !ok && err != ErrCircuitOpen && err != ErrOverloaded && err != ErrDeadlineExceeded && err != ErrClosed {
+				client.check(node)
+				client.failover(state, node)
+			}
 		}
 
 		// done?
-		if !request.redirect {
+		delay, ok := retry.Retry(request, attempt, err)
+		if !ok {
 			break
 		}
 
-		// migrate from a Redis client to a Redis cluster client
-		if !state.shards {
-			if state, err = client.migrate(); err != nil {
-				return
+		if elapsed := clock.Now().Sub(start); budget.exhausted(attempt, elapsed, delay) {
+			err = &BudgetError{
+				Attempts: attempt,
+				Elapsed:  elapsed,
+				Err:      err,
 			}
 
-			slot = request.slot()
-			node = state.slots[slot]
-			continue
+			request.err = err
+			break
 		}
 
-		// already connected?
-		if node = state.nodes[request.address]; node != nil {
-			if request.moved {
-				state, err = client.update(slot, node)
+		clock.Sleep(delay)
+
+		// the redirection may name a known node with another address
+		if request.redirect {
+			request.address = client.canonical(request.address)
+		}
+
+		switch {
+		case !request.redirect:
+			// send it again to the node that now serves the slot
+			if state = client.state.Load().(*mapping); state.closed {
+				return node, err
 			}
 
-			continue
+			if node = client.route(state, slot, policy, request); request.node == "" {
+				node = client.healthy(state, node)
+			}
+		case !state.shards:
+			// migrate from a Redis client to a Redis cluster client
+			if state, err = client.migrate(); err != nil {
+				return node, err
+			}
+
+			slot = request.slot()
+			node = state.slots.get(slot)
+		case state.nodes[request.address] != nil:
+			// already connected
+			if node = state.nodes[request.address]; request.moved {
+				state, err = client.update(slot, node)
+			}
+		default:
+			if state, node, err = client.redirect(request); err != nil {
+				node = client.random()
+			}
 		}
 
-		state, node, err = client.redirect(request)
-		if err != nil {
-			node = client.random()
+		if node != nil {
+			request.moved, request.redirect = false, false
+			err = client.sendNode(node, request)
 		}
 	}
 
-	return
+	return node, err
 }
 
-// LuaScript loads a script into the script cache.
-func (client *Client) LuaScript(code string) (id string, err error) {
-	value := client.state.Load()
-	if value == nil {
-		client.once.Do(client.initialize)
-		value = client.state.Load()
-	}
+// Route returns the node that serves the slot of the request in the cluster.
+// This is the default routing used when no Router is set.
+func (client *Client) Route(request *Request) *Conn {
+	state := client.load()
 
-	state := value.(*mapping)
-	if state.closed {
-		log.Panicf("client closed")
+	slot := 0
+	if state.shards {
+		slot = request.slot()
 	}
 
-	client.mu.Lock()
-	defer client.mu.Unlock()
+	return state.slots.get(slot)
+}
 
-	done := make(chan func() (interface{}, error))
+// Node returns the connection to the node at the specified address e.g. tcp://127.0.0.1:6379.
+// The connection is created when the node is unknown and is closed with the client.
+func (client *Client) Node(address string) *Conn {
+	client.load()
+	return client.lookup(address)
+}
 
-	// load the script on all known connections
-	for name := range client.nodes {
-		name := name
-		go func() {
-			node := client.nodes[name]
-			result, err := node.LuaScript(code)
-			done <- func() (interface{}, error) {
-				return result, err
-			}
-		}()
+func (client *Client) route(state *mapping, slot int, policy KeylessPolicy, request *Request) *Conn {
+	if request.node != "" {
+		return client.lookup(request.node)
 	}
 
-	// wait for the result
-	for i, n := 0, len(client.nodes); i < n; i++ {
-		f := <-done
-		var result interface{}
-		result, err = f()
-		if err != nil {
-			return
+	if client.Router != nil {
+		if node := client.Router.Route(request); node != nil {
+			return node
 		}
+	}
 
-		key := result.(string)
-		if id == "" || id == key {
-			id = key
-		} else {
-			err = fmt.Errorf("script SHA1 doesn't match '%s' vs. '%s'", id, key)
-		}
+	if node := client.keylessNode(policy); node != nil {
+		return node
 	}
 
-	if id != "" {
-		err = nil
-	} else {
-		err = fmt.Errorf("failed to get SHA1 from connections")
+	return state.slots.get(slot)
+}
+
+// LuaScript loads a script into the script cache.
+func (client *Client) LuaScript(code string) (id string, err error) {
+	client.load()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// load the script on all known connections
+	if id, err = loadScript(client.nodes, code); err != nil {
+		return
 	}
 
 	// remember this script for new connections
@@ -232,33 +560,271 @@ func (client *Client) Close() {
 		item.Close()
 	}
 
+	for _, item := range client.replicas {
+		item.Close()
+	}
+
+	for _, item := range client.departed {
+		item.Close()
+	}
+
+	for _, idle := range client.leases {
+		for _, item := range idle {
+			item.Close()
+		}
+	}
+
 	client.nodes = nil
+	client.replicas = nil
+	client.leases = nil
+	client.departed = nil
+
+	if client.Shadow != nil {
+		client.Shadow.close()
+	}
+
+	if client.Audit != nil {
+		client.Audit.close()
+	}
+
 	client.state.Store(&mapping{
 		closed: true,
 	})
 }
 
+// NodeErrors holds the errors returned by each node, indexed by address, when a command is executed on many nodes.
+type NodeErrors map[string]error
+
+func (errs NodeErrors) Error() string {
+	return joinErrors(errs)
+}
+
+func joinErrors(errs map[string]error) string {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	text := make([]string, len(names))
+	for i, name := range names {
+		text[i] = fmt.Sprintf("%s: %s", name, errs[name])
+	}
+
+	return strings.Join(text, "; ")
+}
+
+func (client *Client) load() (state *mapping) {
+	value := client.state.Load()
+	if value == nil {
+		client.once.Do(client.start)
+		value = client.state.Load()
+	}
+
+	state = value.(*mapping)
+	if state.closed {
+		log.Panicf("client closed")
+	}
+
+	return
+}
+
+// closed returns true once the client is closed, when sending requests would panic.
+func (client *Client) closed() bool {
+	state, ok := client.state.Load().(*mapping)
+	return ok && state.closed
+}
+
+// masters returns the master nodes of the cluster indexed by address.
+// When the client isn't handling a cluster yet, it tries to migrate and falls back to the primary node.
+func (client *Client) masters() (nodes map[string]*Conn) {
+	state := client.load()
+	if !state.shards {
+		if next, err := client.migrate(); err == nil {
+			state = next
+		}
+	}
+
+	if state.shards {
+		nodes = state.nodes
+		return
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	nodes = make(map[string]*Conn)
+	for name, node := range state.nodes {
+		if node == state.slots.get(0) {
+			nodes[name] = node
+			break
+		}
+	}
+
+	return
+}
+
+// lookup returns the connection to the node at the specified address and creates it when unknown.
+func (client *Client) lookup(name string) (node *Conn) {
+	client.mu.Lock()
+	if node = client.nodes[name]; node == nil {
+		node = client.replicas[name]
+	}
+
+	client.mu.Unlock()
+
+	if node != nil {
+		return
+	}
+
+	client.prefetch(name)
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if node = client.nodes[name]; node != nil {
+		return
+	}
+
+	if node = client.replicas[name]; node != nil {
+		return
+	}
+
+	// the node may already be known with another address
+	if alias := client.alias(name); alias != name {
+		if node = client.nodes[alias]; node == nil {
+			node = client.replicas[alias]
+		}
+
+		return
+	}
+
+	node = client.connect(name)
+	client.nodes[name] = node
+	return
+}
+
+// each calls the function concurrently for every specified node and waits for all of them to complete.
+// Results are indexed by address while failures are reported as NodeErrors.
+func each(nodes map[string]*Conn, f func(name string, node *Conn) (interface{}, error)) (results map[string]interface{}, err error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	results = make(map[string]interface{})
+	errs := make(NodeErrors)
+
+	for name, node := range nodes {
+		wg.Add(1)
+		go func(name string, node *Conn) {
+			result, e := f(name, node)
+
+			mu.Lock()
+			if e != nil {
+				errs[name] = e
+			} else {
+				results[name] = result
+			}
+			mu.Unlock()
+
+			wg.Done()
+		}(name, node)
+	}
+
+	wg.Wait()
+
+	if len(errs) != 0 {
+		err = errs
+	}
+
+	return
+}
+
+// loadScript loads the script on every node and makes sure they all agree on its SHA1.
+func loadScript(nodes map[string]*Conn, code string) (id string, err error) {
+	results, err := each(nodes, func(name string, node *Conn) (interface{}, error) {
+		return node.LuaScript(code)
+	})
+
+	if err != nil {
+		return
+	}
+
+	for _, result := range results {
+		key := result.(string)
+		if id != "" && id != key {
+			err = fmt.Errorf("script SHA1 doesn't match '%s' vs. '%s'", id, key)
+			return
+		}
+
+		id = key
+	}
+
+	if id == "" {
+		err = fmt.Errorf("failed to get SHA1 from connections")
+	}
+
+	return
+}
+
 func (client *Client) connect(address string) *Conn {
+	return client.dial(client.settings(), address)
+}
+
+// dial returns a connection to the address with the connection settings of the configuration.
+func (client *Client) dial(config *Client, address string) *Conn {
 	lua := make(map[string]string)
 	for key, code := range client.lua {
 		lua[key] = code
 	}
 
-	return &Conn{
-		MaximumConcurrentRequests: client.MaximumConcurrentRequests,
-		MaximumPendingRequests:    client.MaximumPendingRequests,
-		MaximumConnectionRetries:  client.MaximumConnectionRetries,
-		RetryTimeout:              client.RetryTimeout,
-		db: dialerFunc(func() (net.Conn, error) {
-			u, err := url.Parse(address)
-			if err != nil {
-				return nil, err
-			}
+	conn := &Conn{
+		MaximumConcurrentRequests: config.MaximumConcurrentRequests,
+		MaximumPendingRequests:    config.MaximumPendingRequests,
+		MaximumConnectionRetries:  config.MaximumConnectionRetries,
+		RetryTimeout:              config.RetryTimeout,
+		Breaker:                   config.Breaker,
+		FailFast:                  config.FailFast,
+		FailFastTimeout:           config.FailFastTimeout,
+		MaximumOfflineRequests:    config.MaximumOfflineRequests,
+		OfflineTimeout:            config.OfflineTimeout,
+		MaximumBatchSize:          config.MaximumBatchSize,
+		FlushInterval:             config.FlushInterval,
+		KeepWarmInterval:          client.KeepWarmInterval,
+		PriorityWeight:            client.PriorityWeight,
+		MaximumReplySize:          config.MaximumReplySize,
+		StrictProtocol:            config.StrictProtocol,
+		NoEvict:                   config.NoEvict,
+		NoTouch:                   config.NoTouch,
+		Credentials:               config.Credentials,
+		Clock:                     client.Clock,
+		Rand:                      client.Rand,
+		lua:                       lua,
+	}
 
-			return net.Dial(u.Scheme, u.Host+u.Path)
-		}),
-		lua: lua,
+	if client.VerifyRoles {
+		conn.connected = client.verify
 	}
+
+	conn.mustConfigure(config.nodeAddress(address), dialOptions{
+		dial:  config.DialTimeout,
+		read:  config.ReadTimeout,
+		write: config.WriteTimeout,
+		tls:   config.TLSConfig,
+
+		keepAlive:   client.KeepAlive,
+		delayWrites: client.DelayWrites,
+		readBuffer:  client.ReadBufferSize,
+		writeBuffer: client.WriteBufferSize,
+	})
+
+	if client.Dialer != nil {
+		conn.db = dialerFunc(func() (net.Conn, error) {
+			return client.Dialer(conn.address)
+		})
+	}
+
+	return conn
 }
 
 func (client *Client) migrate() (state *mapping, err error) {
@@ -273,7 +839,7 @@ func (client *Client) migrate() (state *mapping, err error) {
 	}
 
 	// update the mapping then
-	state, err = client.reconfigure(state, state.slots[0])
+	state, err = client.reconfigure(state, state.slots.get(0))
 	return
 }
 
@@ -287,24 +853,51 @@ func (client *Client) update(slot int, node *Conn) (state *mapping, err error) {
 	}
 
 	// check if we can simply update the state or if a full refresh is required
-	state = client.state.Load().(*mapping)
-	state.missed++
-	if state.missed < miss {
-		state = &mapping{
-			id:     state.id + 1,
-			shards: true,
-			nodes:  state.nodes,
-			slots:  state.slots,
-		}
+	last := client.state.Load().(*mapping)
+	last.missed++
+	if last.missed < miss {
+		state = client.patch(last, slot, node)
+		return
+	}
 
-		// update the slot in the new copy of the state
-		state.slots[slot] = node
+	// the slot moved even when the full refresh has to wait
+	if state, err = client.reconfigure(last, node); err == ErrThrottled {
+		state, err = client.patch(state, slot, node), nil
+	}
 
-		client.state.Store(state)
-		return
+	return
+}
+
+// patch stores a copy of the state with the slot served by the node and must be called with the lock held.
+func (client *Client) patch(last *mapping, slot int, node *Conn) (state *mapping) {
+	state = &mapping{
+		id:       last.id + 1,
+		missed:   last.missed,
+		shards:   true,
+		nodes:    last.nodes,
+		replicas: last.replicas,
+		ids:      last.ids,
+		slots:    last.slots,
+
+		followers: last.followers,
+		config:    last.config,
 	}
 
-	state, err = client.reconfigure(state, node)
+	// a node that wasn't serving slots yet is added to a copy of the nodes
+	if last.nodes[node.address] == nil {
+		state.nodes = make(map[string]*Conn)
+		for name, item := range last.nodes {
+			state.nodes[name] = item
+		}
+
+		state.nodes[node.address] = node
+	}
+
+	// update the slot in the new copy of the state
+	state.slots.set(slot, node)
+
+	client.changed(last, state)
+	client.state.Store(state)
 	return
 }
 
@@ -320,9 +913,18 @@ func (client *Client) redirect(request *Request) (state *mapping, node *Conn, er
 	}
 
 	// connect to that new node then
-	node = client.connect(request.address)
+	if node = client.nodes[request.address]; node == nil {
+		node = client.connect(request.address)
+		client.nodes[request.address] = node
+	}
+
+	// follow the redirection alone until the full refresh is allowed
+	if state, err = client.reconfigure(state, node); err == ErrThrottled {
+		if err = nil; request.moved {
+			state = client.patch(state, request.slot(), node)
+		}
+	}
 
-	state, err = client.reconfigure(state, node)
 	return
 }
 
@@ -331,7 +933,7 @@ func (client *Client) random() (node *Conn) {
 	defer client.mu.Unlock()
 
 	// random walk
-	n := rand.Intn(len(client.nodes))
+	n := client.rand().Intn(len(client.nodes))
 
 	for _, conn := range client.nodes {
 		if n == 0 {
@@ -346,27 +948,50 @@ func (client *Client) random() (node *Conn) {
 }
 
 func (client *Client) reconfigure(last *mapping, node *Conn) (next *mapping, err error) {
-	result, err := node.Do("CLUSTER", "SLOTS")
+	// callers waiting during a storm of redirections get the result of the last refresh and fall back on what they were told
+	if client.throttled() != 0 {
+		next, err = client.state.Load().(*mapping), ErrThrottled
+		return
+	}
+
+	result, err := client.clusterSlots(last, node)
+	if err == ErrNoQuorum {
+		// keep the current mapping until the nodes agree
+		client.refreshed = client.clock().Now()
+		next = last
+		return
+	}
+
 	if err != nil {
 		return
 	}
 
+	ranges, err := parseClusterSlots(result)
+	if err != nil {
+		return
+	}
+
+	client.refreshed = client.clock().Now()
+
 	next = &mapping{
-		id:     last.id + 1,
-		shards: true,
-		nodes:  make(map[string]*Conn),
+		id:       last.id + 1,
+		shards:   true,
+		nodes:    make(map[string]*Conn),
+		replicas: make(map[string]*Conn),
+		ids:      make(map[string]string),
+
+		followers: make(map[*Conn][]*Conn),
+		config:    last.config,
 	}
 
 	// prepare the next state with only read access to the last state
-	groups := result.([]interface{})
-	for i := range groups {
-		item := groups[i].([]interface{})
-		a := item[0].(int64)
-		b := item[1].(int64)
-		m := item[2].([]interface{})
-		addr := string(m[0].([]byte))
-		port := m[1].(int64)
-		name := fmt.Sprintf("tcp://%s:%d", addr, port)
+	for _, item := range ranges {
+		name := client.alias(item.Master.Address)
+
+		// node IDs are only available since Redis 4.0
+		if item.Master.ID != "" {
+			next.ids[item.Master.ID] = name
+		}
 
 		conn, ok := next.nodes[name]
 		if !ok {
@@ -379,8 +1004,27 @@ func (client *Client) reconfigure(last *mapping, node *Conn) (next *mapping, err
 		}
 
 		// fill slots
-		for j := a; j <= b; j++ {
-			next.slots[j] = conn
+		next.slots.fill(item.First, item.Last, conn)
+
+		// remember the replicas of the range
+		for _, r := range item.Replicas {
+			name := client.alias(r.Address)
+			if r.ID != "" {
+				next.ids[r.ID] = name
+			}
+
+			replica, ok := next.replicas[name]
+			if !ok {
+				replica, ok = client.replicas[name]
+				if !ok {
+					replica = client.connect(name)
+					replica.readonly = true
+				}
+
+				next.replicas[name] = replica
+			}
+
+			next.followers[conn] = append(next.followers[conn], replica)
 		}
 	}
 
@@ -389,10 +1033,40 @@ func (client *Client) reconfigure(last *mapping, node *Conn) (next *mapping, err
 		client.nodes[name] = item
 	}
 
+	for name, item := range next.replicas {
+		client.replicas[name] = item
+	}
+
+	// close the connections to the nodes that left once they have drained
+	client.forget(next)
+
+	client.changed(last, next)
 	client.state.Store(next)
+
+	// hostnames are looked up again without the lock in case their IPs changed
+	go client.prefetch()
+
+	if client.VerifyRoles {
+		client.verifyRoles(next)
+	}
+
 	return
 }
 
+// throttled returns how long until the next full refresh is allowed and must be called with the lock held.
+func (client *Client) throttled() time.Duration {
+	interval := client.ReconfigureInterval
+	if 0 == interval {
+		interval = DefaultReconfigureInterval
+	}
+
+	if wait := interval - client.clock().Now().Sub(client.refreshed); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
 func init() {
 	blueprint.Register(Client{})
 }
@@ -0,0 +1,197 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClientListEntry defines a connection reported by the CLIENT LIST command.
+type ClientListEntry struct {
+	// Address is the node that reported the connection.
+	Address   string
+	ID        int64
+	Addr      string
+	LocalAddr string
+	Name      string
+	Age       time.Duration
+	Idle      time.Duration
+	Flags     string
+	DB        int64
+	Cmd       string
+
+	// Fields holds all raw fields e.g. Fields["qbuf"].
+	Fields map[string]string
+}
+
+// KillFilter defines which connections are closed by CLIENT KILL.
+// At least one of the ID, Addr, LocalAddr or Type filters must be set.
+type KillFilter struct {
+	// Node is the address of the node where the command is executed.
+	// When empty, the command is executed on all master nodes.
+	Node string
+
+	// ID is the unique identifier of the connection and only makes sense for a specific Node.
+	ID int64
+
+	Addr      string
+	LocalAddr string
+
+	// Type is one of normal, master, slave or pubsub.
+	Type string
+}
+
+// Clients returns the connections of every master node.
+// Nodes that failed are reported with NodeErrors while the entries of the others are still returned.
+func (client *Client) Clients() (result []ClientListEntry, err error) {
+	results, err := each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return node.Clients()
+	})
+
+	for name, item := range results {
+		for _, entry := range item.([]ClientListEntry) {
+			entry.Address = name
+			result = append(result, entry)
+		}
+	}
+
+	return
+}
+
+// KillClient closes the connections matching the filter and returns how many were killed.
+func (client *Client) KillClient(filter KillFilter) (killed int64, err error) {
+	nodes := client.masters()
+
+	if filter.Node != "" {
+		client.mu.Lock()
+		node, ok := client.nodes[filter.Node]
+		client.mu.Unlock()
+
+		if !ok {
+			err = fmt.Errorf("unknown node '%s'", filter.Node)
+			return
+		}
+
+		nodes = map[string]*Conn{filter.Node: node}
+	} else if filter.ID != 0 {
+		err = errors.New("connection ID requires a node")
+		return
+	}
+
+	results, err := each(nodes, func(name string, node *Conn) (interface{}, error) {
+		return node.KillClient(filter)
+	})
+
+	for _, item := range results {
+		killed += item.(int64)
+	}
+
+	return
+}
+
+// Clients returns the connections of the Redis instance.
+func (conn *Conn) Clients() (result []ClientListEntry, err error) {
+	reply, err := conn.Do("CLIENT", "LIST")
+	if err != nil {
+		return
+	}
+
+	text, ok := reply.([]byte)
+	if !ok {
+		err = fmt.Errorf("unexpected CLIENT LIST reply '%v'", reply)
+		return
+	}
+
+	result = parseClientList(string(text))
+	return
+}
+
+// KillClient closes the connections matching the filter and returns how many were killed.
+// The Node of the filter is ignored.
+func (conn *Conn) KillClient(filter KillFilter) (killed int64, err error) {
+	args := []interface{}{"KILL"}
+	if filter.ID != 0 {
+		args = append(args, "ID", filter.ID)
+	}
+
+	if filter.Addr != "" {
+		args = append(args, "ADDR", filter.Addr)
+	}
+
+	if filter.LocalAddr != "" {
+		args = append(args, "LADDR", filter.LocalAddr)
+	}
+
+	if filter.Type != "" {
+		args = append(args, "TYPE", filter.Type)
+	}
+
+	if len(args) == 1 {
+		err = errors.New("empty client kill filter")
+		return
+	}
+
+	reply, err := conn.Do("CLIENT", args...)
+	if err != nil {
+		return
+	}
+
+	killed, ok := reply.(int64)
+	if !ok {
+		err = fmt.Errorf("unexpected CLIENT KILL reply '%v'", reply)
+	}
+
+	return
+}
+
+func parseClientList(text string) (result []ClientListEntry) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		entry := ClientListEntry{
+			Fields: make(map[string]string),
+		}
+
+		// formatted as id=... addr=... fd=... name=... age=...
+		for _, item := range strings.Fields(line) {
+			i := strings.IndexByte(item, '=')
+			if i < 0 {
+				continue
+			}
+
+			key, value := item[:i], item[i+1:]
+			entry.Fields[key] = value
+
+			switch key {
+			case "id":
+				entry.ID = parseInt(value)
+			case "addr":
+				entry.Addr = value
+			case "laddr":
+				entry.LocalAddr = value
+			case "name":
+				entry.Name = value
+			case "age":
+				entry.Age = time.Duration(parseInt(value)) * time.Second
+			case "idle":
+				entry.Idle = time.Duration(parseInt(value)) * time.Second
+			case "flags":
+				entry.Flags = value
+			case "db":
+				entry.DB = parseInt(value)
+			case "cmd":
+				entry.Cmd = value
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return
+}
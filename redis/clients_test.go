@@ -0,0 +1,28 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClientList(t *testing.T) {
+	text := "id=3 addr=127.0.0.1:52555 fd=8 name=worker age=855 idle=2 flags=N db=0 sub=0 psub=0 cmd=client\n" +
+		"id=5 addr=10.0.0.1:6000 laddr=10.0.0.2:6379 fd=9 name= age=1 idle=1 flags=S db=2 cmd=replconf\n"
+
+	result := parseClientList(text)
+	if len(result) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	a := result[0]
+	if a.ID != 3 || a.Addr != "127.0.0.1:52555" || a.Name != "worker" || a.Age != 855*time.Second || a.Idle != 2*time.Second || a.Cmd != "client" || a.Fields["sub"] != "0" {
+		t.Fatalf("unexpected entry %+v", a)
+	}
+
+	b := result[1]
+	if b.ID != 5 || b.LocalAddr != "10.0.0.2:6379" || b.Name != "" || b.Flags != "S" || b.DB != 2 {
+		t.Fatalf("unexpected entry %+v", b)
+	}
+}
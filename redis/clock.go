@@ -0,0 +1,105 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for the client, which lets tests control its delays and timeouts.
+// After returns a channel receiving the time once the duration elapsed like time.After.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// Rand picks the random numbers of the client, which lets tests reproduce the nodes it selects and the jitter of its retries.
+type Rand interface {
+	Intn(n int) int
+	Float64() float64
+}
+
+// NewRand returns a source of random numbers with the seed that is safe for concurrent use.
+func NewRand(seed int64) Rand {
+	return &lockedRand{
+		r: rand.New(rand.NewSource(seed)),
+	}
+}
+
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// systemClock implements the clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// globalRand implements the shared source of the math/rand package.
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int {
+	return rand.Intn(n)
+}
+
+func (globalRand) Float64() float64 {
+	return rand.Float64()
+}
+
+func (client *Client) clock() Clock {
+	if client.Clock != nil {
+		return client.Clock
+	}
+
+	return systemClock{}
+}
+
+func (client *Client) rand() Rand {
+	if client.Rand != nil {
+		return client.Rand
+	}
+
+	return globalRand{}
+}
+
+func (conn *Conn) clock() Clock {
+	if conn.Clock != nil {
+		return conn.Clock
+	}
+
+	return systemClock{}
+}
+
+func (conn *Conn) rand() Rand {
+	if conn.Rand != nil {
+		return conn.Rand
+	}
+
+	return globalRand{}
+}
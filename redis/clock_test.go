@@ -0,0 +1,142 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) Sleep(d time.Duration) {
+	clock.mu.Lock()
+	clock.now = clock.now.Add(d)
+	clock.mu.Unlock()
+}
+
+// After advances the clock and fires right away.
+func (clock *fakeClock) After(d time.Duration) <-chan time.Time {
+	clock.Sleep(d)
+
+	c := make(chan time.Time, 1)
+	c <- clock.Now()
+	return c
+}
+
+func TestClock(t *testing.T) {
+	db := new(mockDB)
+	db.err = fmt.Errorf("failure")
+
+	client := &Client{
+		MaximumConnectionRetries: 1,
+		RetryPolicy: RetryPolicyFunc(func(request *Request, attempt int, err error) (time.Duration, bool) {
+			return 20 * time.Minute, true
+		}),
+		Budget: Budget{
+			Timeout: time.Hour,
+		},
+	}
+
+	WithClock(&fakeClock{now: time.Unix(0, 0)})(client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	_, err := client.Do("GET", "key")
+	if e, ok := err.(*BudgetError); !ok || e.Attempts != 3 || e.Elapsed != 40*time.Minute {
+		t.Fatal(err)
+	}
+}
+
+func TestClockNodes(t *testing.T) {
+	db := new(mockDB)
+	var buffer bytes.Buffer
+
+	// the latency of the chaos is added to the clock of the client instead of waiting
+	client := &Client{
+		Middleware: []Middleware{&Chaos{Latency: time.Hour}},
+		Audit: &Audit{
+			Sink: &AuditLog{W: &buffer},
+		},
+	}
+
+	WithClock(&fakeClock{now: time.Unix(0, 0)})(client)
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n")
+	if _, err := client.Do("SET", "key", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Close()
+
+	line := strings.TrimSpace(buffer.String())
+	if !strings.HasPrefix(line, "1970-01-01T01:00:00Z SET ") || !strings.Contains(line, " 1h0m0s OK") {
+		t.Fatal(line)
+	}
+}
+
+func TestRand(t *testing.T) {
+	a, b := NewRand(42), NewRand(42)
+	for i := 0; i < 10; i++ {
+		if a.Intn(1000) != b.Intn(1000) || a.Float64() != b.Float64() {
+			t.Fatal("expecting the same numbers with the same seed")
+		}
+	}
+
+	backoff := &Backoff{
+		MaximumRetries: 3,
+		Delay:          100 * time.Millisecond,
+		Jitter:         0.5,
+		Rand:           NewRand(1),
+	}
+
+	expected := &Backoff{
+		MaximumRetries: 3,
+		Delay:          100 * time.Millisecond,
+		Jitter:         0.5,
+		Rand:           NewRand(1),
+	}
+
+	request := NewRequest("GET", "key")
+	for attempt := 1; attempt <= 3; attempt++ {
+		delay, ok := backoff.Retry(request, attempt, fmt.Errorf("failure"))
+		other, _ := expected.Retry(request, attempt, fmt.Errorf("failure"))
+		max := 100 * time.Millisecond << uint(attempt-1)
+		if !ok || delay != other || delay > max || delay < max/2 {
+			t.Fatal(attempt, delay, other)
+		}
+	}
+}
+
+func TestClockDeadline(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	conn := &Conn{Clock: clock, db: new(mockDB)}
+	defer conn.Close()
+
+	// the deadline is over for the clock of the node long before it is for the system
+	request := NewRequest("PING")
+	request.SetDeadline(time.Unix(60, 0))
+	clock.Sleep(time.Hour)
+
+	if err := conn.Send(request); err != ErrDeadlineExceeded {
+		t.Fatal(err)
+	}
+}
@@ -245,7 +245,23 @@ func crc16(key []byte) (result uint16) {
 	return
 }
 
+// Slot returns the slot of the cluster that holds the key.
+// Keys with the same hash tag always share a slot.
+func Slot(key string) int {
+	return slot([]byte(key))
+}
+
+// HashTag returns the part of the key used to compute its slot.
+// This is the content between the first '{' and the next '}' when not empty or the whole key otherwise.
+func HashTag(key string) string {
+	return string(tag([]byte(key)))
+}
+
 func slot(key []byte) int {
+	return int(crc16(tag(key))) % 16384
+}
+
+func tag(key []byte) []byte {
 	if i := bytes.IndexByte(key, '{'); i >= 0 {
 		sub := key[i+1:]
 		if j := bytes.IndexByte(sub, '}'); j >= 1 {
@@ -253,5 +269,5 @@ func slot(key []byte) int {
 		}
 	}
 
-	return int(crc16(key)) % 16384
+	return key
 }
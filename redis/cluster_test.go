@@ -49,6 +49,27 @@ func TestSlot(t *testing.T) {
 	test("{foobar", "{foobar")
 }
 
+func TestHashTag(t *testing.T) {
+	test := func(key, expected string) {
+		if tag := HashTag(key); tag != expected {
+			t.Fatalf("unexpected tag '%s' of '%s' instead of '%s'", tag, key, expected)
+		}
+
+		if Slot(key) != Slot(expected) {
+			t.Fatalf("unexpected slot of '%s'", key)
+		}
+	}
+
+	test("{user1000}.following", "user1000")
+	test("foo{}{bar}", "foo{}{bar}")
+	test("foo{{bar}}zap", "{bar")
+	test("foobar", "foobar")
+
+	if Slot("123456789") != 0x31C3%16384 {
+		t.Fail()
+	}
+}
+
 func TestCluster(t *testing.T) {
 	if !clusterSupported() {
 		t.Skip("redis-server doesn't support clusters")
@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultCoalesceWindow defines the default time a coalescer waits for more GETs before sending them.
+var DefaultCoalesceWindow = 500 * time.Microsecond
+
+// DefaultMaximumCoalescedKeys defines the default number of keys that triggers sending a MGET right away.
+var DefaultMaximumCoalescedKeys = 128
+
+// Coalescer collects the GETs made concurrently on keys of the same slot and sends them as a single MGET.
+// This trades a little latency for far fewer round trips when many small GETs are made at once.
+type Coalescer struct {
+	Sender Sender
+
+	// Window is how long a GET waits for others to join it.
+	Window time.Duration
+
+	// MaximumKeys is the number of keys that sends a MGET without waiting for the end of the window.
+	MaximumKeys int
+
+	mu      sync.Mutex
+	batches map[int]*coalesced
+}
+
+// coalesced holds the keys of a slot waiting to be sent.
+type coalesced struct {
+	keys    []interface{}
+	timer   *time.Timer
+	done    chan struct{}
+	results []interface{}
+	err     error
+}
+
+// Get returns the value of the key like a GET would, possibly fetched along with other keys.
+func (coalescer *Coalescer) Get(key string) (result interface{}, err error) {
+	s := Slot(key)
+
+	coalescer.mu.Lock()
+	if coalescer.batches == nil {
+		coalescer.batches = make(map[int]*coalesced)
+	}
+
+	batch := coalescer.batches[s]
+	if batch == nil {
+		batch = &coalesced{
+			done: make(chan struct{}),
+		}
+
+		window := coalescer.Window
+		if 0 == window {
+			window = DefaultCoalesceWindow
+		}
+
+		batch.timer = time.AfterFunc(window, func() {
+			coalescer.flush(s, batch)
+		})
+
+		coalescer.batches[s] = batch
+	}
+
+	i := len(batch.keys)
+	batch.keys = append(batch.keys, key)
+
+	n := coalescer.MaximumKeys
+	if 0 == n {
+		n = DefaultMaximumCoalescedKeys
+	}
+
+	full := len(batch.keys) >= n
+	coalescer.mu.Unlock()
+
+	if full && batch.timer.Stop() {
+		coalescer.flush(s, batch)
+	}
+
+	<-batch.done
+	if err = batch.err; err == nil {
+		result = batch.results[i]
+	}
+
+	return
+}
+
+// flush sends the keys of the batch as a MGET and hands out the results.
+func (coalescer *Coalescer) flush(s int, batch *coalesced) {
+	coalescer.mu.Lock()
+	if coalescer.batches[s] == batch {
+		delete(coalescer.batches, s)
+	}
+
+	coalescer.mu.Unlock()
+
+	request := NewRequest("MGET", batch.keys...)
+	if batch.err = coalescer.Sender.Send(request); batch.err == nil {
+		results, ok := request.commands[0].result.([]interface{})
+		if !ok || len(results) != len(batch.keys) {
+			batch.err = fmt.Errorf("unexpected MGET reply for %d keys: %v", len(batch.keys), request.commands[0].result)
+		}
+
+		batch.results = results
+	}
+
+	close(batch.done)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoSender replies to MGET with the keys themselves.
+type echoSender struct {
+	requests int32
+}
+
+func (sender *echoSender) Send(request *Request) error {
+	atomic.AddInt32(&sender.requests, 1)
+
+	cmd := &request.commands[0]
+	results := make([]interface{}, len(cmd.args))
+	for i, arg := range cmd.args {
+		results[i] = []byte(arg.(string))
+	}
+
+	cmd.result = results
+	return nil
+}
+
+func TestCoalescer(t *testing.T) {
+	sender := new(echoSender)
+	coalescer := &Coalescer{
+		Sender:      sender,
+		Window:      time.Second,
+		MaximumKeys: 3,
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"{a}1", "{a}2", "{a}3"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			result, err := coalescer.Get(key)
+			if err != nil || string(result.([]byte)) != key {
+				t.Error(key, result, err)
+			}
+		}(key)
+	}
+
+	wg.Wait()
+
+	// the full batch didn't wait for the end of the window
+	if n := atomic.LoadInt32(&sender.requests); n != 1 {
+		t.Fatal(n)
+	}
+
+	// keys of another slot go in their own batch
+	coalescer.Window = time.Millisecond
+	if result, err := coalescer.Get("b"); err != nil || string(result.([]byte)) != "b" {
+		t.Fatal(result, err)
+	}
+
+	if n := atomic.LoadInt32(&sender.requests); n != 2 {
+		t.Fatal(n)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrNoKeys is returned by the commands that need at least one key when none is given.
+var ErrNoKeys = errors.New("no keys")
+
+// ListEnd defines the end of a list where LMPOP pops elements.
+type ListEnd string
+
+const (
+	PopLeft  ListEnd = "LEFT"
+	PopRight ListEnd = "RIGHT"
+)
+
+// ScoreEnd defines the end of a sorted set where ZMPOP pops members.
+type ScoreEnd string
+
+const (
+	PopMin ScoreEnd = "MIN"
+	PopMax ScoreEnd = "MAX"
+)
+
+// ZMember defines a member of a sorted set with its score.
+type ZMember struct {
+	Member []byte
+	Score  float64
+}
+
+// LPosOptions defines the optional parameters of LPOS.
+// Rank skips the first matches, or starts from the tail of the list when negative, and MaxLen bounds the number of elements compared.
+type LPosOptions struct {
+	Rank   int
+	MaxLen int
+}
+
+// LPos returns the index of the first element of the list equal to the element and false when there is none.
+// This requires Redis 6.0.6.
+func (client *Client) LPos(key string, element interface{}, options LPosOptions) (index int64, ok bool, err error) {
+	reply, err := client.Do("LPOS", options.args(key, element)...)
+	index, ok = reply.(int64)
+	return
+}
+
+// LPosCount returns the indexes of up to count elements of the list equal to the element or of all of them when count is 0.
+func (client *Client) LPosCount(key string, element interface{}, count int, options LPosOptions) (indexes []int64, err error) {
+	reply, err := client.Do("LPOS", append(options.args(key, element), "COUNT", count)...)
+	items, _ := reply.([]interface{})
+	for _, item := range items {
+		n, _ := item.(int64)
+		indexes = append(indexes, n)
+	}
+
+	return
+}
+
+func (options LPosOptions) args(key string, element interface{}) []interface{} {
+	args := []interface{}{key, element}
+	if options.Rank != 0 {
+		args = append(args, "RANK", options.Rank)
+	}
+
+	if options.MaxLen != 0 {
+		args = append(args, "MAXLEN", options.MaxLen)
+	}
+
+	return args
+}
+
+// LMPop pops up to count elements, or one when count is 0, from the first non-empty list and returns its key.
+// The key is empty when every list is empty and the keys must share a slot in a cluster. This requires Redis 7.0.
+func (client *Client) LMPop(keys []string, end ListEnd, count int) (key string, elements [][]byte, err error) {
+	return client.lmpop("LMPOP", nil, keys, end, count)
+}
+
+// BLMPop is like LMPop but waits up to the timeout, or forever when 0, for an element to pop.
+func (client *Client) BLMPop(timeout time.Duration, keys []string, end ListEnd, count int) (key string, elements [][]byte, err error) {
+	return client.lmpop("BLMPOP", []interface{}{timeout.Seconds()}, keys, end, count)
+}
+
+func (client *Client) lmpop(name string, args []interface{}, keys []string, end ListEnd, count int) (key string, elements [][]byte, err error) {
+	reply, err := client.mpop(name, args, keys, string(end), count)
+	if err != nil || reply == nil {
+		return
+	}
+
+	key = string(reply[0].([]byte))
+
+	items, _ := reply[1].([]interface{})
+	for _, item := range items {
+		value, _ := item.([]byte)
+		elements = append(elements, value)
+	}
+
+	return
+}
+
+// ZMPop pops up to count members, or one when count is 0, with the lowest or highest scores from the first non-empty sorted set and returns its key.
+// The key is empty when every sorted set is empty and the keys must share a slot in a cluster. This requires Redis 7.0.
+func (client *Client) ZMPop(keys []string, end ScoreEnd, count int) (key string, members []ZMember, err error) {
+	return client.zmpop("ZMPOP", nil, keys, end, count)
+}
+
+// BZMPop is like ZMPop but waits up to the timeout, or forever when 0, for a member to pop.
+func (client *Client) BZMPop(timeout time.Duration, keys []string, end ScoreEnd, count int) (key string, members []ZMember, err error) {
+	return client.zmpop("BZMPOP", []interface{}{timeout.Seconds()}, keys, end, count)
+}
+
+func (client *Client) zmpop(name string, args []interface{}, keys []string, end ScoreEnd, count int) (key string, members []ZMember, err error) {
+	reply, err := client.mpop(name, args, keys, string(end), count)
+	if err != nil || reply == nil {
+		return
+	}
+
+	key = string(reply[0].([]byte))
+
+	items, _ := reply[1].([]interface{})
+	for _, item := range items {
+		pair, _ := item.([]interface{})
+		if len(pair) != 2 {
+			err = fmt.Errorf("unexpected %s member '%v'", name, item)
+			return
+		}
+
+		member, _ := pair[0].([]byte)
+		score, _ := pair[1].([]byte)
+
+		z := ZMember{Member: member}
+		if z.Score, err = strconv.ParseFloat(string(score), 64); err != nil {
+			return
+		}
+
+		members = append(members, z)
+	}
+
+	return
+}
+
+// mpop sends the command formatted as [args] numkeys key [key ...] end [COUNT count] and returns its reply of a key and its items or nil.
+func (client *Client) mpop(name string, args []interface{}, keys []string, end string, count int) (reply []interface{}, err error) {
+	if len(keys) == 0 {
+		err = ErrNoKeys
+		return
+	}
+
+	args = append(args, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	args = append(args, end)
+	if count != 0 {
+		args = append(args, "COUNT", count)
+	}
+
+	result, err := client.Do(name, args...)
+	if err != nil || result == nil {
+		return
+	}
+
+	if reply, _ = result.([]interface{}); len(reply) != 2 {
+		err = fmt.Errorf("unexpected %s reply '%v'", name, result)
+		return
+	}
+
+	if _, ok := reply[0].([]byte); !ok {
+		err = fmt.Errorf("unexpected %s reply '%v'", name, result)
+	}
+
+	return
+}
+
+// SInterCard returns the number of members of the intersection of the sets counting up to limit, or all of them when 0.
+// The keys must share a slot in a cluster. This requires Redis 7.0.
+func (client *Client) SInterCard(keys []string, limit int) (n int64, err error) {
+	if len(keys) == 0 {
+		err = ErrNoKeys
+		return
+	}
+
+	args := []interface{}{len(keys)}
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	if limit != 0 {
+		args = append(args, "LIMIT", limit)
+	}
+
+	reply, err := client.Do("SINTERCARD", args...)
+	n, _ = reply.(int64)
+	return
+}
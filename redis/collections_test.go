@@ -0,0 +1,77 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollections(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	node := client.nodes["tcp://127.0.0.1:6379"]
+	node.db = db
+
+	db.result.WriteString(":3\r\n$-1\r\n*2\r\n:3\r\n:7\r\n")
+	db.result.WriteString("*2\r\n$1\r\nb\r\n*2\r\n$1\r\nx\r\n$1\r\ny\r\n*-1\r\n")
+	db.result.WriteString("*2\r\n$1\r\nz\r\n*1\r\n*2\r\n$1\r\nm\r\n$3\r\n1.5\r\n:2\r\n")
+
+	if index, ok, err := client.LPos("l", "a", LPosOptions{Rank: -1}); err != nil || !ok || index != 3 {
+		t.Fatal(index, ok, err)
+	}
+
+	if _, ok, err := client.LPos("l", "missing", LPosOptions{}); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	if indexes, err := client.LPosCount("l", "a", 0, LPosOptions{}); err != nil || !reflect.DeepEqual(indexes, []int64{3, 7}) {
+		t.Fatal(indexes, err)
+	}
+
+	key, elements, err := client.LMPop([]string{"a", "b"}, PopLeft, 2)
+	if err != nil || key != "b" || !reflect.DeepEqual(elements, [][]byte{[]byte("x"), []byte("y")}) {
+		t.Fatal(key, elements, err)
+	}
+
+	if key, elements, err := client.BLMPop(time.Second, []string{"a"}, PopRight, 0); err != nil || key != "" || elements != nil {
+		t.Fatal(key, elements, err)
+	}
+
+	members := []ZMember{{Member: []byte("m"), Score: 1.5}}
+	if key, result, err := client.ZMPop([]string{"z"}, PopMin, 0); err != nil || key != "z" || !reflect.DeepEqual(result, members) {
+		t.Fatal(key, result, err)
+	}
+
+	if n, err := client.SInterCard([]string{"s", "t"}, 10); err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+
+	if _, err := client.SInterCard(nil, 0); err != ErrNoKeys {
+		t.Fatal(err)
+	}
+
+	// the keys are checked before the request is sent in a cluster
+	state := &mapping{
+		id:        1,
+		shards:    true,
+		nodes:     map[string]*Conn{node.address: node},
+		replicas:  make(map[string]*Conn),
+		ids:       make(map[string]string),
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	state.slots.fill(0, 16383, node)
+	client.state.Store(state)
+
+	if _, _, err := client.LMPop([]string{"a", "b"}, PopLeft, 0); err == nil {
+		t.Fatal("expecting a cross slot error")
+	} else if _, ok := err.(*CrossSlotError); !ok {
+		t.Fatal(err)
+	}
+}
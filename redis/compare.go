@@ -0,0 +1,123 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultMaximumMismatches defines the default number of mismatches reported per second by a MirrorClient.
+var DefaultMaximumMismatches = 10
+
+// Mismatch describes a command of a dark read whose result from the secondary differs from the one of the primary.
+// Diff summarizes the difference without the values themselves e.g. "3 vs 4 items".
+type Mismatch struct {
+	Command   string
+	Key       string
+	Primary   interface{}
+	Secondary interface{}
+	Diff      string
+}
+
+// rateLimit allows a number of events per second.
+type rateLimit struct {
+	mu     sync.Mutex
+	window time.Time
+	count  int
+}
+
+func (r *rateLimit) allow(max int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.window) >= time.Second {
+		r.window, r.count = now, 0
+	}
+
+	if r.count >= max {
+		return false
+	}
+
+	r.count++
+	return true
+}
+
+// compare reports the commands whose results differ between the primary and the secondary.
+func (client *MirrorClient) compare(primary, secondary *Request) {
+	max := client.MaximumMismatches
+	if 0 == max {
+		max = DefaultMaximumMismatches
+	}
+
+	for i := range primary.commands {
+		a, b := &primary.commands[i], &secondary.commands[i]
+		diff := difference(a.result, a.err, b.result, b.err)
+		if diff == "" || !client.mismatches.allow(max) {
+			continue
+		}
+
+		mismatch := Mismatch{
+			Command:   a.name,
+			Primary:   a.result,
+			Secondary: b.result,
+			Diff:      diff,
+		}
+
+		if keys := a.keys(); len(keys) != 0 {
+			mismatch.Key = argString(a.args[keys[0]])
+		}
+
+		if a.err != nil {
+			mismatch.Primary = a.err
+		}
+
+		if b.err != nil {
+			mismatch.Secondary = b.err
+		}
+
+		client.Mismatch(mismatch)
+	}
+}
+
+// difference summarizes how the results differ or returns an empty string when they are the same.
+func difference(a interface{}, errA error, b interface{}, errB error) string {
+	switch {
+	case errA != nil || errB != nil:
+		if errA != nil && errB != nil && errA.Error() == errB.Error() {
+			return ""
+		}
+
+		return fmt.Sprintf("error %v vs %v", errA, errB)
+	case reflect.DeepEqual(a, b):
+		return ""
+	case reflect.TypeOf(a) != reflect.TypeOf(b):
+		return fmt.Sprintf("%T vs %T", a, b)
+	}
+
+	switch x := a.(type) {
+	case []interface{}:
+		y := b.([]interface{})
+		if len(x) != len(y) {
+			return fmt.Sprintf("%d vs %d items", len(x), len(y))
+		}
+
+		for i := range x {
+			if diff := difference(x[i], nil, y[i], nil); diff != "" {
+				return fmt.Sprintf("item %d: %s", i, diff)
+			}
+		}
+	case []byte:
+		if y := b.([]byte); len(x) != len(y) {
+			return fmt.Sprintf("%d vs %d bytes", len(x), len(y))
+		}
+
+		return "different bytes"
+	case int64:
+		return fmt.Sprintf("%d vs %d", x, b)
+	}
+
+	return "different values"
+}
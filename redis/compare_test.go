@@ -0,0 +1,77 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestDarkReads(t *testing.T) {
+	var dbs []*mockDB
+	var clients []*Client
+	for i := 0; i < 2; i++ {
+		db := new(mockDB)
+		client := new(Client)
+		client.load()
+		client.nodes["tcp://127.0.0.1:6379"].db = db
+
+		defer client.Close()
+
+		dbs = append(dbs, db)
+		clients = append(clients, client)
+	}
+
+	mismatches := make(chan Mismatch, 2)
+	client := &MirrorClient{
+		Primary:           clients[0],
+		Secondary:         clients[1],
+		MaximumMismatches: 1,
+		Mismatch: func(mismatch Mismatch) {
+			mismatches <- mismatch
+		},
+	}
+
+	dbs[0].result.WriteString("*2\r\n$1\r\na\r\n$1\r\nb\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n")
+	dbs[1].result.WriteString("*2\r\n$1\r\na\r\n$1\r\nb\r\n*1\r\n$1\r\na\r\n")
+
+	for i := 0; i < 2; i++ {
+		result, err := client.Do("LRANGE", "list", 0, -1)
+		if err != nil || len(result.([]interface{})) != 2 {
+			t.Fatal(result, err)
+		}
+	}
+
+	client.Close()
+	close(mismatches)
+
+	mismatch := <-mismatches
+	if mismatch.Command != "LRANGE" || mismatch.Key != "list" || mismatch.Diff != "2 vs 1 items" {
+		t.Fatal(mismatch)
+	}
+
+	if _, ok := <-mismatches; ok {
+		t.Fatal("expecting a single mismatch")
+	}
+}
+
+func TestDifference(t *testing.T) {
+	for _, item := range []struct {
+		a, b interface{}
+		diff string
+	}{
+		{[]byte("abc"), []byte("abc"), ""},
+		{[]byte("abc"), []byte("ab"), "3 vs 2 bytes"},
+		{[]byte("abc"), []byte("abd"), "different bytes"},
+		{int64(1), int64(2), "1 vs 2"},
+		{int64(1), []byte("1"), "int64 vs []uint8"},
+		{[]interface{}{int64(1), []byte("a")}, []interface{}{int64(1), []byte("ab")}, "item 1: 1 vs 2 bytes"},
+	} {
+		if diff := difference(item.a, nil, item.b, nil); diff != item.diff {
+			t.Fatal(item, diff)
+		}
+	}
+
+	if diff := difference(nil, ReplyError("ERR x"), nil, ReplyError("ERR x")); diff != "" {
+		t.Fatal(diff)
+	}
+}
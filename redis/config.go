@@ -0,0 +1,70 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+)
+
+// ConfigGet returns the configuration parameters matching the pattern on the node serving slot 0.
+func (client *Client) ConfigGet(pattern string) (result map[string]string, err error) {
+	node := client.load().slots.get(0)
+	if node == nil {
+		err = fmt.Errorf("no node serving slot %d", 0)
+		return
+	}
+
+	result, err = node.ConfigGet(pattern)
+	return
+}
+
+// ConfigSet changes the configuration parameter on every master node and, optionally, on every replica.
+// Nodes that failed are reported with NodeErrors; the change is still applied on the others.
+func (client *Client) ConfigSet(key, value string, replicas bool) (err error) {
+	nodes := make(map[string]*Conn)
+	for name, node := range client.masters() {
+		nodes[name] = node
+	}
+
+	if replicas {
+		state := client.load()
+		for name, node := range state.replicas {
+			nodes[name] = node
+		}
+	}
+
+	_, err = each(nodes, func(name string, node *Conn) (interface{}, error) {
+		return nil, node.ConfigSet(key, value)
+	})
+
+	return
+}
+
+// ConfigGet returns the configuration parameters matching the pattern.
+func (conn *Conn) ConfigGet(pattern string) (result map[string]string, err error) {
+	reply, err := conn.Do("CONFIG", "GET", pattern)
+	if err != nil {
+		return
+	}
+
+	items, ok := reply.([]interface{})
+	if !ok || len(items)%2 != 0 {
+		err = fmt.Errorf("unexpected CONFIG GET reply '%v'", reply)
+		return
+	}
+
+	result = make(map[string]string)
+	for i := 0; i < len(items); i += 2 {
+		key, _ := items[i].([]byte)
+		value, _ := items[i+1].([]byte)
+		result[string(key)] = string(value)
+	}
+
+	return
+}
+
+// ConfigSet changes the configuration parameter.
+func (conn *Conn) ConfigSet(key, value string) (err error) {
+	err = expectOK(conn.Do("CONFIG", "SET", key, value))
+	return
+}
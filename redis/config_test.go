@@ -0,0 +1,37 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigGet(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	db.result.WriteString("*4\r\n$7\r\ntimeout\r\n$1\r\n0\r\n$9\r\nmaxmemory\r\n$4\r\n1024\r\n")
+	result, err := conn.ConfigGet("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"timeout":   "0",
+		"maxmemory": "1024",
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %v", result)
+	}
+
+	client := &Client{}
+	client.once.Do(func() {})
+	client.state.Store(&mapping{shards: true})
+
+	if _, err := client.ConfigGet("*"); err == nil {
+		t.Fatal("expecting no node serving slot 0")
+	}
+}
@@ -3,10 +3,12 @@
 package redis
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +21,12 @@ var DefaultMaximumPendingRequests = 1000
 // DefaultMaximumConnectionRetries defines the number of times the client will try to connect to the Redis database before giving up.
 var DefaultMaximumConnectionRetries = 8
 
+// DefaultMaximumBatchSize defines the default maximum number of pending requests written to the Redis database at once.
+var DefaultMaximumBatchSize = 64
+
+// ErrClosed is returned without sending the request when the connection was closed, e.g. to a node that left the cluster.
+var ErrClosed = errors.New("connection closed")
+
 // DefaultRetryTimeout defines the duration multiplicatively increased to provide exponential backoff delay when connecting to the Redis database.
 var DefaultRetryTimeout = time.Second
 
@@ -29,13 +37,91 @@ type Conn struct {
 	MaximumConnectionRetries  int
 	RetryTimeout              time.Duration
 
-	db  dialer
-	lua map[string]string
+	// Breaker optionally fails requests right away when the node keeps failing.
+	Breaker Breaker
+
+	// FailFast returns ErrOverloaded instead of blocking when MaximumPendingRequests are already queued.
+	// The request can still wait up to FailFastTimeout for the queue to drain.
+	FailFast        bool
+	FailFastTimeout time.Duration
+
+	// MaximumOfflineRequests is the number of requests held, when the node stays unreachable after all connection retries, until it is back.
+	// The connection keeps retrying every RetryTimeout and requests held for more than OfflineTimeout fail with ErrOffline.
+	// By default, requests fail right away instead.
+	MaximumOfflineRequests int
+	OfflineTimeout         time.Duration
+
+	// MaximumBatchSize is the number of requests written at once when many are pending.
+	// FlushInterval optionally delays writing a request to give the next ones a chance to be written with it.
+	MaximumBatchSize int
+	FlushInterval    time.Duration
+
+	// KeepWarmInterval optionally sends PING once the connection has been idle that long so that firewalls don't drop it.
+	// This also reconnects a broken connection before the next request needs it.
+	KeepWarmInterval time.Duration
+
+	// PriorityWeight is the number of Interactive requests sent for each Batch request when both are pending.
+	PriorityWeight int
+
+	// MaximumReplySize optionally fails requests with ReplyTooLargeError when a reply is larger than the number of bytes.
+	// This prevents running out of memory on huge replies and the connection is then reset.
+	MaximumReplySize int64
+
+	// StrictProtocol validates the framing of replies and fails with ProtocolError, which resets the connection.
+	StrictProtocol bool
+
+	// Credentials optionally provides the credentials sent with AUTH every time the connection is established.
+	Credentials CredentialsProvider
+
+	// NoEvict sends CLIENT NO-EVICT ON every time the connection is established to protect it from the eviction of clients under memory pressure.
+	// NoTouch sends CLIENT NO-TOUCH ON so that its commands don't change the last access time of the keys e.g. for scanners.
+	NoEvict bool
+	NoTouch bool
+
+	// Clock and Rand optionally replace the time and the random numbers of the connection and of the middleware sending to it.
+	Clock Clock
+	Rand  Rand
+
+	db       dialer
+	address  string
+	database int
+	lua      map[string]string
+
+	// readonly sends READONLY on connect for replicas of a cluster to serve reads.
+	readonly bool
+
+	// connected is optionally called in the background every time the connection is established.
+	connected func(*Conn)
+
+	feed  chan *Request
+	batch chan *Request
+	conn  *net.Conn
+	once  sync.Once
+	wg    sync.WaitGroup
+
+	// closed is set under closing once the queues stop accepting requests.
+	closing sync.RWMutex
+	closed  bool
+
+	// failures counts consecutive requests that failed without a reply.
+	failures    int32
+	quarantined int32
 
-	feed chan *Request
-	conn *net.Conn
-	once sync.Once
-	wg   sync.WaitGroup
+	// unhealthy is set when the last health check of the node failed.
+	unhealthy int32
+
+	circuit circuit
+
+	// latency is the moving average of the reply time in nanoseconds.
+	latency int64
+
+	queued      int64
+	queuedBatch int64
+	inflight    int64
+	overloaded  int64
+
+	stats  counters
+	tracer atomic.Value
 }
 
 type dialerFunc func() (net.Conn, error)
@@ -55,6 +141,7 @@ func (conn *Conn) process() {
 	}
 
 	conn.feed = make(chan *Request, pending)
+	conn.batch = make(chan *Request, pending)
 
 	requests := conn.MaximumConcurrentRequests
 	if 0 == requests {
@@ -87,36 +174,57 @@ func (conn *Conn) process() {
 			timeout = DefaultRetryTimeout
 		}
 
+		batch := conn.MaximumBatchSize
+		if 0 == batch {
+			batch = DefaultMaximumBatchSize
+		}
+
+		window := conn.FlushInterval
+		idle := conn.KeepWarmInterval
+
+		weight := conn.PriorityWeight
+		if 0 == weight {
+			weight = DefaultPriorityWeight
+		}
+
+		queues := scheduler{weight: weight}
+
 		var encoder *Encoder
 		var decoder *Decoder
 
 		// try to connect for the first time
 		fd, err := conn.connect()
 
-		// when in fail state, all pending commands are purged
-		fail := false
-
-		for cmd := range conn.feed {
-			if fail {
-				if fail = cmd != nil; fail {
-					cmd.err = err
-					close(cmd.done)
-				}
+		// number of requests encoded but not yet written
+		unflushed := 0
 
-				continue
+		// flush writes the buffered requests at once
+		// on failure, the requests waiting for their reply fail when the connection is closed and the next one reconnects
+		flush := func() {
+			if unflushed == 0 {
+				return
 			}
 
-			c := cmd
-			n := 0
+			unflushed = 0
+			if e := encoder.Flush(); e != nil {
+				fd.Close()
+				fd = nil
+				encoder = nil
+				decoder = nil
+				err = e
+			}
+		}
 
-			for n < retries {
+		// send encodes the request and enqueues the decoding of its reply, reconnecting when needed
+		send := func(c *Request, retries int) bool {
+			for n := 0; n < retries; n++ {
 				// encode and send the request over the network
 				if fd != nil {
 					if encoder == nil {
 						encoder = NewEncoder(fd)
 					}
 
-					err = c.encode(encoder)
+					err = c.encode(encoder, conn.clock().Now())
 				}
 
 				// handle errors by reconnecting
@@ -125,10 +233,11 @@ func (conn *Conn) process() {
 						fd.Close()
 						encoder = nil
 						decoder = nil
+						unflushed = 0
 					}
 
 					if n != 0 {
-						time.Sleep(time.Duration(int64(n) * int64(timeout)))
+						conn.clock().Sleep(time.Duration(int64(n) * int64(timeout)))
 						log.Println("retry connect", n)
 					}
 
@@ -136,7 +245,6 @@ func (conn *Conn) process() {
 					if err != nil {
 						log.Println("connection error:", err)
 					}
-					n++
 					c.err = err
 					continue
 				}
@@ -145,31 +253,148 @@ func (conn *Conn) process() {
 
 				if decoder == nil {
 					decoder = NewDecoder(fd)
+					decoder.MaximumReplySize = conn.MaximumReplySize
+					decoder.Strict = conn.StrictProtocol
 				}
 
 				// enqueue the decoding of the response to the request
-				d := decoder
-				read <- func() {
-					c.decode(d)
-					close(c.done)
+				d, w := decoder, fd
+				f := func() {
+					// wait for a blocking command as long as its deadline tells instead of the read timeout
+					until := c.waitUntil()
+					if !until.IsZero() {
+						w.SetReadDeadline(until)
+					}
+
+					// the rest of a reply that is too large or that failed to be read is lost with the connection
+					if c.decode(d); d.truncated || IsNetworkError(c.err) {
+						w.Close()
+					}
+
+					if !until.IsZero() {
+						w.SetReadDeadline(time.Time{})
+					}
+
+					if t := conn.tracing(); t != nil {
+						t.reply(conn.address, c)
+					}
+
+					atomic.AddInt64(&conn.stats.replies, int64(c.replies()))
+
+					s, _ := c.commands[0].result.(*stream)
+
+					atomic.AddInt64(&conn.inflight, -1)
+					c.finish()
+
+					// the next replies are decoded once a streamed reply is closed
+					if s != nil {
+						<-s.done
+					}
 				}
 
-				n = 0
-				break
+				if t := conn.tracing(); t != nil {
+					t.request(conn.address, c)
+				}
+
+				atomic.AddInt64(&conn.inflight, 1)
+				atomic.AddInt64(&conn.stats.commands, int64(len(c.commands)))
+				unflushed++
+
+				// the replies can't be read until the requests are written
+				select {
+				case read <- f:
+				default:
+					flush()
+					read <- f
+				}
+
+				return true
 			}
 
-			// enter fail mode to purge pending requests
-			if n != 0 {
-				close(c.done)
+			return false
+		}
 
-				fail = true
-				go func() {
-					conn.feed <- nil
-				}()
+		// requests held while the node is offline and when to try to reach it again
+		var offline offlineQueue
+		var retry time.Time
+
+		for {
+			var timer <-chan time.Time
+			warm := false
+
+			switch {
+			case unflushed != 0 && window != 0:
+				timer = conn.clock().After(window)
+			case len(offline) != 0:
+				timer = conn.clock().After(retry.Sub(conn.clock().Now()))
+			case idle != 0:
+				timer = conn.clock().After(idle)
+				warm = true
+			}
 
-				panic("fail to reconnect")
+			cmd, ok, expired := queues.next(conn, timer)
+			if !ok {
 				break
 			}
+
+			if expired && warm {
+				// nobody waits for the reply
+				ping := NewRequest("PING")
+				ping.done = make(chan struct{})
+				send(ping, 1)
+			}
+
+			if cmd != nil {
+				conn.dequeued(cmd)
+
+				switch {
+				case len(offline) != 0:
+					// keep the order of the requests until the node is back
+					offline = offline.hold(conn, cmd)
+				case send(cmd, retries):
+					// sent
+				case conn.MaximumOfflineRequests == 0:
+					cmd.finish()
+					conn.purge(err)
+				default:
+					offline = offline.hold(conn, cmd)
+					retry = conn.clock().Now().Add(timeout)
+				}
+			}
+
+			// write the requests when enough are buffered or when no others are coming
+			if unflushed >= batch || unflushed != 0 && (cmd == nil || window == 0 && len(conn.feed)+len(conn.batch) == 0) {
+				flush()
+			}
+
+			if len(offline) == 0 || conn.clock().Now().Before(retry) {
+				continue
+			}
+
+			// send the requests held when the node is reachable again
+			offline = offline.expire(conn.clock().Now())
+			for len(offline) != 0 && send(offline[0].request, 2) {
+				offline = offline[1:]
+			}
+
+			flush()
+			retry = conn.clock().Now().Add(timeout)
+		}
+
+		// the batch requests still queued are sent before closing
+		for len(conn.batch) != 0 {
+			cmd := <-conn.batch
+			conn.dequeued(cmd)
+			if !send(cmd, 1) {
+				cmd.finish()
+			}
+		}
+
+		flush()
+
+		for _, item := range offline {
+			item.request.err = ErrOffline
+			item.request.finish()
 		}
 
 		close(read)
@@ -180,6 +405,28 @@ func (conn *Conn) process() {
 	return
 }
 
+// purge fails the pending requests with the error.
+func (conn *Conn) purge(err error) {
+	for {
+		select {
+		case cmd, ok := <-conn.feed:
+			if !ok {
+				return
+			}
+
+			conn.dequeued(cmd)
+			cmd.err = err
+			cmd.finish()
+		case cmd := <-conn.batch:
+			conn.dequeued(cmd)
+			cmd.err = err
+			cmd.finish()
+		default:
+			return
+		}
+	}
+}
+
 // LuaScript loads a script into the script cache.
 func (conn *Conn) LuaScript(code string) (id string, err error) {
 	result, err := conn.Do("SCRIPT", "LOAD", code)
@@ -197,31 +444,110 @@ func (conn *Conn) LuaScript(code string) (id string, err error) {
 }
 
 // Close tears down the connection to the Redis database.
+// Requests sent afterwards fail with ErrClosed.
 func (conn *Conn) Close() {
 	if conn == nil {
 		return
 	}
 
+	// make sure a connection that was never used won't start
+	conn.once.Do(func() {})
+
+	conn.closing.Lock()
+	closed := conn.closed
+	conn.closed = true
+	conn.closing.Unlock()
+
+	if closed || conn.feed == nil {
+		return
+	}
+
 	close(conn.feed)
 	conn.wg.Wait()
 }
 
 // Do executes the specified command (with optional arguments) to the Redis instance and waits to decode the reply.
 func (conn *Conn) Do(name string, args ...interface{}) (result interface{}, err error) {
-	request := NewRequest(name, args...)
+	request := newRequest(name, args)
 	if err = conn.Send(request); err == nil {
 		result = request.commands[len(request.commands)-1].result
 	}
 
+	release(request)
 	return
 }
 
 // Send sends the specified request to the Redis instance and waits for the reply.
-func (conn *Conn) Send(request *Request) error {
+func (conn *Conn) Send(request *Request) (err error) {
+	if err = conn.send(request); conn.Credentials != nil && isAuthError(err) {
+		err = conn.reauthenticate(request)
+	}
+
+	return
+}
+
+// reauthenticate authenticates again with fresh credentials and retries the request once.
+func (conn *Conn) reauthenticate(request *Request) (err error) {
+	if err = conn.authenticate(); err == nil {
+		err = conn.send(request)
+	} else {
+		request.err = err
+	}
+
+	return
+}
+
+func (conn *Conn) send(request *Request) error {
+	if request.expired(conn.clock().Now()) {
+		request.err = ErrDeadlineExceeded
+		return request.err
+	}
+
+	probe, err := conn.begin(request)
+	if err != nil {
+		return err
+	}
+
+	start := conn.clock().Now()
+	if err = conn.enqueue(request); err != nil {
+		request.err = err
+		return err
+	}
+
+	<-request.done
+	return conn.end(request, probe, start)
+}
+
+// begin prepares the request to be enqueued unless the circuit breaker rejects it.
+func (conn *Conn) begin(request *Request) (probe bool, err error) {
+	ok, probe := conn.allow()
+	if !ok {
+		request.err = ErrCircuitOpen
+		err = request.err
+		return
+	}
+
 	conn.once.Do(conn.process)
 	request.done = make(chan struct{})
-	conn.feed <- request
-	<-request.done
+	return
+}
+
+// end records the outcome of the request once it is done.
+func (conn *Conn) end(request *Request, probe bool, start time.Time) error {
+	// any reply, even an error, means the node is alive
+	_, replied := request.err.(ReplyError)
+	failed := request.err != nil && !replied
+	if failed {
+		atomic.AddInt32(&conn.failures, 1)
+		conn.stats.fail(request.err)
+	} else {
+		atomic.StoreInt32(&conn.failures, 0)
+		conn.observe(conn.clock().Now().Sub(start))
+	}
+
+	conn.stats.count(request.err)
+	conn.record(probe, failed)
+
 	return request.err
 }
 
@@ -231,15 +557,62 @@ func (conn *Conn) connect() (result net.Conn, err error) {
 		return
 	}
 
+	c = &countedConn{
+		Conn: c,
+		conn: conn,
+	}
+
+	// work directly on the stream to bypass everything
+	encoder := NewEncoder(c)
+	decoder := NewDecoder(c)
+
+	if conn.Credentials != nil {
+		var args []interface{}
+		if args, err = conn.auth(); err != nil {
+			c.Close()
+			return
+		}
+
+		encoder.Encode("AUTH", args...)
+		if _, err = decoder.Decode(); err != nil {
+			c.Close()
+			return
+		}
+	}
+
+	if conn.database != 0 {
+		encoder.Encode("SELECT", conn.database)
+		if _, err = decoder.Decode(); err != nil {
+			c.Close()
+			return
+		}
+	}
+
+	if conn.readonly {
+		encoder.Encode("READONLY")
+		if _, err = decoder.Decode(); err != nil {
+			c.Close()
+			return
+		}
+	}
+
+	for mode, on := range map[string]bool{"NO-EVICT": conn.NoEvict, "NO-TOUCH": conn.NoTouch} {
+		if !on {
+			continue
+		}
+
+		encoder.Encode("CLIENT", mode, "ON")
+		if _, err = decoder.Decode(); err != nil {
+			c.Close()
+			return
+		}
+	}
+
 	// load lua scripts when needed
 	n := len(conn.lua)
 	if n != 0 {
 		ids := make([]string, 0, n)
 
-		// work directly on the stream to bypass everything
-		encoder := NewEncoder(c)
-		decoder := NewDecoder(c)
-
 		// send all scripts commands at once
 		for key, code := range conn.lua {
 			encoder.Encode("SCRIPT", "LOAD", code)
@@ -262,6 +635,13 @@ func (conn *Conn) connect() (result net.Conn, err error) {
 		}
 	}
 
+	atomic.AddInt64(&conn.stats.connects, 1)
+	atomic.StoreInt64(&conn.stats.since, conn.clock().Now().UnixNano())
+
+	if conn.connected != nil {
+		go conn.connected(conn)
+	}
+
 	result = c
 	return
 }
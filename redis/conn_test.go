@@ -7,6 +7,7 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -55,6 +56,86 @@ func TestConnectionDropped(t *testing.T) {
 	}
 }
 
+func TestClientModes(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db, NoEvict: true, NoTouch: true}
+
+	db.result.WriteString("+OK\r\n+OK\r\n")
+	fd, err := conn.connect()
+	if err != nil || atomic.LoadInt32(&db.writes) != 2 || db.result.Len() != 0 {
+		t.Fatal(err, db.writes)
+	}
+
+	fd.Close()
+
+	db.result.WriteString("-ERR unknown subcommand 'NO-EVICT'\r\n")
+	if _, err := conn.connect(); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	client := &Client{NoEvict: true}
+	defer client.Close()
+
+	if node := client.Node("tcp://127.0.0.2:6379"); !node.NoEvict || !client.lease(node).NoEvict {
+		t.Fatal("expecting the mode to be set on every connection")
+	}
+}
+
+func TestCloseUnused(t *testing.T) {
+	conn := &Conn{db: new(mockDB)}
+	conn.Close()
+}
+
+func TestSendClosed(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("+PONG\r\n")
+
+	conn := &Conn{db: db}
+	if _, err := conn.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.Close()
+	conn.Close()
+
+	for _, priority := range []Priority{Interactive, Batch} {
+		request := NewRequest("PING")
+		request.SetPriority(priority)
+		if err := conn.Send(request); err != ErrClosed {
+			t.Fatal(priority, err)
+		}
+	}
+
+	unused := &Conn{db: new(mockDB)}
+	unused.Close()
+
+	if _, err := unused.Do("PING"); err != ErrClosed {
+		t.Fatal(err)
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	db.result.WriteString("-ERR wrong\r\n+PONG\r\n")
+	request := NewRequest("BAD")
+	request.Add("PING")
+	if err := conn.Send(request); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	if result, err := request.Result(1); err != nil || result != "PONG" {
+		t.Fatal(err, result)
+	}
+
+	db.result.WriteString(":1\r\n")
+	if result, err := conn.Do("INCR", "count"); err != nil || result != int64(1) {
+		t.Fatal(err, result)
+	}
+}
+
 var testCommands = []struct {
 	args     []interface{}
 	expected interface{}
@@ -215,3 +296,71 @@ func BenchmarkConn(b *testing.B) {
 	close(send)
 	wg.Wait()
 }
+
+func TestCoalescedWrites(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	db.result.WriteString("+OK\r\n:1\r\n$3\r\nbar\r\n")
+	request := NewRequest("SET", "foo", "bar")
+	request.Add("EXPIRE", "foo", 10)
+	request.Add("GET", "foo")
+	if err := conn.Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&db.writes); n != 1 {
+		t.Fatal("expecting a single write instead of", n)
+	}
+}
+
+func TestRequestReset(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	request := NewRequest("GET", "foo")
+	for i := 0; i < 3; i++ {
+		db.result.WriteString(fmt.Sprintf(":%d\r\n", i))
+		if err := conn.Send(request); err != nil {
+			t.Fatal(err)
+		}
+
+		if result, err := request.Result(0); err != nil || result != int64(i) {
+			t.Fatal(err, result)
+		}
+
+		request.Reset()
+		request.Add("INCR", "foo")
+
+		if request.Len() != 1 || request.commands[0].result != nil {
+			t.Fatal("request should be empty")
+		}
+	}
+}
+
+func TestKeepWarm(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("+PONG\r\n+PONG\r\n+PONG\r\n")
+
+	conn := &Conn{
+		KeepWarmInterval: time.Millisecond,
+		db:               db,
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the idle connection keeps sending PING on its own
+	for i := 0; atomic.LoadInt32(&db.writes) < 3; i++ {
+		if i == 1000 {
+			t.Fatal("expecting PINGs on the idle connection")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
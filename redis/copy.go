@@ -0,0 +1,216 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+)
+
+// DefaultCopyBatchSize defines the default number of keys copied at once.
+var DefaultCopyBatchSize = 100
+
+// CopyOptions defines the optional parameters of Copy.
+type CopyOptions struct {
+	// BatchSize is the number of keys copied at once, whose commands are pipelined to the nodes that serve them.
+	BatchSize int
+
+	// Replace overwrites keys that already exist in the destination.
+	Replace bool
+
+	// DropTTL doesn't copy the remaining time to live of the keys.
+	DropTTL bool
+}
+
+// KeyErrors holds the errors returned for each key when a command is executed on many keys.
+type KeyErrors map[string]error
+
+func (errs KeyErrors) Error() string {
+	return joinErrors(errs)
+}
+
+// keyBatch holds the commands of the keys sent to a node.
+type keyBatch struct {
+	node    *Conn
+	keys    []string
+	request *Request
+}
+
+// Copy copies the keys to another Redis database or cluster using DUMP and RESTORE.
+// Keys that don't exist are skipped while failures are reported with KeyErrors.
+func (client *Client) Copy(dst *Client, keys []string, options CopyOptions) (err error) {
+	size := options.BatchSize
+	if 0 == size {
+		size = DefaultCopyBatchSize
+	}
+
+	errs := make(KeyErrors)
+	for len(keys) != 0 {
+		n := size
+		if n > len(keys) {
+			n = len(keys)
+		}
+
+		client.copy(dst, keys[:n], options, errs)
+		keys = keys[n:]
+	}
+
+	if len(errs) != 0 {
+		err = errs
+	}
+
+	return
+}
+
+// copy dumps the keys with a pipeline per node of the source and restores them with a pipeline per node of the destination.
+func (client *Client) copy(dst *Client, keys []string, options CopyOptions, errs KeyErrors) {
+	dumps := client.pipelineKeys(keys, func(request *Request, key string) {
+		request.Add("DUMP", key)
+		request.Add("PTTL", key)
+	})
+
+	restores := make(map[string][]interface{})
+	for _, batch := range dumps {
+		for i, key := range batch.keys {
+			dump, err := batch.request.Result(2 * i)
+			reply, e := batch.request.Result(2*i + 1)
+			if err == nil {
+				err = e
+			}
+
+			if err != nil {
+				errs[key] = err
+				continue
+			}
+
+			// -1 when the key has no TTL and -2 when it vanished
+			data, ok := dump.([]byte)
+			ttl, _ := reply.(int64)
+			if !ok || ttl == -2 {
+				continue
+			}
+
+			if ttl < 0 || options.DropTTL {
+				ttl = 0
+			}
+
+			args := []interface{}{key, ttl, data}
+			if options.Replace {
+				args = append(args, "REPLACE")
+			}
+
+			restores[key] = args
+		}
+	}
+
+	var restored []string
+	for _, key := range keys {
+		if restores[key] != nil {
+			restored = append(restored, key)
+		}
+	}
+
+	for _, batch := range dst.pipelineKeys(restored, func(request *Request, key string) {
+		request.Add("RESTORE", restores[key]...)
+	}) {
+		for i, key := range batch.keys {
+			if _, err := batch.request.Result(i); err != nil {
+				errs[key] = err
+			}
+		}
+	}
+}
+
+// pipelineKeys groups the keys by the node that serves them and pipelines the commands added for each key to its node concurrently.
+// Commands whose slot moved are sent again individually and follow the redirection.
+func (client *Client) pipelineKeys(keys []string, add func(request *Request, key string)) (batches []*keyBatch) {
+	pending := make(map[*Conn]*keyBatch)
+	for _, key := range keys {
+		node := client.serving(key)
+		batch := pending[node]
+		if batch == nil {
+			batch = &keyBatch{node: node, request: new(Request)}
+			pending[node] = batch
+			batches = append(batches, batch)
+		}
+
+		batch.keys = append(batch.keys, key)
+		add(batch.request, key)
+	}
+
+	var wg sync.WaitGroup
+	for _, batch := range batches {
+		wg.Add(1)
+		go func(batch *keyBatch) {
+			client.sendBatch(batch)
+			wg.Done()
+		}(batch)
+	}
+
+	wg.Wait()
+	return
+}
+
+// sendBatch pipelines the commands of the batch to its node and sends the redirected ones again through the client.
+func (client *Client) sendBatch(batch *keyBatch) {
+	request := batch.request
+	if batch.node == nil {
+		client.Send(request)
+	} else {
+		client.sendNode(batch.node, request)
+	}
+
+	// commands that couldn't be read share the failure of the request
+	_, replied := request.err.(ReplyError)
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		if request.err != nil && !replied && cmd.err == nil {
+			cmd.err = request.err
+		}
+
+		if IsRedirect(cmd.err) {
+			redirected := NewRequest(cmd.name, cmd.args...)
+			client.Send(redirected)
+			cmd.result, cmd.err = redirected.Result(0)
+		}
+	}
+}
+
+// serving returns the node that serves the key or nil when unknown.
+func (client *Client) serving(key string) *Conn {
+	state := client.load()
+
+	slot := 0
+	if state.shards {
+		slot = Slot(key)
+	}
+
+	return state.slots.get(slot)
+}
+
+// dump returns the serialized value of the key with its time to live in milliseconds, 0 when it has none, or false when there is no key.
+func (client *Client) dump(key string) (data []byte, ttl int64, ok bool, err error) {
+	request := NewRequest("DUMP", key)
+	request.Add("PTTL", key)
+
+	if err = client.Send(request); err != nil {
+		return
+	}
+
+	dump, _ := request.Result(0)
+	if data, ok = dump.([]byte); !ok {
+		return
+	}
+
+	// -1 when the key has no TTL and -2 when it vanished
+	reply, _ := request.Result(1)
+	if ttl, _ = reply.(int64); ttl == -2 {
+		data, ok = nil, false
+		return
+	}
+
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	return
+}
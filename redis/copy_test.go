@@ -0,0 +1,70 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	src, dst := new(mockDB), new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = src
+
+	other := new(Client)
+	defer other.Close()
+
+	other.load()
+	other.nodes["tcp://127.0.0.1:6379"].db = dst
+
+	// the keys are dumped and restored in a pipeline per batch while the missing key is skipped
+	src.result.WriteString("$3\r\nxyz\r\n:5000\r\n$-1\r\n:-2\r\n")
+	src.result.WriteString("$3\r\nuvw\r\n:-1\r\n")
+	dst.result.WriteString("+OK\r\n")
+	dst.result.WriteString("-BUSYKEY Target key name already exists.\r\n")
+
+	err := client.Copy(other, []string{"a", "b", "c"}, CopyOptions{BatchSize: 2})
+	errs, ok := err.(KeyErrors)
+	if !ok || len(errs) != 1 || !strings.Contains(errs["c"].Error(), "BUSYKEY") {
+		t.Fatal(err)
+	}
+
+	if n, m := atomic.LoadInt32(&src.writes), atomic.LoadInt32(&dst.writes); n != 2 || m != 2 {
+		t.Fatal(n, m)
+	}
+}
+
+func TestCopyFailure(t *testing.T) {
+	src, dst := new(mockDB), new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = src
+
+	other := new(Client)
+	defer other.Close()
+
+	other.load()
+	other.nodes["tcp://127.0.0.1:6379"].db = dst
+
+	// keys that couldn't be dumped aren't restored
+	src.result.WriteString("-ERR denied\r\n:-1\r\n$3\r\nxyz\r\n:-1\r\n")
+	dst.result.WriteString("+OK\r\n")
+
+	err := client.Copy(other, []string{"a", "b"}, CopyOptions{Replace: true})
+	if errs, ok := err.(KeyErrors); !ok || len(errs) != 1 || errs["a"] == nil {
+		t.Fatal(err)
+	}
+
+	if n, m := atomic.LoadInt32(&src.writes), atomic.LoadInt32(&dst.writes); n != 1 || m != 1 {
+		t.Fatal(n, m)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "strings"
+
+// CredentialsProvider is implemented to supply the credentials used to authenticate with AUTH.
+// It is called every time a connection is established so that rotated credentials are picked up without restarting.
+type CredentialsProvider interface {
+	Credentials() (username, password string, err error)
+}
+
+// CredentialsProviderFunc adapts a function to implement a CredentialsProvider.
+type CredentialsProviderFunc func() (username, password string, err error)
+
+// Credentials calls the function.
+func (f CredentialsProviderFunc) Credentials() (username, password string, err error) {
+	return f()
+}
+
+// StaticCredentials implements a CredentialsProvider that never changes.
+// The username can be left empty to use the password of requirepass.
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+// Credentials returns the username and password.
+func (c StaticCredentials) Credentials() (username, password string, err error) {
+	username, password = c.Username, c.Password
+	return
+}
+
+// auth returns the arguments of AUTH built from the current credentials.
+func (conn *Conn) auth() (args []interface{}, err error) {
+	username, password, err := conn.Credentials.Credentials()
+	if err != nil {
+		return
+	}
+
+	if username != "" {
+		args = append(args, username)
+	}
+
+	args = append(args, password)
+	return
+}
+
+// authenticate sends AUTH again on the current connection e.g. after the credentials were rotated on the server.
+func (conn *Conn) authenticate() (err error) {
+	args, err := conn.auth()
+	if err != nil {
+		return
+	}
+
+	err = conn.send(NewRequest("AUTH", args...))
+	return
+}
+
+// isAuthError returns true when Redis replied that the connection isn't or is no longer authenticated.
+func isAuthError(err error) bool {
+	e, ok := err.(ReplyError)
+	return ok && (strings.HasPrefix(string(e), "NOAUTH") || strings.HasPrefix(string(e), "WRONGPASS"))
+}
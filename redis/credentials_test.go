@@ -0,0 +1,83 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCredentials(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	passwords := []string{"old", "new"}
+	conn := &Conn{
+		Credentials: CredentialsProviderFunc(func() (username, password string, err error) {
+			username, password, passwords = "user", passwords[0], passwords[1:]
+			return
+		}),
+		db: dialerFunc(func() (net.Conn, error) {
+			return client, nil
+		}),
+	}
+
+	defer conn.Close()
+
+	// the password is rotated on the server once the connection is authenticated
+	go func() {
+		decoder := NewDecoder(server)
+		password := "old"
+		authenticated, rotated := false, false
+
+		for {
+			cmd, err := decoder.Decode()
+			if err != nil {
+				return
+			}
+
+			args := cmd.([]interface{})
+			reply := "+PONG\r\n"
+
+			switch {
+			case string(args[0].([]byte)) == "AUTH":
+				if authenticated = string(args[2].([]byte)) == password; authenticated {
+					reply = "+OK\r\n"
+				} else {
+					reply = "-WRONGPASS invalid username-password pair\r\n"
+				}
+
+				password = "new"
+			case !authenticated || !rotated:
+				authenticated, rotated = false, true
+				reply = "-NOAUTH Authentication required.\r\n"
+			}
+
+			server.Write([]byte(reply))
+		}
+	}()
+
+	if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+		t.Fatal(err, result)
+	}
+
+	if len(passwords) != 0 {
+		t.Fatal("credentials should have been asked twice")
+	}
+}
+
+func TestWrongCredentials(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{
+		MaximumConnectionRetries: 1,
+		Credentials:              StaticCredentials{Password: "wrong"},
+		db:                       db,
+	}
+
+	defer conn.Close()
+
+	db.result.WriteString("-WRONGPASS invalid username-password pair\r\n")
+	if _, err := conn.Do("PING"); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDeadlineMargin defines the extra time the connection waits for the reply of a blocking command past the deadline of its request.
+// This gives Redis the time to reply to a command that timed out on its side before the connection gives up.
+var DefaultDeadlineMargin = 250 * time.Millisecond
+
+// ErrDeadlineExceeded is returned when the deadline of a request is over before it could be sent.
+var ErrDeadlineExceeded = errors.New("request deadline exceeded")
+
+// SetDeadline sets when the caller stops waiting for the reply.
+// The timeouts of blocking commands like BLPOP or XREAD BLOCK are lowered to the time left when they are sent and the connection waits for their reply until the deadline plus DefaultDeadlineMargin, even with a shorter read timeout.
+func (request *Request) SetDeadline(deadline time.Time) {
+	request.deadline = deadline
+}
+
+// Deadline returns the deadline of the request or zero when it has none.
+func (request *Request) Deadline() time.Time {
+	return request.deadline
+}
+
+// expired returns true when the deadline of the request is over at now.
+func (request *Request) expired(now time.Time) bool {
+	return !request.deadline.IsZero() && !now.Before(request.deadline)
+}
+
+// blocking returns true when one of the commands may wait before replying.
+func (request *Request) blocking() bool {
+	for i := range request.commands {
+		if request.commands[i].blocking() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitUntil returns until when the connection waits for the reply of the request or zero to keep the read timeout.
+func (request *Request) waitUntil() time.Time {
+	if request.deadline.IsZero() || !request.blocking() {
+		return time.Time{}
+	}
+
+	return request.deadline.Add(DefaultDeadlineMargin)
+}
+
+// timeoutArgument returns the index of the timeout argument of a blocking command and whether it is in milliseconds instead of seconds.
+func (cmd *command) timeoutArgument() (index int, milliseconds bool) {
+	n := len(cmd.args)
+	switch strings.ToUpper(cmd.name) {
+	case "BLPOP", "BRPOP", "BZPOPMAX", "BZPOPMIN", "BRPOPLPUSH", "BLMOVE":
+		return n - 1, false
+	case "BLMPOP", "BZMPOP":
+		return 0, false
+	case "WAIT", "WAITAOF":
+		return n - 1, true
+	case "XREAD", "XREADGROUP":
+		for i := 0; i+1 < n; i++ {
+			if option := strings.ToUpper(argString(cmd.args[i])); option == "BLOCK" {
+				return i + 1, true
+			} else if option == "STREAMS" {
+				break
+			}
+		}
+	}
+
+	return -1, false
+}
+
+// clamp returns the arguments of the command with its timeout lowered to the time left from now until the deadline.
+// A timeout of zero, which blocks forever, is lowered too.
+func (cmd *command) clamp(deadline, now time.Time) []interface{} {
+	i, milliseconds := cmd.timeoutArgument()
+	if i < 0 || i >= len(cmd.args) {
+		return cmd.args
+	}
+
+	timeout, err := strconv.ParseFloat(argString(cmd.args[i]), 64)
+	if err != nil {
+		return cmd.args
+	}
+
+	left := deadline.Sub(now)
+	if left < time.Millisecond {
+		left = time.Millisecond
+	}
+
+	var value interface{}
+	if milliseconds {
+		if timeout != 0 && timeout <= float64(left/time.Millisecond) {
+			return cmd.args
+		}
+
+		value = int64(left / time.Millisecond)
+	} else {
+		if timeout != 0 && timeout <= left.Seconds() {
+			return cmd.args
+		}
+
+		value = strconv.FormatFloat(float64(left/time.Millisecond)/1000, 'f', 3, 64)
+	}
+
+	args := make([]interface{}, len(cmd.args))
+	copy(args, cmd.args)
+	args[i] = value
+	return args
+}
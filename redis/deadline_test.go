@@ -0,0 +1,54 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	deadline := time.Now().Add(2 * time.Second)
+
+	tests := []struct {
+		name  string
+		args  []interface{}
+		index int
+		check func(interface{}) bool
+	}{
+		{"BLPOP", []interface{}{"a", "b", 0}, 2, func(v interface{}) bool { s, ok := v.(string); return ok && s > "1.5" && s <= "2.000" }},
+		{"BLPOP", []interface{}{"a", 10}, 1, func(v interface{}) bool { _, ok := v.(string); return ok }},
+		{"BLPOP", []interface{}{"a", 1}, 1, func(v interface{}) bool { return v == 1 }},
+		{"XREAD", []interface{}{"COUNT", 1, "BLOCK", 60000, "STREAMS", "s", "$"}, 3, func(v interface{}) bool { n, ok := v.(int64); return ok && n > 1500 && n <= 2000 }},
+		{"XREAD", []interface{}{"BLOCK", 100, "STREAMS", "s", "$"}, 1, func(v interface{}) bool { return v == 100 }},
+		{"XREAD", []interface{}{"STREAMS", "BLOCK", "$"}, 1, func(v interface{}) bool { return v == "BLOCK" }},
+	}
+
+	for i, test := range tests {
+		cmd := &command{name: test.name, args: test.args}
+		args := cmd.clamp(deadline, time.Now())
+		if len(args) != len(test.args) || !test.check(args[test.index]) {
+			t.Fatal(i, args)
+		}
+	}
+
+	request := NewRequest("BLPOP", "a", 0)
+	if !request.waitUntil().IsZero() || request.expired(time.Now()) {
+		t.Fatal("expecting no deadline")
+	}
+
+	request.SetDeadline(deadline)
+	if until := request.waitUntil(); !until.Equal(deadline.Add(DefaultDeadlineMargin)) {
+		t.Fatal(until)
+	}
+
+	get := NewRequest("GET", "a")
+	if get.SetDeadline(deadline); !get.waitUntil().IsZero() {
+		t.Fatal("expecting the read timeout for a command that does not block")
+	}
+
+	request.SetDeadline(time.Now().Add(-time.Second))
+	if !request.expired(time.Now()) {
+		t.Fatal("expecting the deadline to be over")
+	}
+}
@@ -8,16 +8,78 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
+	"sync"
 )
 
 // OK represents the +OK string returned by many Redis commands.
 var OK interface{} = "+OK"
 
+// ReplyError is returned when Redis replies with an error.
+type ReplyError string
+
+func (e ReplyError) Error() string {
+	return "redis returned an error: " + string(e)
+}
+
+// ReplyTooLargeError is returned when a reply is larger than the maximum reply size.
+// The rest of the reply isn't read and the connection can't be used anymore.
+type ReplyTooLargeError struct {
+	Limit int64
+}
+
+func (e *ReplyTooLargeError) Error() string {
+	return fmt.Sprintf("redis reply larger than %d bytes", e.Limit)
+}
+
+// DefaultMaximumBulkLength defines the largest length of a bulk string accepted in strict mode, which is the largest allowed by Redis.
+var DefaultMaximumBulkLength int64 = 512 << 20
+
+// DefaultMaximumArrayLength defines the largest number of items of an array accepted in strict mode.
+var DefaultMaximumArrayLength int64 = 1 << 24
+
+// DefaultMaximumNestingDepth defines the deepest nesting of arrays accepted in strict mode.
+var DefaultMaximumNestingDepth = 32
+
+// ProtocolError is returned in strict mode when a reply violates the Redis serialization protocol.
+// The rest of the input can't be decoded anymore.
+type ProtocolError struct {
+	Reason string
+}
+
+func (e *ProtocolError) Error() string {
+	return "redis protocol error: " + e.Reason
+}
+
 // Decoder implements the decoding part of the Redis serialization protocol.
 type Decoder struct {
+	// MaximumReplySize is the number of bytes after which decoding a reply fails with ReplyTooLargeError.
+	// There is no limit when left to 0.
+	MaximumReplySize int64
+
+	// Strict validates the framing of replies and fails with ProtocolError instead of trusting the input.
+	// This protects against malformed replies e.g. from a faulty proxy.
+	Strict bool
+
+	// depth is the nesting of the array being decoded.
+	depth int
+
 	// reader adds some buffering to the input.
 	reader *bufio.Reader
+
+	// size is the number of bytes of the reply being decoded.
+	size int64
+
+	// truncated is set once a reply was too large to be read entirely.
+	truncated bool
+}
+
+// readers caches the buffers used by Unmarshal.
+var readers = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewReader(nil)
+	},
 }
 
 // NewDecoder creates a RESP decoder from the specified reader source.
@@ -29,40 +91,137 @@ func NewDecoder(reader io.Reader) (result *Decoder) {
 	return
 }
 
-func (decoder *Decoder) getLine() (result string, err error) {
-	line, err := decoder.reader.ReadString('\n')
+// getLine returns the next line without its terminator.
+// The line is only valid until the next read.
+func (decoder *Decoder) getLine() (line []byte, err error) {
+	line, err = decoder.reader.ReadSlice('\n')
+
+	// lines that don't fit in the buffer are uncommon and gathered in a new slice
+	if err == bufio.ErrBufferFull {
+		line = append([]byte(nil), line...)
+		for err == bufio.ErrBufferFull {
+			var more []byte
+			more, err = decoder.reader.ReadSlice('\n')
+			line = append(line, more...)
+		}
+	}
+
 	if err != nil {
 		return
 	}
 
 	n := len(line)
+	if err = decoder.consume(int64(n)); err != nil {
+		return
+	}
+
+	if n < 2 || line[n-2] != '\r' {
+		err = decoder.invalid(fmt.Sprintf("invalid terminator '%q'", line), fmt.Errorf("redis return data with invalid terminator '%s'", line))
+		return
+	}
+
+	line = line[:n-2]
+	return
+}
 
-	if n == 0 {
-		err = errors.New("redis returned an empty line")
+// parseInteger parses a decimal number without allocating.
+func parseInteger(text []byte) (result int64, err error) {
+	n := len(text)
+	if n == 0 || n > 20 {
+		err = fmt.Errorf("redis returned an invalid integer '%s'", text)
 		return
 	}
 
-	if n < 2 {
-		err = fmt.Errorf("redis return data with invalid terminator '%s'", []byte(line))
+	negative := text[0] == '-'
+	if negative {
+		text = text[1:]
+		if len(text) == 0 {
+			err = fmt.Errorf("redis returned an invalid integer '-'")
+			return
+		}
+	}
+
+	// the magnitude of math.MinInt64 is one more than math.MaxInt64
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+
+	var magnitude uint64
+	for _, c := range text {
+		d := uint64(c - '0')
+		if c < '0' || c > '9' || magnitude > (limit-d)/10 {
+			err = fmt.Errorf("redis returned an invalid integer '%s'", text)
+			return
+		}
+
+		magnitude = magnitude*10 + d
+	}
+
+	if result = int64(magnitude); negative {
+		result = -result
+	}
+
+	return
+}
+
+// invalid returns the error of invalid input, which is a ProtocolError in strict mode.
+func (decoder *Decoder) invalid(reason string, err error) error {
+	if decoder.Strict {
+		return &ProtocolError{
+			Reason: reason,
+		}
+	}
+
+	return err
+}
+
+// length parses the length of a bulk string or of an array.
+// In strict mode, the length must be valid and not greater than the maximum.
+func (decoder *Decoder) length(text []byte, max int64) (n int64, err error) {
+	if !decoder.Strict {
+		n, err = parseInteger(text)
 		return
 	}
 
-	if line[n-2] != '\r' && line[n-1] != '\n' {
-		err = fmt.Errorf("redis return data with invalid terminator '%s'", []byte(line))
+	n, err = strconv.ParseInt(string(text), 10, 64)
+	switch {
+	case err != nil || n < -1:
+		err = &ProtocolError{fmt.Sprintf("invalid length '%s'", text)}
+	case n > max:
+		err = &ProtocolError{fmt.Sprintf("length %d larger than %d", n, max)}
 	}
 
-	result = line[:n-2]
 	return
 }
 
-func (decoder *Decoder) get() (result interface{}, err error) {
+// consume accounts for n more bytes of the reply.
+func (decoder *Decoder) consume(n int64) error {
+	decoder.size += n
+	if limit := decoder.MaximumReplySize; limit != 0 && decoder.size > limit {
+		decoder.truncated = true
+		return &ReplyTooLargeError{
+			Limit: limit,
+		}
+	}
+
+	return nil
+}
+
+func (decoder *Decoder) get(buffer []byte) (result interface{}, err error) {
 	line, err := decoder.getLine()
 	if err != nil {
 		return
 	}
 
+	result, err = decoder.parse(line, buffer)
+	return
+}
+
+// parse decodes the reply starting with the line.
+func (decoder *Decoder) parse(line, buffer []byte) (result interface{}, err error) {
 	if len(line) == 0 {
-		err = errors.New("redis returned nothing")
+		err = decoder.invalid("empty line", errors.New("redis returned nothing"))
 		return
 	}
 
@@ -73,49 +232,98 @@ func (decoder *Decoder) get() (result interface{}, err error) {
 			return
 		}
 
-		result = line[1:]
+		result = string(line[1:])
 	case '-':
-		result, err = line[1:], fmt.Errorf("redis returned an error: %s", line[1:])
+		text := string(line[1:])
+		result, err = text, ReplyError(text)
 	case ':':
-		result, err = strconv.ParseInt(line[1:], 10, 64)
+		if !decoder.Strict {
+			result, err = parseInteger(line[1:])
+		} else if result, err = strconv.ParseInt(string(line[1:]), 10, 64); err != nil {
+			err = &ProtocolError{fmt.Sprintf("invalid integer '%s'", line[1:])}
+		}
 	case '$':
 		var n int64
-		n, err = strconv.ParseInt(line[1:], 10, 64)
+		n, err = decoder.length(line[1:], DefaultMaximumBulkLength)
 		if n < 0 || err != nil {
 			return
 		}
 
-		reply := make([]byte, n)
+		// check before allocating anything
+		if err = decoder.consume(n); err != nil {
+			return
+		}
+
+		// use the buffer of the caller when it is large enough
+		var reply []byte
+		if int64(cap(buffer)) >= n {
+			reply = buffer[:n]
+		} else {
+			reply = make([]byte, n)
+		}
 
 		_, err = io.ReadFull(decoder.reader, reply)
 		if err != nil {
 			return
 		}
 
-		_, err = decoder.getLine()
+		var end []byte
+		end, err = decoder.getLine()
 		if err != nil {
 			return
 		}
 
+		// the data must be followed by its terminator right away
+		if len(end) != 0 && decoder.Strict {
+			err = &ProtocolError{fmt.Sprintf("bulk string longer than %d bytes", n)}
+			return
+		}
+
 		result = reply
 	case '*':
 		var n int64
-		n, err = strconv.ParseInt(line[1:], 10, 64)
+		n, err = decoder.length(line[1:], DefaultMaximumArrayLength)
 		if n < 0 || err != nil {
 			return
 		}
 
+		if decoder.depth++; decoder.Strict && decoder.depth > DefaultMaximumNestingDepth {
+			err = &ProtocolError{fmt.Sprintf("arrays nested deeper than %d", DefaultMaximumNestingDepth)}
+			return
+		}
+
+		defer func() {
+			decoder.depth--
+		}()
+
+		// each item takes at least 3 bytes
+		if err = decoder.consume(3 * n); err != nil {
+			return
+		}
+
+		decoder.size -= 3 * n
+
+		// read every item to stay in sync but report the first error
 		reply := make([]interface{}, n)
 		for i := range reply {
-			reply[i], err = decoder.get()
-			if err != nil {
-				return
+			var e error
+			if reply[i], e = decoder.get(nil); e != nil {
+				if _, ok := e.(ReplyError); !ok {
+					// stop on protocol or network errors
+					err = e
+					return
+				}
+
+				if err == nil {
+					err = e
+				}
 			}
 		}
 
 		result = reply
 	default:
-		result, err = line, fmt.Errorf("redis returned '%s'", line)
+		text := string(line)
+		result, err = text, decoder.invalid(fmt.Sprintf("invalid type '%q'", line[0]), fmt.Errorf("redis returned '%s'", text))
 	}
 
 	return
@@ -123,13 +331,31 @@ func (decoder *Decoder) get() (result interface{}, err error) {
 
 // Decode unmarshal the reply of the Redis instance for a command that was sent.
 func (decoder *Decoder) Decode() (result interface{}, err error) {
-	result, err = decoder.get()
+	decoder.size, decoder.depth = 0, 0
+	result, err = decoder.get(nil)
+	return
+}
+
+// DecodeBuffer is like Decode but a bulk string reply is stored in the buffer when it is large enough.
+// This avoids allocating a new slice for each reply when reading many values of similar sizes.
+func (decoder *Decoder) DecodeBuffer(buffer []byte) (result interface{}, err error) {
+	decoder.size, decoder.depth = 0, 0
+	result, err = decoder.get(buffer)
 	return
 }
 
 // Unmarshal decodes the reply from the buffer.
 func Unmarshal(data []byte) (result interface{}, err error) {
-	buffer := bytes.NewBuffer(data)
-	result, err = NewDecoder(buffer).Decode()
+	reader := readers.Get().(*bufio.Reader)
+	reader.Reset(bytes.NewReader(data))
+
+	decoder := &Decoder{
+		reader: reader,
+	}
+
+	result, err = decoder.Decode()
+
+	reader.Reset(nil)
+	readers.Put(reader)
 	return
 }
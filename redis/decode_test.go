@@ -0,0 +1,147 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		data   string
+		result interface{}
+		err    bool
+	}{
+		{"+OK\r\n", OK, false},
+		{"+PONG\r\n", "PONG", false},
+		{"+" + long + "\r\n", long, false},
+		{"-ERR wrong\r\n", "ERR wrong", true},
+		{":-42\r\n", int64(-42), false},
+		{":4x\r\n", int64(0), true},
+		{":9223372036854775807\r\n", int64(math.MaxInt64), false},
+		{":-9223372036854775808\r\n", int64(math.MinInt64), false},
+		{":9223372036854775808\r\n", int64(0), true},
+		{":-9223372036854775809\r\n", int64(0), true},
+		{":99999999999999999999\r\n", int64(0), true},
+		{"$3\r\nfoo\r\n", []byte("foo"), false},
+		{"$-1\r\n", nil, false},
+		{"*2\r\n:1\r\n$3\r\nbar\r\n", []interface{}{int64(1), []byte("bar")}, false},
+		{"*2\r\n-ERR wrong\r\n:1\r\n", []interface{}{"ERR wrong", int64(1)}, true},
+		{":1\n", nil, true},
+	}
+
+	for _, test := range tests {
+		result, err := Unmarshal([]byte(test.data))
+		if (err != nil) != test.err {
+			t.Fatal(test.data, err)
+		}
+
+		if test.result != nil && !reflect.DeepEqual(result, test.result) {
+			t.Fatalf("%q: %#v", test.data, result)
+		}
+	}
+}
+
+func TestDecodeBuffer(t *testing.T) {
+	decoder := NewDecoder(bytes.NewBufferString("$3\r\nfoo\r\n$6\r\nfoobar\r\n"))
+
+	buffer := make([]byte, 4)
+
+	result, err := decoder.DecodeBuffer(buffer)
+	if err != nil || string(result.([]byte)) != "foo" || &result.([]byte)[0] != &buffer[0] {
+		t.Fatal(err, result)
+	}
+
+	result, err = decoder.DecodeBuffer(buffer)
+	if err != nil || string(result.([]byte)) != "foobar" || &result.([]byte)[0] == &buffer[0] {
+		t.Fatal(err, result)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := []byte(strings.Repeat(":12345\r\n$5\r\nhello\r\n", 1000))
+	buffer := make([]byte, 5)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		decoder := NewDecoder(bytes.NewReader(data))
+		for j := 0; j < 1000; j++ {
+			decoder.Decode()
+			decoder.DecodeBuffer(buffer)
+		}
+	}
+}
+
+func TestMaximumReplySize(t *testing.T) {
+	tests := []string{
+		"$100\r\n",
+		"*100\r\n",
+		"*2\r\n$3\r\nfoo\r\n$3\r\nbar\r\n",
+		"+" + strings.Repeat("x", 20) + "\r\n",
+	}
+
+	for _, test := range tests {
+		decoder := NewDecoder(bytes.NewBufferString(test))
+		decoder.MaximumReplySize = 16
+
+		if _, err := decoder.Decode(); err == nil {
+			t.Fatalf("%q: expecting an error", test)
+		} else if _, ok := err.(*ReplyTooLargeError); !ok {
+			t.Fatalf("%q: %s", test, err)
+		}
+	}
+
+	decoder := NewDecoder(bytes.NewBufferString("$3\r\nfoo\r\n$3\r\nbar\r\n"))
+	decoder.MaximumReplySize = 16
+
+	for i := 0; i < 2; i++ {
+		if _, err := decoder.Decode(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStrictDecoder(t *testing.T) {
+	invalid := []string{
+		"+OK\n",
+		"$3\r\nfoobar\r\n",
+		"$-2\r\n",
+		"$x\r\n",
+		"*-5\r\n",
+		"*99999999999999999\r\n",
+		":99999999999999999999\r\n",
+		"%1\r\n",
+		"\r\n",
+		strings.Repeat("*1\r\n", 100) + ":1\r\n",
+	}
+
+	for _, text := range invalid {
+		decoder := NewDecoder(strings.NewReader(text))
+		decoder.Strict = true
+
+		if _, err := decoder.Decode(); err == nil {
+			t.Fatalf("expecting an error for %q", text)
+		} else if _, ok := err.(*ProtocolError); !ok {
+			t.Fatalf("expecting a protocol error for %q instead of %v", text, err)
+		}
+	}
+
+	decoder := NewDecoder(strings.NewReader("*2\r\n$3\r\nfoo\r\n*1\r\n:-1\r\n$-1\r\n"))
+	decoder.Strict = true
+
+	if result, err := decoder.Decode(); err != nil || !reflect.DeepEqual(result, []interface{}{[]byte("foo"), []interface{}{int64(-1)}}) {
+		t.Fatal(err, result)
+	}
+
+	if result, err := decoder.Decode(); err != nil || result != nil {
+		t.Fatal(err, result)
+	}
+}
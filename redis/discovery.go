@@ -0,0 +1,134 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultDiscoveryInterval defines the default delay between the resolutions of the addresses by the discovery of a client.
+var DefaultDiscoveryInterval = 30 * time.Second
+
+// Discovery is implemented to find the addresses of the database or of the seed nodes of the cluster.
+// Changes optionally returns a channel signaled when the addresses may have changed.
+// For discoveries returning a nil channel, Resolve is called every DiscoveryInterval instead.
+type Discovery interface {
+	Resolve() (addresses []string, err error)
+	Changes() <-chan struct{}
+}
+
+// DNSDiscovery implements a Discovery resolving a hostname to the addresses of all its IPs with the port e.g. a headless service.
+type DNSDiscovery struct {
+	Host string
+	Port int
+}
+
+// Resolve looks up the IPs of the host.
+func (dns *DNSDiscovery) Resolve() (addresses []string, err error) {
+	hosts, err := net.LookupHost(dns.Host)
+	if err != nil {
+		return
+	}
+
+	port := dns.Port
+	if 0 == port {
+		port = 6379
+	}
+
+	for _, host := range hosts {
+		addresses = append(addresses, "tcp://"+net.JoinHostPort(host, strconv.Itoa(port)))
+	}
+
+	return
+}
+
+// Changes returns nil since DNS records are polled.
+func (dns *DNSDiscovery) Changes() <-chan struct{} {
+	return nil
+}
+
+// resolveAddresses returns the sorted addresses given by the discovery.
+func (client *Client) resolveAddresses() (addresses []string, err error) {
+	if addresses, err = client.Discovery.Resolve(); err == nil && len(addresses) == 0 {
+		err = fmt.Errorf("no address discovered")
+	}
+
+	sort.Strings(addresses)
+	return
+}
+
+// discover follows the changes of the addresses given by the discovery until the client is closed.
+// New addresses are added as seeds of the cluster while, without a cluster, the client connects to the first one.
+func (client *Client) discover() {
+	interval := client.DiscoveryInterval
+	if 0 == interval {
+		interval = DefaultDiscoveryInterval
+	}
+
+	changes := client.Discovery.Changes()
+	for {
+		if changes != nil {
+			select {
+			case <-changes:
+			case <-time.After(interval):
+			}
+		} else {
+			time.Sleep(interval)
+		}
+
+		state := client.state.Load().(*mapping)
+		if state.closed {
+			return
+		}
+
+		addresses, err := client.resolveAddresses()
+		if err != nil {
+			log.Println("discovery error:", err)
+			continue
+		}
+
+		config := client.settings()
+		if same(addresses, config.Address) {
+			continue
+		}
+
+		config = config.config()
+		config.Address = addresses
+
+		if !state.shards {
+			client.Reload(config)
+			continue
+		}
+
+		client.mu.Lock()
+		if !client.state.Load().(*mapping).closed {
+			client.publish(config)
+			for _, address := range addresses {
+				if name := nodeName(address); client.nodes[name] == nil {
+					client.nodes[name] = client.dial(config, address)
+				}
+			}
+		}
+
+		client.mu.Unlock()
+	}
+}
+
+func same(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type staticDiscovery struct {
+	mu        sync.Mutex
+	addresses []string
+	changes   chan struct{}
+}
+
+func (d *staticDiscovery) Resolve() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.addresses, nil
+}
+
+func (d *staticDiscovery) Changes() <-chan struct{} {
+	return d.changes
+}
+
+func TestDiscovery(t *testing.T) {
+	discovery := &staticDiscovery{
+		addresses: []string{"tcp://127.0.0.1:7001", "tcp://127.0.0.1:7000"},
+		changes:   make(chan struct{}),
+	}
+
+	client := &Client{
+		Discovery:         discovery,
+		DiscoveryInterval: time.Hour,
+		SeedDialDelay:     time.Millisecond,
+	}
+
+	defer client.Close()
+
+	if node := client.Route(NewRequest("GET", "a")); node.Address() != "tcp://127.0.0.1:7000" {
+		t.Fatal(node.Address())
+	}
+
+	discovery.mu.Lock()
+	discovery.addresses = []string{"tcp://127.0.0.1:7002"}
+	discovery.mu.Unlock()
+
+	discovery.changes <- struct{}{}
+
+	for i := 0; client.Route(NewRequest("GET", "a")).Address() != "tcp://127.0.0.1:7002"; i++ {
+		if i == 1000 {
+			t.Fatal("expecting the discovered address")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func ExampleDiscovery() {
+	client := &Client{
+		Discovery: &KubernetesDiscovery{
+			Namespace: "default",
+			Service:   "redis",
+			Port:      6379,
+		},
+		DiscoveryInterval: 10 * time.Second,
+	}
+
+	defer client.Close()
+
+	fmt.Println(client.Do("PING"))
+}
@@ -135,6 +135,17 @@ func (encoder *Encoder) Encode(command string, args ...interface{}) (err error)
 	return
 }
 
+// Buffer writes the specified command and arguments without flushing them.
+// This allows many commands to be sent at once with Flush.
+func (encoder *Encoder) Buffer(command string, args ...interface{}) error {
+	return encoder.put(command, args)
+}
+
+// Flush writes any buffered commands.
+func (encoder *Encoder) Flush() error {
+	return encoder.writer.Flush()
+}
+
 // Marshaler is implemented by objects that want to marshal their Redis representation.
 type Marshaler interface {
 	MarshalREDIS() ([]byte, error)
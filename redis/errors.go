@@ -0,0 +1,60 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io"
+	"net"
+	"strings"
+)
+
+// transientReplies lists the prefixes of errors replied by Redis while a node or the cluster is temporarily unavailable.
+var transientReplies = []string{"TRYAGAIN", "LOADING", "CLUSTERDOWN", "MASTERDOWN"}
+
+// IsNetworkError returns true when the error comes from the connection to the node rather than from Redis itself.
+func IsNetworkError(err error) bool {
+	switch err.(type) {
+	case net.Error, *ReplyTooLargeError, *ProtocolError:
+		return true
+	}
+
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// IsTimeout returns true when the error is a network timeout.
+func IsTimeout(err error) bool {
+	e, ok := err.(net.Error)
+	return ok && e.Timeout()
+}
+
+// IsRedirect returns true when Redis replied with MOVED or ASK because another node serves the slot.
+func IsRedirect(err error) bool {
+	e, ok := err.(ReplyError)
+	return ok && (strings.HasPrefix(string(e), "MOVED") || strings.HasPrefix(string(e), "ASK"))
+}
+
+// IsTransient returns true when sending the request again later may succeed.
+// This includes network errors, redirections, errors of the client protecting an unhealthy node and replies like LOADING or TRYAGAIN.
+// Errors like WRONGTYPE are permanent.
+func IsTransient(err error) bool {
+	switch err {
+	case nil:
+		return false
+	case ErrCircuitOpen, ErrOverloaded, ErrOffline:
+		return true
+	}
+
+	if IsNetworkError(err) || IsRedirect(err) {
+		return true
+	}
+
+	if e, ok := err.(ReplyError); ok {
+		for _, prefix := range transientReplies {
+			if strings.HasPrefix(string(e), prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
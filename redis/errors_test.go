@@ -0,0 +1,48 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (e timeoutError) Error() string   { return "timeout" }
+func (e timeoutError) Timeout() bool   { return true }
+func (e timeoutError) Temporary() bool { return true }
+
+func TestErrorClassification(t *testing.T) {
+	var _ net.Error = timeoutError{}
+
+	tests := []struct {
+		err       error
+		network   bool
+		timeout   bool
+		redirect  bool
+		transient bool
+	}{
+		{nil, false, false, false, false},
+		{io.EOF, true, false, false, true},
+		{timeoutError{}, true, true, false, true},
+		{ReplyError("MOVED 3999 127.0.0.1:6381"), false, false, true, true},
+		{ReplyError("ASK 3999 127.0.0.1:6381"), false, false, true, true},
+		{ReplyError("TRYAGAIN Multiple keys request during rehashing of slot"), false, false, false, true},
+		{ReplyError("WRONGTYPE Operation against a key holding the wrong kind of value"), false, false, false, false},
+		{ErrOverloaded, false, false, false, true},
+		{fmt.Errorf("failure"), false, false, false, false},
+	}
+
+	for i, test := range tests {
+		if IsNetworkError(test.err) != test.network || IsTimeout(test.err) != test.timeout {
+			t.Fatal(i, test.err)
+		}
+
+		if IsRedirect(test.err) != test.redirect || IsTransient(test.err) != test.transient {
+			t.Fatal(i, test.err)
+		}
+	}
+}
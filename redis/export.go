@@ -0,0 +1,142 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// DefaultMaximumConcurrentRestores defines the default number of RESTORE in flight during an import.
+var DefaultMaximumConcurrentRestores = 256
+
+// exportVersion identifies the format written by Export.
+const exportVersion = "1"
+
+// Export writes every key of every master node to the writer, along with its remaining time to live.
+// The export is a stream of RESP arrays holding the key, its TTL in milliseconds (0 when it doesn't expire) and the payload of DUMP.
+// Keys modified during the export may be missed or exported twice like with SCAN.
+// It returns the number of keys exported.
+func (client *Client) Export(w io.Writer) (n int, err error) {
+	var mu sync.Mutex
+	encoder := NewEncoder(w)
+
+	if err = encoder.Encode("EXPORT", exportVersion); err != nil {
+		return
+	}
+
+	_, err = each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return nil, node.Scan("", 0, func(keys []string) (err error) {
+			request := new(Request)
+			for _, key := range keys {
+				request.Add("PTTL", key)
+				request.Add("DUMP", key)
+			}
+
+			if err = client.sendNode(node, request); err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for i := 0; i < len(request.commands); i += 2 {
+				ttl, _ := request.commands[i].result.(int64)
+				dump, _ := request.commands[i+1].result.([]byte)
+
+				// the key expired or was deleted since it was scanned
+				if ttl == -2 || dump == nil {
+					continue
+				}
+
+				if ttl < 0 {
+					ttl = 0
+				}
+
+				if err = encoder.Buffer(keys[i/2], ttl, dump); err != nil {
+					return
+				}
+
+				n++
+			}
+
+			return encoder.Flush()
+		})
+	})
+
+	return
+}
+
+// Import restores the keys written by Export, replacing existing keys, and returns the number of keys restored.
+// Keys are sent to the nodes that serve them in the cluster being imported into.
+func (client *Client) Import(r io.Reader) (n int, err error) {
+	decoder := NewDecoder(r)
+
+	header, err := decoder.Decode()
+	if err != nil {
+		return
+	}
+
+	if items, ok := header.([]interface{}); !ok || len(items) != 2 || fmt.Sprintf("%s %s", items...) != "EXPORT "+exportVersion {
+		err = fmt.Errorf("unexpected export header '%v'", header)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	inflight := make(chan struct{}, DefaultMaximumConcurrentRestores)
+
+	var failure error
+	done := func(result interface{}, err error) {
+		mu.Lock()
+		if err != nil && failure == nil {
+			failure = err
+		} else if err == nil {
+			n++
+		}
+
+		mu.Unlock()
+
+		<-inflight
+		wg.Done()
+	}
+
+	for {
+		var record interface{}
+		if record, err = decoder.Decode(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+
+			break
+		}
+
+		items, ok := record.([]interface{})
+		if !ok || len(items) != 3 {
+			err = fmt.Errorf("unexpected export record '%v'", record)
+			break
+		}
+
+		key, _ := items[0].([]byte)
+		text, _ := items[1].([]byte)
+
+		var ttl int64
+		if ttl, err = strconv.ParseInt(string(text), 10, 64); err != nil {
+			break
+		}
+
+		inflight <- struct{}{}
+		wg.Add(1)
+		client.SendAsync(NewRequest("RESTORE", string(key), ttl, items[2], "REPLACE"), done)
+	}
+
+	wg.Wait()
+
+	if err == nil {
+		err = failure
+	}
+
+	return
+}
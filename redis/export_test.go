@@ -0,0 +1,41 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	db := new(mockDB)
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	// b expired between SCAN and DUMP
+	db.result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n")
+	db.result.WriteString("*2\r\n$1\r\n0\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n")
+	db.result.WriteString(":-1\r\n$3\r\nxyz\r\n:-2\r\n$-1\r\n")
+
+	var buffer bytes.Buffer
+	if n, err := client.Export(&buffer); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+
+	if text := buffer.String(); text != "*2\r\n$6\r\nEXPORT\r\n$1\r\n1\r\n*3\r\n$1\r\na\r\n$1\r\n0\r\n$3\r\nxyz\r\n" {
+		t.Fatalf("%q", text)
+	}
+
+	db.result.WriteString("+OK\r\n")
+	if n, err := client.Import(&buffer); err != nil || n != 1 {
+		t.Fatal(n, err)
+	}
+
+	if _, err := client.Import(strings.NewReader("*1\r\n$3\r\nfoo\r\n")); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
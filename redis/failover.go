@@ -0,0 +1,63 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// failover refreshes the mapping of the cluster in the background from another node when a node fails.
+// This picks up the promotion of a replica right away instead of waiting for requests to be redirected.
+func (client *Client) failover(state *mapping, failed *Conn) {
+	if !state.shards || !atomic.CompareAndSwapInt32(&client.refreshing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&client.refreshing, 0)
+
+		// wait out the throttle without the lock so that the failure is always followed by a refresh
+		for wait := client.refresh(state, failed); wait != 0; wait = client.refresh(state, failed) {
+			client.clock().Sleep(wait)
+		}
+	}()
+}
+
+// refresh implements failover and returns how long to wait when the last refresh is too recent.
+func (client *Client) refresh(state *mapping, failed *Conn) (wait time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// skip when closed or when the mapping was already refreshed
+	last := client.state.Load().(*mapping)
+	if last.closed || last.id != state.id {
+		return
+	}
+
+	if wait = client.throttled(); wait != 0 {
+		return
+	}
+
+	// replicas know the mapping too and may have been promoted
+	var nodes []*Conn
+	for _, node := range last.nodes {
+		nodes = append(nodes, node)
+	}
+
+	for _, node := range last.replicas {
+		nodes = append(nodes, node)
+	}
+
+	for _, node := range nodes {
+		if node == failed || node.Quarantined() {
+			continue
+		}
+
+		if _, err := client.reconfigure(last, node); err == nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	failed, good := &Conn{db: new(mockDB)}, &Conn{db: new(mockDB)}
+	defer failed.Close()
+	defer good.Close()
+
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+	state := &mapping{
+		id:     1,
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://failed": failed,
+			"tcp://good":   good,
+		},
+	}
+
+	client.state.Store(state)
+
+	good.db.(*mockDB).result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:7001\r\n")
+	client.failover(state, failed)
+
+	for i := 0; client.state.Load().(*mapping) == state; i++ {
+		if i == 1000 {
+			t.Fatal("mapping should be refreshed")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	next := client.state.Load().(*mapping)
+	if next.nodes["tcp://127.0.0.1:7001"] == nil || next.slots.get(0) != next.nodes["tcp://127.0.0.1:7001"] {
+		t.Fatal("unexpected mapping")
+	}
+}
+
+func TestReconfigureThrottle(t *testing.T) {
+	db := new(mockDB)
+	node := &Conn{db: db}
+	defer node.Close()
+
+	client := &Client{
+		MaximumSlotUpdates: 1,
+	}
+
+	defer client.Close()
+
+	last := client.load()
+
+	db.result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:7001\r\n")
+	client.mu.Lock()
+	first, err := client.reconfigure(last, node)
+	client.mu.Unlock()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing left to read so this would fail if it wasn't throttled
+	client.mu.Lock()
+	second, err := client.reconfigure(last, node)
+	client.mu.Unlock()
+
+	if err != ErrThrottled || first != second {
+		t.Fatal(err, "expecting the state of the last refresh")
+	}
+
+	// a MOVED still updates the slot while the full refresh is throttled
+	other := client.Node("tcp://127.0.0.1:7002")
+	state, err := client.update(5, other)
+	if err != nil || state.slots.get(5) != other || state.nodes["tcp://127.0.0.1:7002"] != other || first.slots.get(5) == other {
+		t.Fatal(err, "expecting the slot to move")
+	}
+}
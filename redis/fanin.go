@@ -0,0 +1,286 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDeduplicationWindow defines how long a message is remembered to drop its copies received from the other masters.
+var DefaultDeduplicationWindow = time.Second
+
+// ClusterSubscriber receives the messages published on the channels of a cluster by subscribing on every master node.
+// Since every node receives each message, it keeps receiving them as long as one master is reachable and the subscriptions follow the masters as the topology changes.
+// The copies of a message received from the other masters within DeduplicationWindow are dropped so that it is delivered once.
+// Identical messages are still delivered as many times as they were published unless a master missed some and a negative window delivers every copy.
+type ClusterSubscriber struct {
+	BufferSize int
+	Overflow   OverflowPolicy
+	Spill      func(Message)
+
+	DeduplicationWindow time.Duration
+
+	client   *Client
+	once     sync.Once
+	mu       sync.Mutex
+	closed   bool
+	channels map[string]bool
+	patterns map[string]bool
+	nodes    map[string]*Subscriber
+	masters  int32
+	listener *topologyListener
+	wg       sync.WaitGroup
+
+	inbox
+
+	// seen counts the copies received of each recent message.
+	seenMu     sync.Mutex
+	seen       map[string]*copies
+	pruned     time.Time
+	duplicates int64
+}
+
+type copies struct {
+	count   int32
+	expires time.Time
+}
+
+// ClusterSubscriber returns a subscriber to the channels of every master of the client.
+func (client *Client) ClusterSubscriber() *ClusterSubscriber {
+	return &ClusterSubscriber{
+		client:   client,
+		channels: make(map[string]bool),
+		patterns: make(map[string]bool),
+		nodes:    make(map[string]*Subscriber),
+		seen:     make(map[string]*copies),
+	}
+}
+
+// Messages returns the channel of the messages received from all masters, which is closed with the subscriber.
+func (cs *ClusterSubscriber) Messages() <-chan Message {
+	cs.once.Do(cs.initialize)
+	return cs.messages
+}
+
+// Subscribe subscribes to the channels on every master.
+func (cs *ClusterSubscriber) Subscribe(channels ...string) error {
+	return cs.change((*Subscriber).Subscribe, cs.channels, true, channels)
+}
+
+// PSubscribe subscribes to the channels matching the glob-style patterns on every master.
+func (cs *ClusterSubscriber) PSubscribe(patterns ...string) error {
+	return cs.change((*Subscriber).PSubscribe, cs.patterns, true, patterns)
+}
+
+// Unsubscribe unsubscribes from the channels or from all of them when none is specified.
+func (cs *ClusterSubscriber) Unsubscribe(channels ...string) error {
+	return cs.change((*Subscriber).Unsubscribe, cs.channels, false, channels)
+}
+
+// PUnsubscribe unsubscribes from the patterns or from all of them when none is specified.
+func (cs *ClusterSubscriber) PUnsubscribe(patterns ...string) error {
+	return cs.change((*Subscriber).PUnsubscribe, cs.patterns, false, patterns)
+}
+
+// Stats returns the statistics of the subscriber with the reconnections of all masters.
+func (cs *ClusterSubscriber) Stats() SubscriberStats {
+	cs.once.Do(cs.initialize)
+
+	stats := cs.inbox.stats()
+	stats.Duplicates = atomic.LoadInt64(&cs.duplicates)
+
+	cs.mu.Lock()
+	for _, s := range cs.nodes {
+		stats.Reconnects += atomic.LoadInt64(&s.reconnects)
+	}
+
+	cs.mu.Unlock()
+	return stats
+}
+
+// Close closes the subscriptions on every master and the channel of messages.
+func (cs *ClusterSubscriber) Close() error {
+	cs.once.Do(cs.initialize)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return nil
+	}
+
+	cs.closed = true
+	close(cs.done)
+
+	cs.client.unlisten(cs.listener)
+
+	for name, s := range cs.nodes {
+		s.Close()
+		delete(cs.nodes, name)
+	}
+
+	go func() {
+		cs.wg.Wait()
+		close(cs.messages)
+	}()
+
+	return nil
+}
+
+func (cs *ClusterSubscriber) initialize() {
+	cs.inbox.open(cs.BufferSize)
+
+	cs.listener = cs.client.listen(func(old, new Topology) {
+		cs.follow(new)
+	})
+
+	cs.follow(cs.client.Topology())
+}
+
+// change updates the subscriptions and applies them on every master.
+func (cs *ClusterSubscriber) change(f func(*Subscriber, ...string) error, names map[string]bool, subscribe bool, args []string) (err error) {
+	cs.once.Do(cs.initialize)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return ErrSubscriberClosed
+	}
+
+	if !subscribe && len(args) == 0 {
+		for name := range names {
+			delete(names, name)
+		}
+	}
+
+	for _, name := range args {
+		if subscribe {
+			names[name] = true
+		} else {
+			delete(names, name)
+		}
+	}
+
+	for _, s := range cs.nodes {
+		if e := f(s, args...); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// follow subscribes on the new masters of the topology and closes the subscriptions on the others.
+func (cs *ClusterSubscriber) follow(topology Topology) {
+	masters := make(map[string]bool)
+	for _, item := range topology.Ranges {
+		masters[item.Master] = true
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return
+	}
+
+	for name, s := range cs.nodes {
+		if !masters[name] {
+			s.Close()
+			delete(cs.nodes, name)
+		}
+	}
+
+	for name := range masters {
+		if cs.nodes[name] != nil {
+			continue
+		}
+
+		// skip the masters that can't be looked up until the next change of topology
+		node := cs.client.Node(name)
+		if node == nil {
+			continue
+		}
+
+		s := node.Subscriber()
+		if channels := members(cs.channels); len(channels) != 0 {
+			s.Subscribe(channels...)
+		}
+
+		if patterns := members(cs.patterns); len(patterns) != 0 {
+			s.PSubscribe(patterns...)
+		}
+
+		cs.nodes[name] = s
+
+		cs.wg.Add(1)
+		go func() {
+			for message := range s.Messages() {
+				if !cs.duplicate(message) {
+					cs.deliver(message, cs.Overflow, cs.Spill)
+				}
+			}
+
+			cs.wg.Done()
+		}()
+	}
+
+	atomic.StoreInt32(&cs.masters, int32(len(cs.nodes)))
+}
+
+// duplicate returns true when the message is a copy of a message already received from another master.
+func (cs *ClusterSubscriber) duplicate(message Message) bool {
+	window := cs.DeduplicationWindow
+	if 0 == window {
+		window = DefaultDeduplicationWindow
+	}
+
+	masters := atomic.LoadInt32(&cs.masters)
+	if window < 0 || masters <= 1 {
+		return false
+	}
+
+	key := message.Pattern + "\x00" + message.Channel + "\x00" + string(message.Payload)
+	now := time.Now()
+
+	cs.seenMu.Lock()
+	defer cs.seenMu.Unlock()
+
+	if now.Sub(cs.pruned) >= window {
+		for k, c := range cs.seen {
+			if now.After(c.expires) {
+				delete(cs.seen, k)
+			}
+		}
+
+		cs.pruned = now
+	}
+
+	if c := cs.seen[key]; c != nil && now.Before(c.expires) && c.count < masters {
+		if c.count++; c.count == masters {
+			delete(cs.seen, key)
+		}
+
+		atomic.AddInt64(&cs.duplicates, 1)
+		return true
+	}
+
+	cs.seen[key] = &copies{
+		count:   1,
+		expires: now.Add(window),
+	}
+
+	return false
+}
+
+// members returns the names of the set.
+func members(names map[string]bool) (result []string) {
+	for name := range names {
+		result = append(result, name)
+	}
+
+	return
+}
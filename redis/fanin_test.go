@@ -0,0 +1,73 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClusterSubscriber(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	a, b := new(mockDB), new(mockDB)
+	a.result.WriteString("*3\r\n$7\r\nmessage\r\n$1\r\nc\r\n$1\r\nx\r\n")
+	b.result.WriteString("*3\r\n$7\r\nmessage\r\n$1\r\nc\r\n$1\r\nx\r\n*3\r\n$7\r\nmessage\r\n$1\r\nc\r\n$1\r\ny\r\n")
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = a
+	client.nodes["tcp://127.0.0.1:6379"].RetryTimeout = time.Hour
+	client.Node("tcp://127.0.0.2:6379").db = b
+	client.Node("tcp://127.0.0.2:6379").RetryTimeout = time.Hour
+
+	cs := client.ClusterSubscriber()
+	if err := cs.Subscribe("c"); err != nil {
+		t.Fatal(err)
+	}
+
+	cs.follow(Topology{
+		Ranges: []SlotRange{
+			{First: 0, Last: 8191, Master: "tcp://127.0.0.1:6379"},
+			{First: 8192, Last: 16383, Master: "tcp://127.0.0.2:6379"},
+		},
+	})
+
+	received := make(map[string]int)
+	for i := 0; i < 2; i++ {
+		m := <-cs.Messages()
+		received[string(m.Payload)]++
+	}
+
+	for cs.Stats().Duplicates != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if received["x"] != 1 || received["y"] != 1 || cs.Stats().Received != 2 {
+		t.Fatal(received, cs.Stats())
+	}
+
+	cs.follow(Topology{
+		Ranges: []SlotRange{
+			{First: 0, Last: 16383, Master: "tcp://127.0.0.2:6379"},
+		},
+	})
+
+	if len(cs.nodes) != 1 || cs.nodes["tcp://127.0.0.2:6379"] == nil {
+		t.Fatal(cs.nodes)
+	}
+
+	cs.Close()
+	if _, ok := <-cs.Messages(); ok {
+		t.Fatal("expecting the messages to be closed")
+	}
+
+	// the closed subscriber no longer follows the topology
+	client.mu.Lock()
+	n := len(client.listeners)
+	client.mu.Unlock()
+
+	if n != 0 {
+		t.Fatal(n)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// FireAndForget sends commands without waiting for their replies, e.g. to increment metrics.
+// Each node gets a dedicated connection where replies are turned off with CLIENT REPLY OFF so nothing has to be read or parsed.
+// Errors replied by Redis, including redirections in a cluster, are lost: only network failures are reported.
+// Flush acts as a barrier that returns once every command sent before it was executed.
+type FireAndForget struct {
+	client *Client
+	node   *Conn
+
+	mu    sync.Mutex
+	conns map[*Conn]*unreplied
+}
+
+// unreplied holds the dedicated connection to a node.
+type unreplied struct {
+	fd        net.Conn
+	encoder   *Encoder
+	decoder   *Decoder
+	unflushed int
+}
+
+// FireAndForget returns a sender of commands whose replies are never read.
+// It must be closed when done.
+func (client *Client) FireAndForget() *FireAndForget {
+	return &FireAndForget{
+		client: client,
+	}
+}
+
+// FireAndForget returns a sender of commands to the node whose replies are never read.
+// It must be closed when done.
+func (conn *Conn) FireAndForget() *FireAndForget {
+	return &FireAndForget{
+		node: conn,
+	}
+}
+
+// Do buffers the specified command (with optional arguments) and writes it once enough are buffered or on Flush.
+func (f *FireAndForget) Do(name string, args ...interface{}) (err error) {
+	node := f.node
+	if f.client != nil {
+		request := newRequest(name, args)
+		state := f.client.load()
+		_, node = f.client.target(state, f.client.keylessPolicy(request), request)
+		release(request)
+	}
+
+	if node == nil {
+		err = errors.New("fire and forget requires a node")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, err := f.conn(node)
+	if err != nil {
+		return
+	}
+
+	if err = c.encoder.Buffer(name, args...); err != nil {
+		f.drop(node)
+		return
+	}
+
+	batch := node.MaximumBatchSize
+	if 0 == batch {
+		batch = DefaultMaximumBatchSize
+	}
+
+	if c.unflushed++; c.unflushed >= batch {
+		c.unflushed = 0
+		if err = c.encoder.Flush(); err != nil {
+			f.drop(node)
+		}
+	}
+
+	return
+}
+
+// Flush writes the buffered commands and waits until Redis has executed them along with those sent before.
+// Failures are reported as NodeErrors.
+func (f *FireAndForget) Flush() (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	errs := make(NodeErrors)
+	for node, c := range f.conns {
+		// the reply to CLIENT REPLY ON comes after every command before it has been executed
+		c.unflushed = 0
+		if err = c.encoder.Encode("CLIENT", "REPLY", "ON"); err == nil {
+			_, err = c.decoder.Decode()
+		}
+
+		if err == nil {
+			err = c.encoder.Buffer("CLIENT", "REPLY", "OFF")
+		}
+
+		if err != nil {
+			errs[node.address] = err
+			f.drop(node)
+		}
+	}
+
+	err = nil
+	if len(errs) != 0 {
+		err = errs
+	}
+
+	return
+}
+
+// Close closes the dedicated connections without writing the buffered commands.
+func (f *FireAndForget) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for node := range f.conns {
+		f.drop(node)
+	}
+}
+
+// conn returns the dedicated connection to the node, connecting when needed.
+func (f *FireAndForget) conn(node *Conn) (c *unreplied, err error) {
+	if c = f.conns[node]; c != nil {
+		return
+	}
+
+	fd, err := node.connect()
+	if err != nil {
+		return
+	}
+
+	c = &unreplied{
+		fd:      fd,
+		encoder: NewEncoder(fd),
+		decoder: NewDecoder(fd),
+	}
+
+	c.decoder.MaximumReplySize = node.MaximumReplySize
+	c.decoder.Strict = node.StrictProtocol
+
+	if err = c.encoder.Buffer("CLIENT", "REPLY", "OFF"); err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	if f.conns == nil {
+		f.conns = make(map[*Conn]*unreplied)
+	}
+
+	f.conns[node] = c
+	return
+}
+
+// drop closes the dedicated connection to the node.
+func (f *FireAndForget) drop(node *Conn) {
+	f.conns[node].fd.Close()
+	delete(f.conns, node)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestFireAndForget(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	conn := &Conn{
+		db: dialerFunc(func() (net.Conn, error) {
+			return client, nil
+		}),
+	}
+
+	commands := make(chan string, 8)
+	go func() {
+		decoder := NewDecoder(server)
+		for {
+			cmd, err := decoder.Decode()
+			if err != nil {
+				close(commands)
+				return
+			}
+
+			text := fmt.Sprintf("%s", cmd)
+			if text == "[CLIENT REPLY ON]" {
+				server.Write([]byte("+OK\r\n"))
+			}
+
+			commands <- text
+		}
+	}()
+
+	f := conn.FireAndForget()
+	for _, key := range []string{"a", "b"} {
+		if err := f.Do("INCR", key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"[CLIENT REPLY OFF]", "[INCR a]", "[INCR b]", "[CLIENT REPLY ON]"} {
+		if text := <-commands; text != expected {
+			t.Fatal(text, expected)
+		}
+	}
+
+	f.Close()
+	if text := <-commands; text != "" {
+		t.Fatal("buffered commands should be dropped:", text)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+//go:build gofuzz
+// +build gofuzz
+
+package redis
+
+import "bytes"
+
+// Fuzz is the entry point of go-fuzz for the strict decoder.
+// It returns 1 when the input was decoded entirely and 0 otherwise.
+func Fuzz(data []byte) int {
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	for {
+		_, err := decoder.Decode()
+		if err == nil {
+			continue
+		}
+
+		if _, ok := err.(ReplyError); ok {
+			continue
+		}
+
+		if _, ok := err.(*ProtocolError); ok {
+			return 0
+		}
+
+		// the end of the input
+		return 1
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSubscriberWorkers defines the default number of goroutines calling the handlers of a subscriber.
+// A single worker calls the handlers in the order of the messages.
+var DefaultSubscriberWorkers = 1
+
+// HandlerStats holds the statistics of a message handler.
+// Panics counts the messages whose handler panicked and Latency the time taken by the handler for each message.
+type HandlerStats struct {
+	Handled int64
+	Panics  int64
+	Latency *Histogram
+}
+
+type handler struct {
+	fn      func(Message)
+	handled int64
+	panics  int64
+	latency Histogram
+}
+
+// Handle subscribes to the channel, or to the channels matching it when it is a glob-style pattern, and calls the function with each message received for it.
+// The functions are called by Workers goroutines reading the messages so the channel of Messages must not be consumed when handlers are used.
+// A function that panics is recovered from and the panic is counted in the statistics of the handler.
+func (s *Subscriber) Handle(pattern string, fn func(Message)) error {
+	s.hmu.Lock()
+	if s.handlers == nil {
+		s.handlers = make(map[string]*handler)
+	}
+
+	s.handlers[pattern] = &handler{fn: fn}
+	s.hmu.Unlock()
+
+	s.dispatch.Do(func() {
+		workers := s.Workers
+		if 0 == workers {
+			workers = DefaultSubscriberWorkers
+		}
+
+		messages := s.Messages()
+		for i := 0; i < workers; i++ {
+			go func() {
+				for message := range messages {
+					s.handle(message)
+				}
+			}()
+		}
+	})
+
+	if strings.ContainsAny(pattern, "*?[") {
+		return s.PSubscribe(pattern)
+	}
+
+	return s.Subscribe(pattern)
+}
+
+// HandlerStats returns the statistics of each handler indexed by pattern.
+func (s *Subscriber) HandlerStats() map[string]HandlerStats {
+	s.hmu.RLock()
+	defer s.hmu.RUnlock()
+
+	result := make(map[string]HandlerStats, len(s.handlers))
+	for pattern, h := range s.handlers {
+		result[pattern] = HandlerStats{
+			Handled: atomic.LoadInt64(&h.handled),
+			Panics:  atomic.LoadInt64(&h.panics),
+			Latency: h.latency.snapshot(),
+		}
+	}
+
+	return result
+}
+
+// handle calls the handler of the subscription that received the message.
+func (s *Subscriber) handle(message Message) {
+	key := message.Pattern
+	if key == "" {
+		key = message.Channel
+	}
+
+	s.hmu.RLock()
+	h := s.handlers[key]
+	s.hmu.RUnlock()
+
+	if h == nil {
+		atomic.AddInt64(&s.unhandled, 1)
+		return
+	}
+
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&h.panics, 1)
+			log.Printf("handler of '%s' panicked: %v", key, r)
+		}
+
+		atomic.AddInt64(&h.handled, 1)
+		h.latency.Observe(time.Since(start))
+	}()
+
+	h.fn(message)
+}
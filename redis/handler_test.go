@@ -0,0 +1,46 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandle(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("*3\r\n$9\r\nsubscribe\r\n$1\r\na\r\n:1\r\n")
+	for i := 0; i < 4; i++ {
+		db.result.WriteString(fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\n%d\r\n", i))
+	}
+
+	db.result.WriteString("*3\r\n$7\r\nmessage\r\n$1\r\nb\r\n$1\r\n4\r\n")
+
+	s := (&Conn{db: db, RetryTimeout: time.Hour}).Subscriber()
+	s.Workers = 2
+	defer s.Close()
+
+	var sum int64
+	err := s.Handle("a", func(m Message) {
+		if string(m.Payload) == "1" {
+			panic("failure")
+		}
+
+		atomic.AddInt64(&sum, int64(m.Payload[0]-'0'))
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for s.HandlerStats()["a"].Handled != 4 || s.Stats().Unhandled != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := s.HandlerStats()["a"]
+	if stats.Panics != 1 || stats.Latency.Count() != 4 || atomic.LoadInt64(&sum) != 5 {
+		t.Fatal(stats, atomic.LoadInt64(&sum))
+	}
+}
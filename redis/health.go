@@ -0,0 +1,250 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaximumNodeFailures defines the default number of consecutive failures after which a node is quarantined.
+var DefaultMaximumNodeFailures = 3
+
+// DefaultProbeInterval defines the default delay between the health probes of a quarantined node.
+var DefaultProbeInterval = time.Second
+
+// DefaultDrainInterval defines the default delay between the checks of the pending requests of a node that left the cluster before closing it.
+var DefaultDrainInterval = 100 * time.Millisecond
+
+// Healthy returns true unless the node is quarantined or failed its last health check.
+func (conn *Conn) Healthy() bool {
+	return !conn.Quarantined() && atomic.LoadInt32(&conn.unhealthy) == 0
+}
+
+// Quarantined returns true when the node failed repeatedly and doesn't receive requests until it answers a probe.
+func (conn *Conn) Quarantined() bool {
+	return atomic.LoadInt32(&conn.quarantined) != 0
+}
+
+// healthy returns the node or, when it is quarantined, another node picked at random among the healthy ones.
+// In a cluster, the replacement node will redirect the request to the right node if it doesn't own the slot.
+func (client *Client) healthy(state *mapping, node *Conn) *Conn {
+	if node == nil || !node.Quarantined() {
+		return node
+	}
+
+	var nodes []*Conn
+	for _, item := range state.nodes {
+		if !item.Quarantined() {
+			nodes = append(nodes, item)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return node
+	}
+
+	return nodes[client.rand().Intn(len(nodes))]
+}
+
+// check quarantines the node once it has failed too many times in a row and starts probing it.
+func (client *Client) check(node *Conn) {
+	max := client.MaximumNodeFailures
+	if 0 == max {
+		max = DefaultMaximumNodeFailures
+	}
+
+	if atomic.LoadInt32(&node.failures) < int32(max) {
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&node.quarantined, 0, 1) {
+		go client.probe(node)
+	}
+}
+
+// probe sends PING to the node on a separate connection until it replies and reintegrates it.
+func (client *Client) probe(node *Conn) {
+	interval := client.ProbeInterval
+	if 0 == interval {
+		interval = DefaultProbeInterval
+	}
+
+	for {
+		client.clock().Sleep(interval)
+
+		if client.state.Load().(*mapping).closed {
+			return
+		}
+
+		if err := ping(node, interval); err == nil {
+			atomic.StoreInt32(&node.failures, 0)
+			atomic.StoreInt32(&node.quarantined, 0)
+			return
+		}
+	}
+}
+
+// ping sends PING to the node on a separate connection so that its requests aren't affected.
+func ping(node *Conn, timeout time.Duration) (err error) {
+	conn := separate(node, timeout)
+	_, err = conn.Do("PING")
+	conn.Close()
+	return
+}
+
+// separate returns a new connection to the node that gives up after the first failure or when the node doesn't reply within the timeout.
+func separate(node *Conn, timeout time.Duration) *Conn {
+	return &Conn{
+		MaximumConnectionRetries: 1,
+		Credentials:              node.Credentials,
+		NoEvict:                  node.NoEvict,
+		NoTouch:                  node.NoTouch,
+		Clock:                    node.Clock,
+		Rand:                     node.Rand,
+		db:                       timeoutDialer{node.db, timeout},
+	}
+}
+
+// timeoutDialer sets read and write timeouts on the connections of its dialer so that a node that stopped replying doesn't block the checks.
+type timeoutDialer struct {
+	dialer
+	timeout time.Duration
+}
+
+func (d timeoutDialer) dial() (c net.Conn, err error) {
+	if c, err = d.dialer.dial(); err == nil {
+		c = &deadlineConn{
+			Conn:  c,
+			read:  d.timeout,
+			write: d.timeout,
+		}
+	}
+
+	return
+}
+
+// monitor checks the health of every known node each HealthCheckInterval until the client is closed.
+func (client *Client) monitor() {
+	for {
+		client.clock().Sleep(client.HealthCheckInterval)
+
+		state := client.state.Load().(*mapping)
+		if state.closed {
+			return
+		}
+
+		nodes := client.prune(state)
+		each(nodes, func(name string, node *Conn) (interface{}, error) {
+			if err := ping(node, client.HealthCheckInterval); err != nil {
+				atomic.StoreInt32(&node.unhealthy, 1)
+				atomic.AddInt32(&node.failures, 1)
+				client.check(node)
+				return nil, err
+			}
+
+			atomic.StoreInt32(&node.unhealthy, 0)
+			return nil, nil
+		})
+	}
+}
+
+// prune forgets the nodes that left the topology and returns the others.
+func (client *Client) prune(state *mapping) (nodes map[string]*Conn) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.nodes == nil {
+		return
+	}
+
+	client.forget(state)
+
+	nodes = make(map[string]*Conn)
+	for _, known := range []map[string]*Conn{client.nodes, client.replicas} {
+		for name, node := range known {
+			nodes[name] = node
+		}
+	}
+
+	return
+}
+
+// forget removes the nodes and replicas that are no longer part of the topology, except the configured addresses, and drains them.
+// It must be called with the lock held.
+func (client *Client) forget(state *mapping) {
+	if !state.shards {
+		return
+	}
+
+	addresses := client.settings().Address
+	seeds := map[string]bool{
+		"tcp://127.0.0.1:6379": len(addresses) == 0,
+	}
+
+	for _, address := range addresses {
+		seeds[nodeName(address)] = true
+	}
+
+	for _, known := range []struct {
+		nodes, current map[string]*Conn
+	}{
+		{client.nodes, state.nodes},
+		{client.replicas, state.replicas},
+	} {
+		for name, node := range known.nodes {
+			if known.current[name] != node && !seeds[name] {
+				delete(known.nodes, name)
+				client.departed = append(client.departed, node)
+			}
+		}
+	}
+
+	if len(client.departed) != 0 && !client.draining {
+		client.draining = true
+		go client.drain()
+	}
+}
+
+// drain closes the departed nodes once they have no pending requests.
+// Requests may still be sent to them with an older state so they are given at least DrainInterval.
+func (client *Client) drain() {
+	interval := client.DrainInterval
+	if 0 == interval {
+		interval = DefaultDrainInterval
+	}
+
+	for {
+		client.clock().Sleep(interval)
+
+		client.mu.Lock()
+		departed := client.departed[:0]
+		for _, node := range client.departed {
+			if node.Pending() != 0 || node.InFlight() != 0 {
+				departed = append(departed, node)
+				continue
+			}
+
+			node.Close()
+			for _, item := range client.leases[node] {
+				item.Close()
+			}
+
+			delete(client.leases, node)
+		}
+
+		client.departed = departed
+
+		done := len(departed) == 0
+		if done {
+			client.draining = false
+		}
+
+		client.mu.Unlock()
+
+		if done {
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	db := new(mockDB)
+	bad := &Conn{db: db}
+	good := &Conn{db: new(mockDB)}
+
+	client := &Client{
+		MaximumNodeFailures: 2,
+		ProbeInterval:       10 * time.Millisecond,
+	}
+
+	defer client.Close()
+
+	client.load()
+	state := &mapping{
+		nodes: map[string]*Conn{
+			"tcp://bad":  bad,
+			"tcp://good": good,
+		},
+	}
+
+	db.err = fmt.Errorf("failure")
+	for i := 0; i < 2; i++ {
+		if _, err := bad.Do("PING"); err == nil {
+			t.Fatal("expecting an error")
+		}
+	}
+
+	// the probe will get this reply once the node is quarantined
+	db.result.WriteString("+PONG\r\n")
+	db.err = nil
+
+	client.check(bad)
+
+	if !bad.Quarantined() {
+		t.Fatal("node should be quarantined")
+	}
+
+	if node := client.healthy(state, bad); node != good {
+		t.Fatal("expecting the healthy node")
+	}
+
+	for i := 0; bad.Quarantined(); i++ {
+		if i == 1000 {
+			t.Fatal("node should be reintegrated")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	bad.Close()
+	good.Close()
+}
+
+func TestHealthChecks(t *testing.T) {
+	db := new(mockDB)
+	db.err = fmt.Errorf("failure")
+
+	client := &Client{
+		HealthCheckInterval: time.Millisecond,
+		ProbeInterval:       time.Hour,
+	}
+
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	for i := 0; client.Stats().Unhealthy != 1; i++ {
+		if i == 1000 {
+			t.Fatal("node should be unhealthy")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPingTimeout(t *testing.T) {
+	// the node accepts the connection but never reads nor replies
+	var mu sync.Mutex
+	var peers []net.Conn
+	node := &Conn{
+		db: dialerFunc(func() (net.Conn, error) {
+			c, peer := net.Pipe()
+			mu.Lock()
+			peers = append(peers, peer)
+			mu.Unlock()
+			return c, nil
+		}),
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ping(node, 10*time.Millisecond)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expecting a timeout")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expecting the health check to give up")
+	}
+
+	mu.Lock()
+	for _, peer := range peers {
+		peer.Close()
+	}
+
+	mu.Unlock()
+}
+
+func TestForget(t *testing.T) {
+	seed := &Conn{db: new(mockDB)}
+	kept := &Conn{db: new(mockDB)}
+	gone := &Conn{db: new(mockDB)}
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://kept": kept,
+		},
+	}
+
+	state.slots.fill(0, 16383, kept)
+
+	client := &Client{
+		Address:       []string{"tcp://seed"},
+		DrainInterval: time.Millisecond,
+		nodes: map[string]*Conn{
+			"tcp://seed": seed,
+			"tcp://kept": kept,
+			"tcp://gone": gone,
+		},
+		replicas: make(map[string]*Conn),
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+
+	nodes := client.prune(state)
+	if len(nodes) != 2 || nodes["tcp://gone"] != nil || client.nodes["tcp://gone"] != nil {
+		t.Fatal(nodes)
+	}
+
+	// the departed node has no pending requests so it gets closed
+	for i := 0; ; i++ {
+		client.mu.Lock()
+		n := len(client.departed)
+		client.mu.Unlock()
+
+		if n == 0 {
+			break
+		}
+
+		if i == 1000 {
+			t.Fatal("expecting the departed node to be closed")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	client.Close()
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHedgeDelay defines the default delay after which a read-only request is also sent to a replica.
+var DefaultHedgeDelay = 10 * time.Millisecond
+
+// DefaultHedgeSamples defines the number of recent latencies of the masters used to compute the hedging delay.
+var DefaultHedgeSamples = 100
+
+// Hedge configures the hedging of read-only requests in a cluster.
+// When the master hasn't replied after some delay, the request is also sent to one of its replicas and the first successful reply wins.
+// The other reply is still read to keep the connection in sync but is discarded.
+type Hedge struct {
+	// Percentile of the recent latencies of the masters, between 0 and 1, used as hedging delay e.g. 0.95.
+	// When 0, or until enough latencies are known, Delay is used instead.
+	Percentile float64
+
+	// Delay is the minimum hedging delay.
+	Delay time.Duration
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	delay   time.Duration
+}
+
+// wait returns the delay to wait for the master before sending the request to a replica.
+func (hedge *Hedge) wait() time.Duration {
+	hedge.mu.Lock()
+	defer hedge.mu.Unlock()
+
+	delay := hedge.Delay
+	if 0 == delay {
+		delay = DefaultHedgeDelay
+	}
+
+	if hedge.delay > delay {
+		delay = hedge.delay
+	}
+
+	return delay
+}
+
+// observe records the latency of a master and recomputes the percentile once every window of samples.
+func (hedge *Hedge) observe(latency time.Duration) {
+	if hedge.Percentile == 0 {
+		return
+	}
+
+	hedge.mu.Lock()
+	defer hedge.mu.Unlock()
+
+	if len(hedge.samples) < DefaultHedgeSamples {
+		hedge.samples = append(hedge.samples, latency)
+	} else {
+		hedge.samples[hedge.next] = latency
+	}
+
+	if hedge.next = (hedge.next + 1) % DefaultHedgeSamples; hedge.next != 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(hedge.samples))
+	copy(sorted, hedge.samples)
+	sort.Sort(durations(sorted))
+
+	i := int(hedge.Percentile * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+
+	hedge.delay = sorted[i]
+}
+
+type durations []time.Duration
+
+func (s durations) Len() int {
+	return len(s)
+}
+
+func (s durations) Less(i, j int) bool {
+	return s[i] < s[j]
+}
+
+func (s durations) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// hedge sends a copy of the request to the master and, when it is too slow, another copy to the replica.
+// The results of the first successful copy are stored in the request.
+func (client *Client) hedge(request *Request, replica *Conn, send func(*Request) error) (err error) {
+	type reply struct {
+		request *Request
+		err     error
+	}
+
+	replies := make(chan reply, 2)
+
+	primary := request.clone()
+	go func() {
+		clock := client.clock()
+		start := clock.Now()
+		err := send(primary)
+		client.Hedge.observe(clock.Now().Sub(start))
+		replies <- reply{primary, err}
+	}()
+
+	timer := client.clock().After(client.Hedge.wait())
+
+	var first reply
+
+	select {
+	case first = <-replies:
+	case <-timer:
+		secondary := request.clone()
+		go func() {
+			replies <- reply{secondary, client.sendNode(replica, secondary)}
+		}()
+
+		// fall back on the other copy when the first one failed
+		if first = <-replies; first.err != nil {
+			first = <-replies
+		}
+	}
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		cmd.result, cmd.err = first.request.commands[i].result, first.request.commands[i].err
+	}
+
+	request.err = first.err
+	err = first.err
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	db := new(mockDB)
+	replica := &Conn{db: db}
+	defer replica.Close()
+
+	client := &Client{
+		Hedge: &Hedge{
+			Delay: time.Millisecond,
+		},
+	}
+
+	slow := func(request *Request) error {
+		time.Sleep(100 * time.Millisecond)
+		request.commands[0].result = []byte("master")
+		return nil
+	}
+
+	db.result.WriteString("$7\r\nreplica\r\n")
+	request := NewRequest("GET", "foo")
+	if err := client.hedge(request, replica, slow); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := request.Result(0); err != nil || string(result.([]byte)) != "replica" {
+		t.Fatal(err, result)
+	}
+}
+
+func TestHedgeDelay(t *testing.T) {
+	hedge := &Hedge{
+		Percentile: 0.9,
+		Delay:      time.Millisecond,
+	}
+
+	for i := 1; i <= DefaultHedgeSamples; i++ {
+		hedge.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	if delay := hedge.wait(); delay != 91*time.Millisecond {
+		t.Fatal(delay)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// histogramBits is the number of bits of precision kept for each duration.
+// Durations are counted in microseconds in buckets that double in size every 16 buckets like HDR histograms.
+const histogramBits = 4
+
+const histogramBuckets = (64 - histogramBits) << histogramBits
+
+// Histogram counts durations with a relative error bounded by the number of sub-buckets of each power of two.
+type Histogram struct {
+	counts [histogramBuckets]int64
+	count  int64
+	sum    int64
+	max    int64
+}
+
+func histogramIndex(v int64) int {
+	if v < 2<<histogramBits {
+		return int(v)
+	}
+
+	shift := uint(0)
+	for v>>shift >= 2<<histogramBits {
+		shift++
+	}
+
+	return int(shift<<histogramBits) + int(v>>shift)
+}
+
+func histogramValue(index int) int64 {
+	if index < 2<<histogramBits {
+		return int64(index)
+	}
+
+	shift := uint(index>>histogramBits) - 1
+	return int64(index-int(shift<<histogramBits)) << shift
+}
+
+// Observe adds the duration to the histogram.
+func (h *Histogram) Observe(duration time.Duration) {
+	v := int64(duration / time.Microsecond)
+	if v < 0 {
+		v = 0
+	}
+
+	atomic.AddInt64(&h.counts[histogramIndex(v)], 1)
+	atomic.AddInt64(&h.count, 1)
+	atomic.AddInt64(&h.sum, int64(duration))
+
+	for {
+		max := atomic.LoadInt64(&h.max)
+		if int64(duration) <= max || atomic.CompareAndSwapInt64(&h.max, max, int64(duration)) {
+			return
+		}
+	}
+}
+
+// Count returns the number of durations in the histogram.
+func (h *Histogram) Count() int64 {
+	return atomic.LoadInt64(&h.count)
+}
+
+// Mean returns the average duration.
+func (h *Histogram) Mean() time.Duration {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64(&h.sum) / n)
+}
+
+// Max returns the longest duration.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(atomic.LoadInt64(&h.max))
+}
+
+// Percentile returns the duration under which the specified percentage of durations fall e.g. 99 for the p99.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	n := h.Count()
+	if n == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p/100*float64(n))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	total := int64(0)
+	for i := range h.counts {
+		if total += atomic.LoadInt64(&h.counts[i]); total > rank {
+			return time.Duration(histogramValue(i)) * time.Microsecond
+		}
+	}
+
+	return h.Max()
+}
+
+// snapshot returns a copy of the histogram.
+func (h *Histogram) snapshot() (result *Histogram) {
+	result = &Histogram{
+		count: atomic.LoadInt64(&h.count),
+		sum:   atomic.LoadInt64(&h.sum),
+		max:   atomic.LoadInt64(&h.max),
+	}
+
+	for i := range h.counts {
+		result.counts[i] = atomic.LoadInt64(&h.counts[i])
+	}
+
+	return
+}
+
+// observe records the latency of each command of the request and reports the request when it is slow.
+func (client *Client) observe(request *Request, node *Conn, duration time.Duration) {
+	address := ""
+	if node != nil {
+		address = node.address
+	}
+
+	slow := client.SlowCommand != nil && client.SlowCommandThreshold != 0 && duration >= client.SlowCommandThreshold
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		name := strings.ToUpper(cmd.name)
+		client.histogram(name).Observe(duration)
+
+		if !slow {
+			continue
+		}
+
+		key := ""
+		if keys := cmd.keys(); len(keys) != 0 {
+			key = argString(cmd.args[keys[0]])
+		}
+
+		client.SlowCommand(name, key, address, duration, request.annotations)
+	}
+}
+
+// histogram returns the latency histogram of the command.
+func (client *Client) histogram(name string) (result *Histogram) {
+	client.latencyMu.RLock()
+	result = client.latencies[name]
+	client.latencyMu.RUnlock()
+
+	if result != nil {
+		return
+	}
+
+	client.latencyMu.Lock()
+	if result = client.latencies[name]; result == nil {
+		if client.latencies == nil {
+			client.latencies = make(map[string]*Histogram)
+		}
+
+		result = new(Histogram)
+		client.latencies[name] = result
+	}
+
+	client.latencyMu.Unlock()
+	return
+}
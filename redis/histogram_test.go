@@ -0,0 +1,68 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	h := new(Histogram)
+	for i := 1; i <= 100; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	if n := h.Count(); n != 100 {
+		t.Fatal(n)
+	}
+
+	if d := h.Max(); d != 100*time.Millisecond {
+		t.Fatal(d)
+	}
+
+	if d := h.Mean(); d != 50500*time.Microsecond {
+		t.Fatal(d)
+	}
+
+	// buckets are precise to 1/16th
+	for _, p := range []float64{50, 90, 99} {
+		d, expected := h.Percentile(p), time.Duration(p)*time.Millisecond
+		if d > expected || d < expected-expected/16 {
+			t.Fatal(p, d)
+		}
+	}
+}
+
+func TestSlowCommand(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("$1\r\na\r\n")
+
+	var slow []string
+	client := &Client{
+		SlowCommand: func(command, key, node string, duration time.Duration, annotations map[string]string) {
+			slow = append(slow, command, key, node, annotations["caller"])
+		},
+		SlowCommandThreshold: time.Nanosecond,
+	}
+
+	defer client.Close()
+
+	client.load()
+	node := client.nodes["tcp://127.0.0.1:6379"]
+	node.db = db
+
+	request := NewRequest("get", "key")
+	request.Annotate("caller", "test")
+	if err := client.Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(slow) != 4 || slow[0] != "GET" || slow[1] != "key" || slow[2] != "tcp://127.0.0.1:6379" || slow[3] != "test" {
+		t.Fatal(slow)
+	}
+
+	if h := client.Stats().Latencies["GET"]; h == nil || h.Count() != 1 {
+		t.Fatal(h)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Info defines the parsed reply of the INFO command of a Redis instance.
+type Info struct {
+	Memory      MemoryInfo
+	Clients     ClientsInfo
+	Replication ReplicationInfo
+	Keyspace    map[int]KeyspaceInfo
+
+	// Fields holds all raw fields indexed by section and name e.g. Fields["server"]["redis_version"].
+	Fields map[string]map[string]string
+}
+
+// MemoryInfo defines the content of the memory section.
+type MemoryInfo struct {
+	UsedMemory         int64
+	UsedMemoryRSS      int64
+	UsedMemoryPeak     int64
+	UsedMemoryLua      int64
+	MaxMemory          int64
+	MaxMemoryPolicy    string
+	FragmentationRatio float64
+}
+
+// ClientsInfo defines the content of the clients section.
+type ClientsInfo struct {
+	ConnectedClients int64
+	BlockedClients   int64
+}
+
+// ReplicationInfo defines the content of the replication section.
+type ReplicationInfo struct {
+	Role             string
+	MasterHost       string
+	MasterPort       int64
+	MasterLinkStatus string
+	ConnectedSlaves  int64
+	Slaves           []SlaveInfo
+	Offset           int64
+}
+
+// SlaveInfo defines a slave connected to a master.
+type SlaveInfo struct {
+	Address string
+	State   string
+	Offset  int64
+	Lag     int64
+}
+
+// KeyspaceInfo defines the statistics of a database.
+type KeyspaceInfo struct {
+	Keys    int64
+	Expires int64
+	AvgTTL  int64
+}
+
+// Info returns the parsed information of the requested sections (or the default ones) for every master node.
+// Nodes that failed are reported with NodeErrors while the others are still returned.
+func (client *Client) Info(sections ...string) (result map[string]*Info, err error) {
+	results, err := each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return node.Info(sections...)
+	})
+
+	result = make(map[string]*Info)
+	for name, item := range results {
+		result[name] = item.(*Info)
+	}
+
+	return
+}
+
+// Info returns the parsed information of the requested sections (or the default ones).
+func (conn *Conn) Info(sections ...string) (result *Info, err error) {
+	args := make([]interface{}, len(sections))
+	for i := range sections {
+		args[i] = sections[i]
+	}
+
+	reply, err := conn.Do("INFO", args...)
+	if err != nil {
+		return
+	}
+
+	text, ok := reply.([]byte)
+	if !ok {
+		err = fmt.Errorf("unexpected INFO reply '%v'", reply)
+		return
+	}
+
+	result, err = ParseInfo(text)
+	return
+}
+
+// ParseInfo decodes the text returned by the INFO command.
+func ParseInfo(text []byte) (result *Info, err error) {
+	info := &Info{
+		Keyspace: make(map[int]KeyspaceInfo),
+		Fields:   make(map[string]map[string]string),
+	}
+
+	section := ""
+
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' {
+			section = strings.ToLower(strings.TrimSpace(line[1:]))
+			continue
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			err = fmt.Errorf("invalid INFO line '%s'", line)
+			return
+		}
+
+		key, value := line[:i], line[i+1:]
+
+		fields := info.Fields[section]
+		if fields == nil {
+			fields = make(map[string]string)
+			info.Fields[section] = fields
+		}
+
+		fields[key] = value
+		info.set(section, key, value)
+	}
+
+	if err = s.Err(); err != nil {
+		return
+	}
+
+	result = info
+	return
+}
+
+func (info *Info) set(section, key, value string) {
+	switch section {
+	case "memory":
+		m := &info.Memory
+		switch key {
+		case "used_memory":
+			m.UsedMemory = parseInt(value)
+		case "used_memory_rss":
+			m.UsedMemoryRSS = parseInt(value)
+		case "used_memory_peak":
+			m.UsedMemoryPeak = parseInt(value)
+		case "used_memory_lua":
+			m.UsedMemoryLua = parseInt(value)
+		case "maxmemory":
+			m.MaxMemory = parseInt(value)
+		case "maxmemory_policy":
+			m.MaxMemoryPolicy = value
+		case "mem_fragmentation_ratio":
+			m.FragmentationRatio, _ = strconv.ParseFloat(value, 64)
+		}
+	case "clients":
+		c := &info.Clients
+		switch key {
+		case "connected_clients":
+			c.ConnectedClients = parseInt(value)
+		case "blocked_clients":
+			c.BlockedClients = parseInt(value)
+		}
+	case "replication":
+		r := &info.Replication
+		switch key {
+		case "role":
+			r.Role = value
+		case "master_host":
+			r.MasterHost = value
+		case "master_port":
+			r.MasterPort = parseInt(value)
+		case "master_link_status":
+			r.MasterLinkStatus = value
+		case "connected_slaves":
+			r.ConnectedSlaves = parseInt(value)
+		case "master_repl_offset":
+			r.Offset = parseInt(value)
+		case "slave_repl_offset":
+			if r.Offset == 0 {
+				r.Offset = parseInt(value)
+			}
+		default:
+			if !strings.HasPrefix(key, "slave") {
+				break
+			}
+
+			if _, err := strconv.Atoi(key[5:]); err != nil {
+				break
+			}
+
+			// formatted as ip=...,port=...,state=...,offset=...,lag=...
+			fields := parseList(value)
+			r.Slaves = append(r.Slaves, SlaveInfo{
+				Address: fields["ip"] + ":" + fields["port"],
+				State:   fields["state"],
+				Offset:  parseInt(fields["offset"]),
+				Lag:     parseInt(fields["lag"]),
+			})
+		}
+	case "keyspace":
+		if !strings.HasPrefix(key, "db") {
+			break
+		}
+
+		db, err := strconv.Atoi(key[2:])
+		if err != nil {
+			break
+		}
+
+		// formatted as keys=...,expires=...,avg_ttl=...
+		fields := parseList(value)
+		info.Keyspace[db] = KeyspaceInfo{
+			Keys:    parseInt(fields["keys"]),
+			Expires: parseInt(fields["expires"]),
+			AvgTTL:  parseInt(fields["avg_ttl"]),
+		}
+	}
+}
+
+func parseList(text string) (result map[string]string) {
+	result = make(map[string]string)
+	for _, item := range strings.Split(text, ",") {
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			result[item[:i]] = item[i+1:]
+		}
+	}
+
+	return
+}
+
+func parseInt(text string) (result int64) {
+	result, _ = strconv.ParseInt(text, 10, 64)
+	return
+}
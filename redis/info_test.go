@@ -0,0 +1,52 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfo(t *testing.T) {
+	text := "# Server\r\nredis_version:3.0.7\r\n\r\n" +
+		"# Clients\r\nconnected_clients:12\r\nblocked_clients:1\r\n\r\n" +
+		"# Memory\r\nused_memory:1024\r\nmaxmemory_policy:noeviction\r\nmem_fragmentation_ratio:1.25\r\n\r\n" +
+		"# Replication\r\nrole:master\r\nconnected_slaves:1\r\n" +
+		"slave0:ip=10.0.0.2,port=6379,state=online,offset=42,lag=0\r\nmaster_repl_offset:42\r\n\r\n" +
+		"# Keyspace\r\ndb0:keys=3,expires=1,avg_ttl=500\r\ndb2:keys=7,expires=0,avg_ttl=0\r\n"
+
+	info, err := ParseInfo([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Fields["server"]["redis_version"] != "3.0.7" {
+		t.Fatalf("unexpected fields %v", info.Fields)
+	}
+
+	if info.Clients != (ClientsInfo{ConnectedClients: 12, BlockedClients: 1}) {
+		t.Fatalf("unexpected clients %+v", info.Clients)
+	}
+
+	if info.Memory.UsedMemory != 1024 || info.Memory.MaxMemoryPolicy != "noeviction" || info.Memory.FragmentationRatio != 1.25 {
+		t.Fatalf("unexpected memory %+v", info.Memory)
+	}
+
+	slaves := []SlaveInfo{{Address: "10.0.0.2:6379", State: "online", Offset: 42}}
+	if info.Replication.Role != "master" || info.Replication.Offset != 42 || !reflect.DeepEqual(info.Replication.Slaves, slaves) {
+		t.Fatalf("unexpected replication %+v", info.Replication)
+	}
+
+	keyspace := map[int]KeyspaceInfo{
+		0: {Keys: 3, Expires: 1, AvgTTL: 500},
+		2: {Keys: 7},
+	}
+
+	if !reflect.DeepEqual(info.Keyspace, keyspace) {
+		t.Fatalf("unexpected keyspace %+v", info.Keyspace)
+	}
+
+	if _, err := ParseInfo([]byte("garbage\r\n")); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
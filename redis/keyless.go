@@ -0,0 +1,130 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// KeylessPolicy defines where commands without keys are sent.
+type KeylessPolicy int
+
+const (
+	// KeylessDefault sends the command to the node serving slot 0.
+	KeylessDefault KeylessPolicy = iota
+
+	// KeylessRoundRobin sends the command to each master node in turn.
+	KeylessRoundRobin
+
+	// KeylessAdmin sends the command to the node at the admin address of the client.
+	KeylessAdmin
+
+	// KeylessBroadcast sends the command to every master node and aggregates the replies.
+	// Integers are summed, arrays are concatenated and the first reply is kept otherwise.
+	KeylessBroadcast
+)
+
+// keylessCommands lists the commands that don't operate on keys.
+var keylessCommands = commandSet([]string{
+	"ACL", "ASKING", "AUTH", "BGREWRITEAOF", "BGSAVE", "CLIENT", "CLUSTER", "COMMAND", "CONFIG",
+	"DBSIZE", "DISCARD", "ECHO", "EXEC", "FAILOVER", "FLUSHALL", "FLUSHDB", "FUNCTION", "HELLO",
+	"INFO", "KEYS", "LASTSAVE", "LATENCY", "LOLWUT", "MODULE", "MONITOR", "MULTI", "PING",
+	"PSUBSCRIBE", "PUBLISH", "PUBSUB", "PUNSUBSCRIBE", "QUIT", "RANDOMKEY", "READONLY",
+	"READWRITE", "REPLICAOF", "RESET", "ROLE", "SAVE", "SCAN", "SCRIPT", "SELECT", "SHUTDOWN",
+	"SLAVEOF", "SLOWLOG", "SUBSCRIBE", "SWAPDB", "TIME", "UNSUBSCRIBE", "UNWATCH", "WAIT",
+	"WAITAOF",
+})
+
+// keyless returns true when none of the commands of the request operate on keys.
+func (request *Request) keyless() bool {
+	for i := range request.commands {
+		if !keylessCommands[strings.ToUpper(request.commands[i].name)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (client *Client) keylessPolicy(request *Request) KeylessPolicy {
+	if request.node != "" || !request.keyless() {
+		return KeylessDefault
+	}
+
+	if policy, ok := client.KeylessPolicies[strings.ToUpper(request.commands[0].name)]; ok {
+		return policy
+	}
+
+	return client.KeylessPolicy
+}
+
+// keylessNode returns the node selected by the policy or nil for the default routing.
+func (client *Client) keylessNode(policy KeylessPolicy) *Conn {
+	switch policy {
+	case KeylessRoundRobin:
+		state := client.load()
+		if !state.shards {
+			break
+		}
+
+		nodes := state.nodes
+
+		names := make([]string, 0, len(nodes))
+		for name := range nodes {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		i := atomic.AddUint32(&client.turn, 1)
+		return nodes[names[i%uint32(len(names))]]
+	case KeylessAdmin:
+		if client.AdminAddress != "" {
+			return client.lookup(client.AdminAddress)
+		}
+	}
+
+	return nil
+}
+
+// broadcast sends a copy of the request to every master node and aggregates the replies.
+func (client *Client) broadcast(request *Request) (err error) {
+	results, err := each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		item := request.clone()
+		if err := client.sendNode(node, item); err != nil {
+			return nil, err
+		}
+
+		return item.commands[0].result, nil
+	})
+
+	cmd := &request.commands[0]
+	cmd.result, cmd.err = nil, err
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch result := results[name].(type) {
+		case int64:
+			sum, _ := cmd.result.(int64)
+			cmd.result = sum + result
+		case []interface{}:
+			list, _ := cmd.result.([]interface{})
+			cmd.result = append(list, result...)
+		default:
+			if cmd.result == nil {
+				cmd.result = result
+			}
+		}
+	}
+
+	request.err = err
+	return
+}
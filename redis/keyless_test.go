@@ -0,0 +1,49 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeylessBroadcast(t *testing.T) {
+	a, b := new(mockDB), new(mockDB)
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	client := &Client{
+		KeylessPolicies: map[string]KeylessPolicy{
+			"DBSIZE": KeylessBroadcast,
+			"KEYS":   KeylessBroadcast,
+		},
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	a.result.WriteString(":3\r\n")
+	b.result.WriteString(":4\r\n")
+	if result, err := client.Do("DBSIZE"); err != nil || result != int64(7) {
+		t.Fatal(err, result)
+	}
+
+	a.result.WriteString("*1\r\n$1\r\nx\r\n")
+	b.result.WriteString("*1\r\n$1\r\ny\r\n")
+	result, err := client.Do("KEYS", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []interface{}{[]byte("x"), []byte("y")}; !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %v", result)
+	}
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CrossSlotError is returned without sending a request that Redis would refuse because its keys belong to different slots of the cluster.
+// This applies to the keys of a command and to all the keys of a transaction.
+// Keys holds the keys of the failing command or transaction and Slots holds the slot of each key.
+type CrossSlotError struct {
+	Keys  []string
+	Slots []int
+}
+
+func (e *CrossSlotError) Error() string {
+	return fmt.Sprintf("keys %q hash to different slots %v: use a hash tag like {user}.name and {user}.email to keep related keys in the same slot", e.Keys, e.Slots)
+}
+
+// keySpec defines the position of keys in the arguments of a command like COMMAND INFO does.
+// First and last are argument indexes where negative values count from the end and a zero step means no such keys.
+// When not zero, numkeys is the index of the argument giving the number of keys that follow it, -1 standing for the first one.
+type keySpec struct {
+	first   int
+	last    int
+	step    int
+	numkeys int
+}
+
+var keySpecs = map[string]keySpec{}
+
+// keywordKeys lists the commands whose keys follow a keyword.
+var keywordKeys = map[string]string{
+	"MIGRATE":    "KEYS",
+	"XREAD":      "STREAMS",
+	"XREADGROUP": "STREAMS",
+}
+
+// storeCommands lists the commands whose STORE or STOREDIST option is followed by a key.
+var storeCommands = commandSet([]string{"GEORADIUS", "GEORADIUSBYMEMBER", "SORT"})
+
+func init() {
+	specs := []struct {
+		spec  keySpec
+		names []string
+	}{
+		{
+			keySpec{0, 0, 1, 0},
+			[]string{
+				"APPEND", "BITCOUNT", "BITFIELD", "BITFIELD_RO", "BITPOS", "DECR", "DECRBY", "DUMP",
+				"EXPIRE", "EXPIREAT", "EXPIRETIME", "GEOADD", "GEODIST", "GEOHASH", "GEOPOS",
+				"GEORADIUS_RO", "GEORADIUSBYMEMBER_RO", "GEOSEARCH", "GET", "GETBIT", "GETDEL", "GETEX",
+				"GETRANGE", "GETSET", "HDEL", "HEXISTS", "HEXPIRE", "HEXPIREAT", "HEXPIRETIME", "HGET",
+				"HGETALL", "HINCRBY", "HINCRBYFLOAT", "HKEYS", "HLEN", "HMGET", "HMSET", "HPERSIST",
+				"HPEXPIRE", "HPEXPIREAT", "HPEXPIRETIME", "HPTTL", "HRANDFIELD", "HSCAN", "HSET",
+				"HSETNX", "HSTRLEN", "HTTL", "HVALS", "INCR", "INCRBY", "INCRBYFLOAT", "LINDEX",
+				"LINSERT", "LLEN", "LPOP", "LPOS", "LPUSH", "LPUSHX", "LRANGE", "LREM", "LSET", "LTRIM",
+				"MOVE", "PERSIST", "PEXPIRE", "PEXPIREAT", "PEXPIRETIME", "PFADD", "PSETEX", "PTTL",
+				"RESTORE", "RPOP", "RPUSH", "RPUSHX", "SADD", "SCARD", "SET", "SETBIT", "SETEX", "SETNX",
+				"SETRANGE", "SISMEMBER", "SMEMBERS", "SMISMEMBER", "SORT_RO", "SPOP", "SRANDMEMBER",
+				"SREM", "SSCAN", "STRLEN", "SUBSTR", "TTL", "TYPE", "XACK", "XADD", "XAUTOCLAIM",
+				"XCLAIM", "XDEL", "XLEN", "XPENDING", "XRANGE", "XREVRANGE", "XSETID", "XTRIM", "ZADD",
+				"ZCARD", "ZCOUNT", "ZINCRBY", "ZLEXCOUNT", "ZMSCORE", "ZPOPMAX", "ZPOPMIN",
+				"ZRANDMEMBER", "ZRANGE", "ZRANGEBYLEX", "ZRANGEBYSCORE", "ZRANK", "ZREM",
+				"ZREMRANGEBYLEX", "ZREMRANGEBYRANK", "ZREMRANGEBYSCORE", "ZREVRANGE", "ZREVRANGEBYLEX",
+				"ZREVRANGEBYSCORE", "ZREVRANK", "ZSCAN", "ZSCORE",
+			},
+		},
+		{
+			keySpec{0, -1, 1, 0},
+			[]string{
+				"DEL", "EXISTS", "MGET", "PFCOUNT", "PFMERGE", "SDIFF", "SDIFFSTORE", "SINTER",
+				"SINTERSTORE", "SUNION", "SUNIONSTORE", "TOUCH", "UNLINK", "WATCH",
+			},
+		},
+		{
+			keySpec{0, 1, 1, 0},
+			[]string{
+				"BLMOVE", "BRPOPLPUSH", "COPY", "GEOSEARCHSTORE", "LCS", "LMOVE", "RENAME", "RENAMENX",
+				"RPOPLPUSH", "SMOVE", "ZRANGESTORE",
+			},
+		},
+		{
+			keySpec{0, -1, 2, 0},
+			[]string{"MSET", "MSETNX"},
+		},
+		{
+			keySpec{0, -2, 1, 0},
+			[]string{"BLPOP", "BRPOP", "BZPOPMAX", "BZPOPMIN"},
+		},
+		{
+			keySpec{1, -1, 1, 0},
+			[]string{"BITOP"},
+		},
+		{
+			keySpec{1, 1, 1, 0},
+			[]string{"DEBUG", "MEMORY", "OBJECT", "XGROUP", "XINFO"},
+		},
+		{
+			keySpec{0, 0, 0, 1},
+			[]string{"BLMPOP", "BZMPOP", "EVAL", "EVAL_RO", "EVALSHA", "EVALSHA_RO", "FCALL", "FCALL_RO"},
+		},
+		{
+			keySpec{0, 0, 0, -1},
+			[]string{"LMPOP", "SINTERCARD", "ZDIFF", "ZINTER", "ZINTERCARD", "ZMPOP", "ZUNION"},
+		},
+		{
+			keySpec{0, 0, 1, 1},
+			[]string{"ZDIFFSTORE", "ZINTERSTORE", "ZUNIONSTORE"},
+		},
+		{
+			keySpec{0, 0, 1, 0},
+			[]string{"GEORADIUS", "GEORADIUSBYMEMBER", "SORT"},
+		},
+	}
+
+	for _, item := range specs {
+		for _, name := range item.names {
+			keySpecs[name] = item.spec
+		}
+	}
+}
+
+// blockingCommands lists the commands that may wait for data or replicas before replying.
+var blockingCommands = commandSet([]string{
+	"BLMOVE", "BLMPOP", "BLPOP", "BRPOP", "BRPOPLPUSH", "BZMPOP", "BZPOPMAX", "BZPOPMIN", "WAIT", "WAITAOF",
+})
+
+// CommandInfo describes a command with its arguments as known by the client.
+type CommandInfo struct {
+	// Keys holds the indexes of the arguments that are keys.
+	Keys []int
+
+	// ReadOnly is set when the command doesn't modify the database and Blocking when it may wait before replying.
+	ReadOnly bool
+	Blocking bool
+
+	// Known is false when the command isn't in the table, in which case none of its arguments are taken as keys.
+	Known bool
+}
+
+// Describe returns what the built-in table of commands tells about the command with the specified arguments.
+func Describe(name string, args ...interface{}) (info CommandInfo) {
+	cmd := command{
+		name: name,
+		args: args,
+	}
+
+	info.Keys = cmd.keys()
+	info.Known = cmd.known()
+	info.ReadOnly = readCommands[strings.ToUpper(name)]
+	info.Blocking = cmd.blocking()
+	return
+}
+
+// blocking returns true when the command may wait before replying.
+func (cmd *command) blocking() bool {
+	switch name := strings.ToUpper(cmd.name); name {
+	case "XREAD", "XREADGROUP":
+		for _, arg := range cmd.args {
+			if option := strings.ToUpper(argString(arg)); option == "BLOCK" {
+				return true
+			} else if option == "STREAMS" {
+				break
+			}
+		}
+
+		return false
+	default:
+		return blockingCommands[name]
+	}
+}
+
+// known returns true when the command is in the table of commands with or without keys.
+func (cmd *command) known() bool {
+	name := strings.ToUpper(cmd.name)
+	_, ok := keySpecs[name]
+	_, found := keywordKeys[name]
+	return ok || found || keylessCommands[name]
+}
+
+// keys returns the indexes of the arguments that are keys.
+// Commands that aren't known have no keys so that their arguments are never rewritten or checked as keys by mistake.
+func (cmd *command) keys() (result []int) {
+	name := strings.ToUpper(cmd.name)
+	if keylessCommands[name] {
+		return
+	}
+
+	n := len(cmd.args)
+
+	spec, ok := keySpecs[name]
+	keyword, found := keywordKeys[name]
+	if !ok && !found {
+		return
+	}
+
+	if spec.step != 0 {
+		last := spec.last
+		if last < 0 {
+			last += n
+		}
+
+		for i := spec.first; i <= last && i < n; i += spec.step {
+			result = append(result, i)
+		}
+	}
+
+	// e.g. EVAL script numkeys key... or ZUNIONSTORE destination numkeys key... or ZUNION numkeys key...
+	if i := spec.numkeys; i != 0 && i < n {
+		if i < 0 {
+			i = 0
+		}
+
+		k, err := strconv.Atoi(argString(cmd.args[i]))
+		if err != nil {
+			return
+		}
+
+		for j := i + 1; j <= i+k && j < n; j++ {
+			result = append(result, j)
+		}
+	}
+
+	// e.g. GEORADIUS key ... STORE destination or SORT key ... STORE destination
+	if storeCommands[name] {
+		for i := 1; i+1 < n; i++ {
+			if option := strings.ToUpper(argString(cmd.args[i])); option == "STORE" || option == "STOREDIST" {
+				result = append(result, i+1)
+			}
+		}
+	}
+
+	// e.g. XREAD ... STREAMS key... id... or MIGRATE host port "" db timeout ... KEYS key...
+	if found {
+		for i := 0; i < n; i++ {
+			if strings.ToUpper(argString(cmd.args[i])) != keyword {
+				continue
+			}
+
+			k := n - i - 1
+			if keyword == "STREAMS" {
+				k /= 2
+			}
+
+			for j := i + 1; j <= i+k; j++ {
+				result = append(result, j)
+			}
+
+			break
+		}
+
+		// MIGRATE of a single key
+		if len(result) == 0 && name == "MIGRATE" && n > 2 && argString(cmd.args[2]) != "" {
+			result = append(result, 2)
+		}
+	}
+
+	return
+}
+
+// crossSlot returns an error when a command or a transaction of the request has keys that belong to different slots.
+func (request *Request) crossSlot() (err error) {
+	var e *CrossSlotError
+	for i := range request.commands {
+		cmd := &request.commands[i]
+
+		// keys of a transaction are gathered until EXEC or DISCARD
+		switch name := strings.ToUpper(cmd.name); {
+		case name == "MULTI":
+			e = new(CrossSlotError)
+			continue
+		case name == "EXEC" || name == "DISCARD":
+			if err = e.check(); err != nil {
+				return
+			}
+
+			e = nil
+			continue
+		}
+
+		keys := cmd.keys()
+
+		// a single key is always fine outside of a transaction
+		multi := e != nil
+		if !multi && len(keys) < 2 {
+			continue
+		}
+
+		if !multi {
+			e = new(CrossSlotError)
+		}
+
+		for _, j := range keys {
+			key := argString(cmd.args[j])
+			e.Keys = append(e.Keys, key)
+			e.Slots = append(e.Slots, Slot(key))
+		}
+
+		if !multi {
+			if err = e.check(); err != nil {
+				return
+			}
+
+			e = nil
+		}
+	}
+
+	return e.check()
+}
+
+// check returns the error when the keys gathered so far are in different slots.
+func (e *CrossSlotError) check() error {
+	if e == nil {
+		return nil
+	}
+
+	for _, slot := range e.Slots {
+		if slot != e.Slots[0] {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func argString(arg interface{}) string {
+	switch arg := arg.(type) {
+	case string:
+		return arg
+	case []byte:
+		return string(arg)
+	case int:
+		return strconv.Itoa(arg)
+	case int64:
+		return strconv.FormatInt(arg, 10)
+	}
+
+	return ""
+}
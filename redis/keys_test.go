@@ -0,0 +1,107 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		args     []interface{}
+		keys     string
+		readonly bool
+		blocking bool
+	}{
+		{[]interface{}{"GET", "a"}, "[0]", true, false},
+		{[]interface{}{"ZADD", "a", 1, "m"}, "[0]", false, false},
+		{[]interface{}{"MEMORY", "USAGE", "a"}, "[1]", false, false},
+		{[]interface{}{"EVALSHA", "sha", 2, "a", "b", "arg"}, "[2 3]", false, false},
+		{[]interface{}{"ZUNION", 2, "a", "b", "WEIGHTS", 1, 2}, "[1 2]", true, false},
+		{[]interface{}{"GEORADIUS", "a", 0, 0, 1, "km", "STORE", "b"}, "[0 6]", false, false},
+		{[]interface{}{"XREAD", "BLOCK", 0, "STREAMS", "a", "b", "0", "0"}, "[3 4]", false, true},
+		{[]interface{}{"XADD", "a", "*", "f", "v"}, "[0]", false, false},
+		{[]interface{}{"MIGRATE", "host", 6379, "", 0, 10, "KEYS", "a", "b"}, "[6 7]", false, false},
+		{[]interface{}{"BLPOP", "a", "b", 0}, "[0 1]", false, true},
+		{[]interface{}{"PING"}, "[]", true, false},
+		{[]interface{}{"WAIT", 1, 0}, "[]", false, true},
+		{[]interface{}{"SELECT", 1}, "[]", false, false},
+		{[]interface{}{"CLUSTER", "KEYSLOT", "a"}, "[]", false, false},
+		{[]interface{}{"MODULE.CMD", "a"}, "[]", false, false},
+	}
+
+	for _, test := range tests {
+		info := Describe(test.args[0].(string), test.args[1:]...)
+		if keys := fmt.Sprint(info.Keys); keys != test.keys || info.ReadOnly != test.readonly || info.Blocking != test.blocking {
+			t.Fatal(test.args, info)
+		}
+	}
+
+	// the slot is computed from the first key wherever it is
+	if n := NewRequest("MEMORY", "USAGE", "foo").slot(); n != Slot("foo") {
+		t.Fatal(n)
+	}
+
+	if info := Describe("MODULE.CMD", "a"); info.Known {
+		t.Fatal(info)
+	}
+
+	// unknown commands are routed by their first argument
+	if n := NewRequest("MODULE.CMD", "foo").slot(); n != Slot("foo") {
+		t.Fatal(n)
+	}
+}
+
+func TestScriptSlot(t *testing.T) {
+	a, b := new(mockDB), new(mockDB)
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	state.slots.fill(0, Slot("foo")-1, state.nodes["tcp://127.0.0.1:7000"])
+	state.slots.fill(Slot("foo"), 16383, state.nodes["tcp://127.0.0.1:7001"])
+
+	client := &Client{
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	// the script runs where its first key is
+	b.result.WriteString(":1\r\n")
+	if result, err := client.Do("EVAL", "return 1", 2, "{foo}a", "{foo}b"); err != nil || result != int64(1) {
+		t.Fatal(result, err)
+	}
+
+	_, err := client.Do("EVALSHA", "sha", 2, "foo", "bar")
+	if e, ok := err.(*CrossSlotError); !ok || len(e.Keys) != 2 || e.Slots[0] != Slot("foo") || e.Slots[1] != Slot("bar") {
+		t.Fatal(err)
+	}
+
+	// every key of a transaction must be in the same slot
+	request := NewRequest("MULTI")
+	request.Add("SET", "{foo}a", 1)
+	request.Add("SET", "bar", 2)
+	request.Add("EXEC")
+	if _, ok := client.Send(request).(*CrossSlotError); !ok {
+		t.Fatal(request.err)
+	}
+
+	// commands of a pipeline are checked one by one
+	b.result.WriteString(":1\r\n")
+	if _, err := client.Do("DEL", "{foo}a", "{foo}b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := NewRequest("MSET", "foo", 1, "bar", 2).crossSlot().(*CrossSlotError); !ok {
+		t.Fatal("expecting an error")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// DefaultKubernetesAPI defines the default address of the API of Kubernetes from within the cluster.
+var DefaultKubernetesAPI = "https://kubernetes.default.svc"
+
+// DefaultKubernetesAccount defines the default directory holding the token and the certificate of the service account of the pod.
+var DefaultKubernetesAccount = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesDiscovery implements a Discovery listing the ready pods behind a service with the API of Kubernetes from within the cluster.
+// The service account of the pod must be allowed to get the endpoints of the service, which are polled every DiscoveryInterval.
+type KubernetesDiscovery struct {
+	Namespace string
+	Service   string
+	Port      int
+
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+func (k *KubernetesDiscovery) initialize() {
+	ca, err := ioutil.ReadFile(filepath.Join(DefaultKubernetesAccount, "ca.crt"))
+	if err != nil {
+		k.err = err
+		return
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		k.err = fmt.Errorf("no certificate in %s", filepath.Join(DefaultKubernetesAccount, "ca.crt"))
+		return
+	}
+
+	k.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+}
+
+// Resolve gets the endpoints of the service.
+func (k *KubernetesDiscovery) Resolve() (addresses []string, err error) {
+	if k.once.Do(k.initialize); k.err != nil {
+		err = k.err
+		return
+	}
+
+	// the token is read every time since it is rotated
+	token, err := ioutil.ReadFile(filepath.Join(DefaultKubernetesAccount, "token"))
+	if err != nil {
+		return
+	}
+
+	namespace := k.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/namespaces/%s/endpoints/%s", DefaultKubernetesAPI, namespace, k.Service), nil)
+	if err != nil {
+		return
+	}
+
+	request.Header.Set("Authorization", "Bearer "+string(token))
+
+	response, err := k.client.Do(request)
+	if err != nil {
+		return
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("endpoints of service %s/%s: %s", namespace, k.Service, response.Status)
+		return
+	}
+
+	var endpoints struct {
+		Subsets []struct {
+			Addresses []struct {
+				IP string `json:"ip"`
+			} `json:"addresses"`
+		} `json:"subsets"`
+	}
+
+	if err = json.NewDecoder(response.Body).Decode(&endpoints); err != nil {
+		return
+	}
+
+	port := k.Port
+	if 0 == port {
+		port = 6379
+	}
+
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			addresses = append(addresses, "tcp://"+net.JoinHostPort(address.IP, strconv.Itoa(port)))
+		}
+	}
+
+	return
+}
+
+// Changes returns nil since the endpoints are polled.
+func (k *KubernetesDiscovery) Changes() <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestKubernetesDiscovery(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/db/endpoints/redis" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(`{"subsets":[{"addresses":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}]}]}`))
+	}))
+
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "account")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	ioutil.WriteFile(filepath.Join(dir, "ca.crt"), ca, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "token"), []byte("secret"), 0600)
+
+	api, account := DefaultKubernetesAPI, DefaultKubernetesAccount
+	DefaultKubernetesAPI, DefaultKubernetesAccount = server.URL, dir
+	defer func() {
+		DefaultKubernetesAPI, DefaultKubernetesAccount = api, account
+	}()
+
+	discovery := &KubernetesDiscovery{
+		Namespace: "db",
+		Service:   "redis",
+		Port:      7000,
+	}
+
+	addresses, err := discovery.Resolve()
+	if expected := []string{"tcp://10.0.0.1:7000", "tcp://10.0.0.2:7000"}; err != nil || !reflect.DeepEqual(addresses, expected) {
+		t.Fatal(addresses, err)
+	}
+
+	// errors of the API aren't taken for an empty service
+	status = http.StatusForbidden
+	if addresses, err = discovery.Resolve(); err == nil {
+		t.Fatal(addresses)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyDecay defines the weight of each new sample in the moving average of the latency of a node.
+var DefaultLatencyDecay = 0.2
+
+// DefaultReplicaExploration defines the probability of reading from a random replica instead of the fastest one.
+// This keeps the latency of the other replicas up to date.
+var DefaultReplicaExploration = 0.05
+
+// Latency returns the exponentially weighted moving average of the time it takes the node to reply.
+// It is 0 until the first reply.
+func (conn *Conn) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&conn.latency))
+}
+
+func (conn *Conn) observe(latency time.Duration) {
+	for {
+		last := atomic.LoadInt64(&conn.latency)
+
+		next := int64(latency)
+		if last != 0 {
+			next = int64(DefaultLatencyDecay*float64(latency) + (1-DefaultLatencyDecay)*float64(last))
+		}
+
+		if atomic.CompareAndSwapInt64(&conn.latency, last, next) {
+			return
+		}
+	}
+}
+
+// replica returns the healthy replica of the master node with the lowest latency or nil when there is none.
+// Once in a while, or when the latency of a replica isn't known yet, one is picked at random instead.
+func (client *Client) replica(state *mapping, master *Conn) (node *Conn) {
+	var nodes []*Conn
+	for _, item := range state.followers[master] {
+		if !item.Quarantined() {
+			nodes = append(nodes, item)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return
+	}
+
+	if r := client.rand(); r.Float64() < DefaultReplicaExploration {
+		node = nodes[r.Intn(len(nodes))]
+		return
+	}
+
+	for _, item := range nodes {
+		if item.Latency() == 0 {
+			node = item
+			return
+		}
+
+		if node == nil || item.Latency() < node.Latency() {
+			node = item
+		}
+	}
+
+	return
+}
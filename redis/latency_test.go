@@ -0,0 +1,45 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatency(t *testing.T) {
+	conn := &Conn{}
+	conn.observe(10 * time.Millisecond)
+	conn.observe(20 * time.Millisecond)
+
+	if latency := conn.Latency(); latency != 12*time.Millisecond {
+		t.Fatal(latency)
+	}
+}
+
+func TestFastestReplica(t *testing.T) {
+	master, slow, fast := &Conn{}, &Conn{}, &Conn{}
+	slow.observe(10 * time.Millisecond)
+	fast.observe(time.Millisecond)
+
+	state := &mapping{
+		followers: map[*Conn][]*Conn{
+			master: {slow, fast},
+		},
+	}
+
+	exploration := DefaultReplicaExploration
+	DefaultReplicaExploration = 0
+	defer func() {
+		DefaultReplicaExploration = exploration
+	}()
+
+	client := &Client{}
+	if node := client.replica(state, master); node != fast {
+		t.Fatal("expecting the fastest replica")
+	}
+
+	if node := client.replica(state, fast); node != nil {
+		t.Fatal("unexpected replica")
+	}
+}
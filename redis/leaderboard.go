@@ -0,0 +1,193 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LeaderboardEntry defines a member of a leaderboard with its score and its rank starting at 0.
+type LeaderboardEntry struct {
+	Member string
+	Score  float64
+	Rank   int64
+}
+
+// Leaderboard ranks members by score in a sorted set with the highest scores first unless Ascending is set.
+// With a period, scores go to a new sorted set at the start of each period e.g. for daily or weekly boards.
+// The sorted sets share the hash tag of the name so that they belong to the same slot of a cluster.
+type Leaderboard struct {
+	Ascending bool
+
+	// Retention optionally expires each sorted set once its period has been over for that long.
+	Retention time.Duration
+
+	client *Client
+	name   string
+	period time.Duration
+	at     time.Time
+}
+
+// Leaderboard returns the leaderboard with the name rolled over every period or never when 0.
+func (client *Client) Leaderboard(name string, period time.Duration) *Leaderboard {
+	return &Leaderboard{
+		client: client,
+		name:   name,
+		period: period,
+	}
+}
+
+// At returns the leaderboard of the period holding the time e.g. to read the board of the previous period.
+func (board *Leaderboard) At(t time.Time) *Leaderboard {
+	result := *board
+	result.at = t
+	return &result
+}
+
+// Key returns the key of the sorted set of the current period.
+func (board *Leaderboard) Key() string {
+	if board.period == 0 {
+		return "{" + board.name + "}"
+	}
+
+	return fmt.Sprintf("{%s}:%d", board.name, board.start().Unix())
+}
+
+// Add sets the score of the member.
+func (board *Leaderboard) Add(member string, score float64) (err error) {
+	key := board.Key()
+	err = board.send(key, NewRequest("ZADD", key, score, member))
+	return
+}
+
+// Incr adds the delta to the score of the member and returns its new score.
+func (board *Leaderboard) Incr(member string, delta float64) (score float64, err error) {
+	key := board.Key()
+
+	request := NewRequest("ZINCRBY", key, delta, member)
+	if err = board.send(key, request); err != nil {
+		return
+	}
+
+	result, _ := request.Result(0)
+	text, _ := result.([]byte)
+	score, err = strconv.ParseFloat(string(text), 64)
+	return
+}
+
+// Remove removes the member.
+func (board *Leaderboard) Remove(member string) (err error) {
+	_, err = board.client.Do("ZREM", board.Key(), member)
+	return
+}
+
+// Rank returns the rank and score of the member and false when it has no score.
+func (board *Leaderboard) Rank(member string) (entry LeaderboardEntry, ok bool, err error) {
+	key := board.Key()
+
+	request := NewRequest(board.command("ZRANK"), key, member)
+	request.Add("ZSCORE", key, member)
+	if err = board.client.Send(request); err != nil {
+		return
+	}
+
+	rank, _ := request.Result(0)
+	if entry.Rank, ok = rank.(int64); !ok {
+		return
+	}
+
+	score, _ := request.Result(1)
+	text, _ := score.([]byte)
+
+	entry.Member = member
+	entry.Score, err = strconv.ParseFloat(string(text), 64)
+	return
+}
+
+// Top returns the first n members.
+func (board *Leaderboard) Top(n int) ([]LeaderboardEntry, error) {
+	return board.Range(0, int64(n)-1)
+}
+
+// Around returns the member with up to n members ranked before and after it or nothing when it has no score.
+func (board *Leaderboard) Around(member string, n int) (result []LeaderboardEntry, err error) {
+	entry, ok, err := board.Rank(member)
+	if err != nil || !ok {
+		return
+	}
+
+	start := entry.Rank - int64(n)
+	if start < 0 {
+		start = 0
+	}
+
+	result, err = board.Range(start, entry.Rank+int64(n))
+	return
+}
+
+// Range returns the members ranked from start to stop included.
+func (board *Leaderboard) Range(start, stop int64) (result []LeaderboardEntry, err error) {
+	reply, err := board.client.Do(board.command("ZRANGE"), board.Key(), start, stop, "WITHSCORES")
+	if err != nil {
+		return
+	}
+
+	items, _ := reply.([]interface{})
+	for i := 0; i+1 < len(items); i += 2 {
+		member, _ := items[i].([]byte)
+		score, _ := items[i+1].([]byte)
+
+		entry := LeaderboardEntry{
+			Member: string(member),
+			Rank:   start + int64(i/2),
+		}
+
+		if entry.Score, err = strconv.ParseFloat(string(score), 64); err != nil {
+			return
+		}
+
+		result = append(result, entry)
+	}
+
+	return
+}
+
+// send sends the request and sets the expiry of the sorted set with it when there is a retention.
+func (board *Leaderboard) send(key string, request *Request) (err error) {
+	if board.period != 0 && board.Retention != 0 {
+		end := board.start().Add(board.period + board.Retention)
+		request.Add("PEXPIREAT", key, end.UnixNano()/int64(time.Millisecond))
+	}
+
+	if err = board.client.Send(request); err != nil {
+		return
+	}
+
+	// report a failure of the command or of its expiry
+	for i := 0; i < request.Len() && err == nil; i++ {
+		_, err = request.Result(i)
+	}
+
+	return
+}
+
+// start returns the start of the current period.
+func (board *Leaderboard) start() time.Time {
+	at := board.at
+	if at.IsZero() {
+		at = board.client.clock().Now()
+	}
+
+	return at.Truncate(board.period)
+}
+
+// command returns the name of the command ranking the members in the order of the leaderboard.
+func (board *Leaderboard) command(name string) string {
+	if board.Ascending {
+		return name
+	}
+
+	return name[:1] + "REV" + name[1:]
+}
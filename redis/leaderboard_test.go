@@ -0,0 +1,71 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeaderboard(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	WithClock(&fakeClock{now: time.Unix(90000, 0)})(client)
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	board := client.Leaderboard("scores", 24*time.Hour)
+	board.Retention = time.Hour
+
+	if key := board.Key(); key != "{scores}:86400" {
+		t.Fatal(key)
+	}
+
+	if key := board.At(time.Unix(1000, 0)).Key(); key != "{scores}:0" {
+		t.Fatal(key)
+	}
+
+	db.result.WriteString(":1\r\n:1\r\n$2\r\n15\r\n:1\r\n")
+	if err := board.Add("alice", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if score, err := board.Incr("alice", 5); err != nil || score != 15 {
+		t.Fatal(score, err)
+	}
+
+	// the sorted set expires an hour after the end of the period
+	if expected, sent := "*3\r\n$9\r\nPEXPIREAT\r\n$14\r\n{scores}:86400\r\n$9\r\n176400000\r\n", db.written(); !strings.Contains(sent, expected) {
+		t.Fatalf("expecting %q in %q", expected, sent)
+	}
+
+	db.result.WriteString(":1\r\n-ERR invalid expire time in 'pexpireat' command\r\n")
+	if err := board.Add("alice", 10); err == nil {
+		t.Fatal("expecting the error of the expiry")
+	}
+
+	db.result.WriteString(":1\r\n$2\r\n15\r\n$-1\r\n$-1\r\n")
+	if entry, ok, err := board.Rank("alice"); err != nil || !ok || entry != (LeaderboardEntry{"alice", 15, 1}) {
+		t.Fatal(entry, ok, err)
+	}
+
+	if _, ok, err := board.Rank("nobody"); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	db.result.WriteString("*4\r\n$3\r\nbob\r\n$2\r\n20\r\n$5\r\nalice\r\n$2\r\n15\r\n")
+	expected := []LeaderboardEntry{{"bob", 20, 0}, {"alice", 15, 1}}
+	if entries, err := board.Top(2); err != nil || !reflect.DeepEqual(entries, expected) {
+		t.Fatal(entries, err)
+	}
+
+	db.result.WriteString(":1\r\n$2\r\n15\r\n*4\r\n$3\r\nbob\r\n$2\r\n20\r\n$5\r\nalice\r\n$2\r\n15\r\n")
+	if entries, err := board.Around("alice", 1); err != nil || !reflect.DeepEqual(entries, expected) {
+		t.Fatal(entries, err)
+	}
+}
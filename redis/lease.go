@@ -0,0 +1,157 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+)
+
+// LeasedConn defines a connection dedicated to the caller until released.
+// Unlike the shared connections of the client, commands changing the state of the connection like WATCH, SELECT or CLIENT SETNAME only affect the sequence of the caller.
+// Requests sent with Do and Send go through the middleware of the client.
+type LeasedConn struct {
+	*Conn
+
+	client *Client
+}
+
+// Do executes the command on the connection through the middleware of the client and waits to decode the reply.
+func (conn *LeasedConn) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := NewRequest(name, args...)
+	if err = conn.Send(request); err == nil {
+		result = request.commands[0].result
+	}
+
+	return
+}
+
+// Send sends the request on the connection through the middleware of the client and waits for the reply.
+func (conn *LeasedConn) Send(request *Request) error {
+	return conn.client.sendNode(conn.Conn, request)
+}
+
+// Conn returns a connection dedicated to the caller to the node serving the first slot, which is the database itself when it isn't a cluster.
+func (client *Client) Conn() (conn *LeasedConn, err error) {
+	conn, err = client.leaseSlot(0)
+	return
+}
+
+// ConnForKey returns a connection dedicated to the caller to the node serving the slot of the key.
+func (client *Client) ConnForKey(key string) (conn *LeasedConn, err error) {
+	conn, err = client.leaseSlot(Slot(key))
+	return
+}
+
+func (client *Client) leaseSlot(slot int) (conn *LeasedConn, err error) {
+	state := client.load()
+	if !state.shards {
+		slot = 0
+	}
+
+	node := state.slots.get(slot)
+	if node == nil {
+		err = fmt.Errorf("no node serving slot %d", slot)
+		return
+	}
+
+	conn = &LeasedConn{
+		Conn:   client.lease(node),
+		client: client,
+	}
+
+	return
+}
+
+// Release gives back the connection.
+// It is closed rather than reused since the state left by the caller isn't known.
+func (conn *LeasedConn) Release() {
+	if conn.Conn != nil {
+		conn.Conn.Close()
+		conn.Conn = nil
+	}
+}
+
+// lease returns an idle connection to the node or a new one.
+func (client *Client) lease(node *Conn) (conn *Conn) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if idle := client.leases[node]; len(idle) != 0 {
+		conn = idle[len(idle)-1]
+		client.leases[node] = idle[:len(idle)-1]
+		return
+	}
+
+	lua := make(map[string]string)
+	for key, code := range node.lua {
+		lua[key] = code
+	}
+
+	conn = &Conn{
+		MaximumConcurrentRequests: node.MaximumConcurrentRequests,
+		MaximumPendingRequests:    node.MaximumPendingRequests,
+		MaximumConnectionRetries:  node.MaximumConnectionRetries,
+		RetryTimeout:              node.RetryTimeout,
+		Breaker:                   node.Breaker,
+		FailFast:                  node.FailFast,
+		FailFastTimeout:           node.FailFastTimeout,
+		MaximumOfflineRequests:    node.MaximumOfflineRequests,
+		OfflineTimeout:            node.OfflineTimeout,
+		MaximumBatchSize:          node.MaximumBatchSize,
+		FlushInterval:             node.FlushInterval,
+		KeepWarmInterval:          node.KeepWarmInterval,
+		PriorityWeight:            node.PriorityWeight,
+		MaximumReplySize:          node.MaximumReplySize,
+		StrictProtocol:            node.StrictProtocol,
+		NoEvict:                   node.NoEvict,
+		NoTouch:                   node.NoTouch,
+		Credentials:               node.Credentials,
+		Clock:                     node.Clock,
+		Rand:                      node.Rand,
+		db:                        node.db,
+		address:                   node.address,
+		database:                  node.database,
+		lua:                       lua,
+	}
+
+	return
+}
+
+// release keeps the connection of a transaction for the next one unless it failed.
+func (client *Client) release(node, conn *Conn, err error) {
+	switch err.(type) {
+	case nil, ReplyError, *CrossSlotError, *PolicyError:
+	default:
+		if err != ErrTxAborted {
+			conn.Close()
+			return
+		}
+	}
+
+	// keys watched by a transaction that was given up must not affect the next one
+	if err != nil {
+		if _, e := conn.Do("UNWATCH"); e != nil {
+			conn.Close()
+			return
+		}
+	}
+
+	n := client.MaximumIdleTransactions
+	if 0 == n {
+		n = DefaultMaximumIdleTransactions
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.leases == nil {
+		client.leases = make(map[*Conn][]*Conn)
+	}
+
+	if len(client.leases[node]) >= n || client.nodes == nil {
+		conn.Close()
+		return
+	}
+
+	client.leases[node] = append(client.leases[node], conn)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestLeasedConn(t *testing.T) {
+	db := new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	conn, err := client.ConnForKey("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.result.WriteString("+OK\r\n$3\r\nabc\r\n")
+
+	if _, err = conn.Do("CLIENT", "SETNAME", "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := conn.Do("CLIENT", "GETNAME")
+	if err != nil || string(result.([]byte)) != "abc" {
+		t.Fatal(result, err)
+	}
+
+	if conn.Conn == client.nodes["tcp://127.0.0.1:6379"] {
+		t.Fatal("expecting a dedicated connection")
+	}
+
+	conn.Release()
+	if conn.Conn != nil {
+		t.Fatal("expecting the connection to be released")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sort"
+)
+
+// BigKey defines a key reported by BigKeys.
+type BigKey struct {
+	Key  string
+	Type string
+	Size int64
+}
+
+// MemoryUsage returns the number of bytes used by the key and its value or 0 when the key doesn't exist.
+func (client *Client) MemoryUsage(key string) (size int64, err error) {
+	request := NewRequest("MEMORY", "USAGE", key)
+	request.route(key)
+
+	if err = client.Send(request); err != nil {
+		return
+	}
+
+	result, err := request.Result(0)
+	size, _ = result.(int64)
+	return
+}
+
+// BigKeys walks the keys of every master node and reports, per node, the keys using at least threshold bytes, largest first.
+// Nodes that failed are reported with NodeErrors while the keys of the others are still returned.
+func (client *Client) BigKeys(threshold int64) (result map[string][]BigKey, err error) {
+	results, err := each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return node.BigKeys(threshold)
+	})
+
+	result = make(map[string][]BigKey)
+	for name, item := range results {
+		result[name] = item.([]BigKey)
+	}
+
+	return
+}
+
+// MemoryUsage returns the number of bytes used by the key and its value or 0 when the key doesn't exist.
+func (conn *Conn) MemoryUsage(key string) (size int64, err error) {
+	result, err := conn.Do("MEMORY", "USAGE", key)
+	size, _ = result.(int64)
+	return
+}
+
+// BigKeys walks the keys and reports those using at least threshold bytes, largest first.
+func (conn *Conn) BigKeys(threshold int64) (result []BigKey, err error) {
+	err = conn.Scan("", 0, func(keys []string) (err error) {
+		// pipeline the size of each key of the batch
+		request := NewRequest("MEMORY", "USAGE", keys[0])
+		for _, key := range keys[1:] {
+			request.Add("MEMORY", "USAGE", key)
+		}
+
+		if err = conn.Send(request); err != nil {
+			return
+		}
+
+		var big []BigKey
+		for i, key := range keys {
+			size, _ := request.commands[i].result.(int64)
+			if size >= threshold && size != 0 {
+				big = append(big, BigKey{
+					Key:  key,
+					Size: size,
+				})
+			}
+		}
+
+		if len(big) == 0 {
+			return
+		}
+
+		// and then pipeline the type of the big ones
+		request = NewRequest("TYPE", big[0].Key)
+		for _, item := range big[1:] {
+			request.Add("TYPE", item.Key)
+		}
+
+		if err = conn.Send(request); err != nil {
+			return
+		}
+
+		for i := range big {
+			big[i].Type, _ = request.commands[i].result.(string)
+		}
+
+		result = append(result, big...)
+		return
+	})
+
+	sort.Sort(bigKeysBySize(result))
+	return
+}
+
+type bigKeysBySize []BigKey
+
+func (s bigKeysBySize) Len() int {
+	return len(s)
+}
+
+func (s bigKeysBySize) Less(i, j int) bool {
+	return s[i].Size > s[j].Size
+}
+
+func (s bigKeysBySize) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
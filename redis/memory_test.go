@@ -0,0 +1,33 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBigKeys(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	// SCAN, then MEMORY USAGE of each key and finally TYPE of the big ones
+	db.result.WriteString("*2\r\n$1\r\n0\r\n*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n")
+	db.result.WriteString(":100\r\n:10\r\n:500\r\n")
+	db.result.WriteString("+string\r\n+hash\r\n")
+
+	result, err := conn.BigKeys(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []BigKey{
+		{Key: "c", Type: "hash", Size: 500},
+		{Key: "a", Type: "string", Size: 100},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+// Middleware is implemented to intercept the requests sent by a client to each node.
+// It calls next to send the request further down the chain, which ends with the node itself, or fails it directly.
+// Redirections replied or faked by a middleware are followed by the client like any other.
+type Middleware interface {
+	Send(node *Conn, request *Request, next Sender) error
+}
+
+// MiddlewareFunc adapts a function to implement a Middleware.
+type MiddlewareFunc func(node *Conn, request *Request, next Sender) error
+
+// Send calls the function.
+func (f MiddlewareFunc) Send(node *Conn, request *Request, next Sender) error {
+	return f(node, request, next)
+}
+
+// Address returns the address of the node e.g. tcp://127.0.0.1:6379.
+func (conn *Conn) Address() string {
+	return conn.address
+}
+
+// chain sends the request through the remaining middleware then to the node.
+type chain struct {
+	middleware []Middleware
+	node       *Conn
+}
+
+func (c chain) Send(request *Request) error {
+	if len(c.middleware) == 0 {
+		return c.node.Send(request)
+	}
+
+	return c.middleware[0].Send(c.node, request, chain{c.middleware[1:], c.node})
+}
+
+// sendNode sends the request to the node through the middleware of the client, records the topology used and audits the request.
+func (client *Client) sendNode(node *Conn, request *Request) (err error) {
+	request.routed, _ = client.state.Load().(*mapping)
+
+	clock := client.clock()
+	start := clock.Now()
+
+	if len(client.Middleware) == 0 {
+		err = node.Send(request)
+	} else {
+		err = chain{client.Middleware, node}.Send(request)
+	}
+
+	if client.Audit != nil {
+		end := clock.Now()
+		client.Audit.record(client.rand(), end, request, node.address, end.Sub(start))
+	}
+
+	return
+}
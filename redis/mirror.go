@@ -0,0 +1,242 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrMirrorOverflow is reported when a request can't be mirrored because too many requests are pending.
+var ErrMirrorOverflow = errors.New("too many pending mirrored requests")
+
+// MirrorClient sends requests to a primary client and mirrors writes asynchronously to a secondary client.
+// Replies always come from the primary which makes it suitable for migrating between clusters without downtime.
+type MirrorClient struct {
+	Primary   *Client
+	Secondary *Client
+
+	// Include lists the commands that are mirrored.
+	// When empty, all commands except reads are mirrored.
+	Include []string
+
+	// Exclude lists the commands that are never mirrored.
+	Exclude []string
+
+	// Error is called when a mirrored request fails or is dropped, with ErrClosed once the secondary is closed.
+	Error func(request *Request, err error)
+
+	// Mismatch optionally enables dark reads: requests that only read are also sent to the secondary and the differences are reported.
+	// At most MaximumMismatches are reported per second.
+	Mismatch          func(mismatch Mismatch)
+	MaximumMismatches int
+
+	MaximumConcurrentRequests int
+	MaximumPendingRequests    int
+
+	once    sync.Once
+	include map[string]bool
+	exclude map[string]bool
+	mu      sync.RWMutex
+	closed  bool
+	feed    chan mirroredRequest
+	wg      sync.WaitGroup
+
+	mismatches rateLimit
+}
+
+// mirroredRequest holds a copy of the request sent to the primary with its results when it is compared.
+type mirroredRequest struct {
+	request *Request
+	primary *Request
+}
+
+// Do executes the specified command (with optional arguments) and waits to decode the reply of the primary.
+func (client *MirrorClient) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := newRequest(name, args)
+	if err = client.Send(request); err == nil {
+		result = request.commands[len(request.commands)-1].result
+	}
+
+	release(request)
+	return
+}
+
+// Send sends the request to the primary, waits for the reply and mirrors it to the secondary when it succeeded.
+// It returns ErrClosed once the mirror is closed.
+func (client *MirrorClient) Send(request *Request) (err error) {
+	client.once.Do(client.initialize)
+
+	client.mu.RLock()
+	closed := client.closed
+	client.mu.RUnlock()
+
+	if closed {
+		return ErrClosed
+	}
+
+	if err = client.Primary.Send(request); err != nil {
+		return
+	}
+
+	var item mirroredRequest
+	switch {
+	case client.mirrored(request):
+		item.request = request.clone()
+	case client.Mismatch != nil && request.ReadOnly():
+		item.request, item.primary = request.clone(), copyResults(request)
+	default:
+		return
+	}
+
+	client.mirror(item)
+	return
+}
+
+// mirror queues the request for the secondary unless the mirror was closed meanwhile or too many requests are pending.
+func (client *MirrorClient) mirror(item mirroredRequest) {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	if client.closed {
+		client.fail(item.request, ErrClosed)
+		return
+	}
+
+	select {
+	case client.feed <- item:
+	default:
+		client.fail(item.request, ErrMirrorOverflow)
+	}
+}
+
+// Close waits for pending mirrored requests to complete.
+// The primary and secondary clients are left open.
+func (client *MirrorClient) Close() {
+	if client == nil {
+		return
+	}
+
+	client.once.Do(client.initialize)
+
+	client.mu.Lock()
+	if !client.closed {
+		client.closed = true
+		close(client.feed)
+	}
+
+	client.mu.Unlock()
+	client.wg.Wait()
+}
+
+func (client *MirrorClient) initialize() {
+	client.include = commandSet(client.Include)
+	client.exclude = commandSet(client.Exclude)
+
+	pending := client.MaximumPendingRequests
+	if 0 == pending {
+		pending = DefaultMaximumPendingRequests
+	}
+
+	client.feed = make(chan mirroredRequest, pending)
+
+	requests := client.MaximumConcurrentRequests
+	if 0 == requests {
+		requests = DefaultMaximumConcurrentRequests
+	}
+
+	for i := 0; i < requests; i++ {
+		client.wg.Add(1)
+		go func() {
+			for item := range client.feed {
+				// the secondary may be closed before the mirror
+				if client.Secondary.closed() {
+					if item.primary == nil {
+						client.fail(item.request, ErrClosed)
+					}
+
+					continue
+				}
+
+				err := client.Secondary.Send(item.request)
+				if item.primary != nil {
+					client.compare(item.primary, item.request)
+				} else if err != nil {
+					client.fail(item.request, err)
+				}
+			}
+
+			client.wg.Done()
+		}()
+	}
+}
+
+func (client *MirrorClient) mirrored(request *Request) bool {
+	for i := range request.commands {
+		name := strings.ToUpper(request.commands[i].name)
+		if client.exclude[name] {
+			continue
+		}
+
+		if len(client.include) != 0 {
+			if client.include[name] {
+				return true
+			}
+
+			continue
+		}
+
+		if !readCommands[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (client *MirrorClient) fail(request *Request, err error) {
+	if client.Error != nil {
+		client.Error(request, err)
+	}
+}
+
+func commandSet(names []string) (result map[string]bool) {
+	result = make(map[string]bool)
+	for _, name := range names {
+		result[strings.ToUpper(name)] = true
+	}
+
+	return
+}
+
+// readCommands lists the commands that don't modify the database.
+var readCommands = commandSet([]string{
+	"BITCOUNT", "BITFIELD_RO", "BITPOS", "DBSIZE", "DUMP", "ECHO", "EVAL_RO", "EVALSHA_RO",
+	"EXISTS", "FCALL_RO", "GEODIST", "GEOHASH", "GEOPOS", "GEORADIUS_RO", "GEORADIUSBYMEMBER_RO",
+	"GEOSEARCH", "GET", "GETBIT", "GETRANGE", "HEXISTS", "HGET", "HGETALL", "HKEYS", "HLEN",
+	"HMGET", "HRANDFIELD", "HSCAN", "HSTRLEN", "HVALS", "INFO", "KEYS", "LCS", "LINDEX", "LLEN",
+	"LPOS", "LRANGE", "MGET", "PFCOUNT", "PING", "PTTL", "RANDOMKEY", "SCAN", "SCARD", "SDIFF",
+	"SINTER", "SINTERCARD", "SISMEMBER", "SMEMBERS", "SMISMEMBER", "SORT_RO", "SRANDMEMBER",
+	"SSCAN", "STRLEN", "SUBSTR", "SUNION", "TIME", "TTL", "TYPE", "XLEN", "XPENDING", "XRANGE",
+	"XREVRANGE", "ZCARD", "ZCOUNT", "ZDIFF", "ZINTER", "ZINTERCARD", "ZLEXCOUNT", "ZMSCORE",
+	"ZRANDMEMBER", "ZRANGE", "ZRANGEBYLEX", "ZRANGEBYSCORE", "ZRANK", "ZREVRANGE",
+	"ZREVRANGEBYLEX", "ZREVRANGEBYSCORE", "ZREVRANK", "ZSCAN", "ZSCORE", "ZUNION",
+})
+
+// writeCommands lists the commands that modify the keys of the database.
+var writeCommands = commandSet([]string{
+	"APPEND", "BITFIELD", "BITOP", "BLMOVE", "BLMPOP", "BLPOP", "BRPOP", "BRPOPLPUSH", "BZMPOP",
+	"BZPOPMAX", "BZPOPMIN", "COPY", "DECR", "DECRBY", "DEL", "EVAL", "EVALSHA", "EXPIRE",
+	"EXPIREAT", "FCALL", "FLUSHALL", "FLUSHDB", "GEOADD", "GEORADIUS", "GEORADIUSBYMEMBER",
+	"GEOSEARCHSTORE", "GETDEL", "GETEX", "GETSET", "HDEL", "HEXPIRE", "HEXPIREAT", "HINCRBY",
+	"HINCRBYFLOAT", "HMSET", "HPERSIST", "HPEXPIRE", "HPEXPIREAT", "HSET", "HSETNX", "INCR",
+	"INCRBY", "INCRBYFLOAT", "LINSERT", "LMOVE", "LMPOP", "LPOP", "LPUSH", "LPUSHX", "LREM", "LSET",
+	"LTRIM", "MIGRATE", "MOVE", "MSET", "MSETNX", "PERSIST", "PEXPIRE", "PEXPIREAT", "PFADD",
+	"PFMERGE", "PSETEX", "RENAME", "RENAMENX", "RESTORE", "RPOP", "RPOPLPUSH", "RPUSH", "RPUSHX",
+	"SADD", "SDIFFSTORE", "SET", "SETBIT", "SETEX", "SETNX", "SETRANGE", "SINTERSTORE", "SMOVE",
+	"SORT", "SPOP", "SREM", "SUNIONSTORE", "SWAPDB", "UNLINK", "XACK", "XADD", "XAUTOCLAIM",
+	"XCLAIM", "XDEL", "XGROUP", "XREADGROUP", "XSETID", "XTRIM", "ZADD", "ZDIFFSTORE", "ZINCRBY",
+	"ZINTERSTORE", "ZMPOP", "ZPOPMAX", "ZPOPMIN", "ZRANGESTORE", "ZREM", "ZREMRANGEBYLEX",
+	"ZREMRANGEBYRANK", "ZREMRANGEBYSCORE", "ZUNIONSTORE",
+})
@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "testing"
+
+func TestMirrored(t *testing.T) {
+	test := func(client *MirrorClient, expected bool, names ...string) {
+		request := NewRequest(names[0])
+		for _, name := range names[1:] {
+			request.Add(name)
+		}
+
+		if client.mirrored(request) != expected {
+			t.Fatalf("unexpected result for %v", names)
+		}
+	}
+
+	client := &MirrorClient{
+		Exclude: []string{"del"},
+	}
+
+	client.once.Do(client.initialize)
+	defer client.Close()
+
+	test(client, true, "SET")
+	test(client, false, "GET")
+	test(client, false, "DEL")
+	test(client, true, "GET", "INCR")
+
+	client = &MirrorClient{
+		Include: []string{"INCR"},
+	}
+
+	client.once.Do(client.initialize)
+	defer client.Close()
+
+	test(client, false, "SET")
+	test(client, true, "incr")
+}
+
+func TestMirrorSecondaryClosed(t *testing.T) {
+	primary, secondary := new(Client), new(Client)
+	defer primary.Close()
+
+	db := new(mockDB)
+	primary.load()
+	primary.nodes["tcp://127.0.0.1:6379"].db = db
+
+	secondary.load()
+	secondary.Close()
+
+	failures := make(chan error, 1)
+	client := &MirrorClient{
+		Primary:   primary,
+		Secondary: secondary,
+		Error: func(request *Request, err error) {
+			failures <- err
+		},
+	}
+
+	db.result.WriteString("+OK\r\n")
+	if _, err := client.Do("SET", "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Close()
+
+	if err := <-failures; err != ErrClosed {
+		t.Fatal(err)
+	}
+}
+
+func TestMirrorClosed(t *testing.T) {
+	primary, secondary := new(Client), new(Client)
+	defer primary.Close()
+	defer secondary.Close()
+
+	client := &MirrorClient{
+		Primary:   primary,
+		Secondary: secondary,
+	}
+
+	client.Close()
+	client.Close()
+
+	if _, err := client.Do("SET", "a", "1"); err != ErrClosed {
+		t.Fatal(err)
+	}
+}
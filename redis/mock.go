@@ -5,12 +5,26 @@ package redis
 import (
 	"bytes"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type mockDB struct {
 	err    error
 	result bytes.Buffer
+	writes int32
+
+	mu   sync.Mutex
+	sent bytes.Buffer
+}
+
+// written returns what was written to the connections of the database so far.
+func (db *mockDB) written() string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.sent.String()
 }
 
 func (db *mockDB) dial() (conn net.Conn, err error) {
@@ -32,7 +46,11 @@ func (conn *mockConn) Read(b []byte) (n int, err error) {
 }
 
 func (conn *mockConn) Write(b []byte) (n int, err error) {
-	n = len(b)
+	atomic.AddInt32(&conn.db.writes, 1)
+
+	conn.db.mu.Lock()
+	n, _ = conn.db.sent.Write(b)
+	conn.db.mu.Unlock()
 	return
 }
 
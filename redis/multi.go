@@ -0,0 +1,168 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaximumRegionFailures defines the default number of consecutive failures of the active client of a MultiClient before failing over.
+var DefaultMaximumRegionFailures = 5
+
+// DefaultRegionStickiness defines the default minimum time a MultiClient stays on a secondary client before going back to the preferred one.
+var DefaultRegionStickiness = 30 * time.Second
+
+// MultiClient implements a client over several clients to active-active databases, typically one per region.
+// Requests go to the first client, which is preferred, until it fails MaximumFailures times in a row.
+// The next client then becomes active while the preferred one is probed every ProbeInterval.
+// Once it replies and after at least Stickiness, requests go back to the preferred client.
+// Idempotent requests failing on the active client are sent right away to the next one.
+type MultiClient struct {
+	Clients         []*Client
+	MaximumFailures int
+	Stickiness      time.Duration
+	ProbeInterval   time.Duration
+
+	active   int32
+	failures int32
+
+	mu      sync.Mutex
+	since   time.Time
+	probing bool
+	closed  bool
+}
+
+// Active returns the index of the client currently receiving the requests.
+func (multi *MultiClient) Active() int {
+	return int(atomic.LoadInt32(&multi.active))
+}
+
+// Do executes the specified command (with optional arguments) on the active client and waits to decode the reply.
+func (multi *MultiClient) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := newRequest(name, args)
+	if err = multi.Send(request); err == nil {
+		result = request.commands[len(request.commands)-1].result
+	}
+
+	release(request)
+	return
+}
+
+// Send sends the specified request to the active client and waits for the reply.
+func (multi *MultiClient) Send(request *Request) (err error) {
+	active := multi.Active()
+	if err = multi.Clients[active].Send(request); !failed(err) {
+		atomic.StoreInt32(&multi.failures, 0)
+		return
+	}
+
+	next := multi.fail(active)
+	if next == active || !request.Idempotent() {
+		return
+	}
+
+	// replay the request on a copy since the original holds the results of the failed attempt
+	replay := request.clone()
+	err = multi.Clients[next].Send(replay)
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		cmd.result, cmd.err = replay.commands[i].result, replay.commands[i].err
+	}
+
+	request.err = replay.err
+	return
+}
+
+// Close closes all the clients.
+func (multi *MultiClient) Close() {
+	multi.mu.Lock()
+	multi.closed = true
+	multi.mu.Unlock()
+
+	for _, client := range multi.Clients {
+		client.Close()
+	}
+}
+
+// failed returns true when the error doesn't come from Redis itself.
+func failed(err error) bool {
+	_, replied := err.(ReplyError)
+	return err != nil && !replied
+}
+
+// fail counts the failure of the client and returns the client that is active afterwards.
+func (multi *MultiClient) fail(active int) int {
+	max := multi.MaximumFailures
+	if 0 == max {
+		max = DefaultMaximumRegionFailures
+	}
+
+	if atomic.AddInt32(&multi.failures, 1) < int32(max) || len(multi.Clients) == 1 {
+		return multi.Active()
+	}
+
+	multi.mu.Lock()
+	defer multi.mu.Unlock()
+
+	// another request already failed over
+	if current := multi.Active(); current != active {
+		return current
+	}
+
+	next := (active + 1) % len(multi.Clients)
+	atomic.StoreInt32(&multi.active, int32(next))
+	atomic.StoreInt32(&multi.failures, 0)
+	multi.since = time.Now()
+
+	if next != 0 && !multi.probing && !multi.closed {
+		multi.probing = true
+		go multi.probe()
+	}
+
+	return next
+}
+
+// probe sends PING to the preferred client until it replies and makes it active again.
+func (multi *MultiClient) probe() {
+	interval := multi.ProbeInterval
+	if 0 == interval {
+		interval = DefaultProbeInterval
+	}
+
+	stickiness := multi.Stickiness
+	if 0 == stickiness {
+		stickiness = DefaultRegionStickiness
+	}
+
+	for {
+		time.Sleep(interval)
+
+		multi.mu.Lock()
+		if multi.closed || multi.Active() == 0 {
+			multi.probing = false
+			multi.mu.Unlock()
+			return
+		}
+
+		since := multi.since
+		multi.mu.Unlock()
+
+		if time.Since(since) < stickiness {
+			continue
+		}
+
+		if _, err := multi.Clients[0].Do("PING"); err != nil {
+			continue
+		}
+
+		multi.mu.Lock()
+		atomic.StoreInt32(&multi.active, 0)
+		atomic.StoreInt32(&multi.failures, 0)
+		multi.probing = false
+		multi.mu.Unlock()
+		return
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMultiClient(t *testing.T) {
+	up := new(mockDB)
+	up.result.WriteString("+PONG\r\n")
+
+	// the preferred region can't be reached until it is back up
+	down := int32(1)
+	primary := dialerFunc(func() (net.Conn, error) {
+		if atomic.LoadInt32(&down) != 0 {
+			return nil, fmt.Errorf("region down")
+		}
+
+		return up.dial()
+	})
+
+	secondary := new(mockDB)
+	secondary.result.WriteString("$1\r\n1\r\n+OK\r\n")
+
+	var clients []*Client
+	for _, db := range []dialer{primary, secondary} {
+		client := &Client{
+			MaximumConnectionRetries: 1,
+			MaximumNodeFailures:      100,
+		}
+
+		client.load()
+		client.nodes["tcp://127.0.0.1:6379"].db = db
+		clients = append(clients, client)
+	}
+
+	multi := &MultiClient{
+		Clients:         clients,
+		MaximumFailures: 2,
+		Stickiness:      time.Millisecond,
+		ProbeInterval:   time.Millisecond,
+	}
+
+	defer multi.Close()
+
+	// the first failure isn't enough to fail over
+	if _, err := multi.Do("SET", "a", "1"); err == nil || multi.Active() != 0 {
+		t.Fatal(err, multi.Active())
+	}
+
+	// reads are replayed right away on the next client
+	if result, err := multi.Do("GET", "a"); err != nil || string(result.([]byte)) != "1" || multi.Active() != 1 {
+		t.Fatal(result, err, multi.Active())
+	}
+
+	if _, err := multi.Do("SET", "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&down, 0)
+
+	for i := 0; multi.Active() != 0; i++ {
+		if i == 1000 {
+			t.Fatal("expecting the preferred client to be active again")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
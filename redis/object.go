@@ -0,0 +1,107 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sort"
+	"time"
+)
+
+// KeyType defines the type of the value of a key reported by TYPE.
+type KeyType string
+
+const (
+	TypeNone   KeyType = "none"
+	TypeString KeyType = "string"
+	TypeList   KeyType = "list"
+	TypeSet    KeyType = "set"
+	TypeZSet   KeyType = "zset"
+	TypeHash   KeyType = "hash"
+	TypeStream KeyType = "stream"
+)
+
+// Encoding defines the internal representation of a value reported by OBJECT ENCODING.
+// Small values use compact encodings that are converted once they grow past the limits set in the configuration.
+type Encoding string
+
+const (
+	EncodingRaw        Encoding = "raw"
+	EncodingInt        Encoding = "int"
+	EncodingEmbstr     Encoding = "embstr"
+	EncodingListpack   Encoding = "listpack"
+	EncodingZiplist    Encoding = "ziplist"
+	EncodingQuicklist  Encoding = "quicklist"
+	EncodingLinkedlist Encoding = "linkedlist"
+	EncodingIntset     Encoding = "intset"
+	EncodingHashtable  Encoding = "hashtable"
+	EncodingSkiplist   Encoding = "skiplist"
+	EncodingStream     Encoding = "stream"
+)
+
+// Type returns the type of the value of the key or TypeNone when there is no key.
+func (client *Client) Type(key string) (result KeyType, err error) {
+	reply, err := client.Do("TYPE", key)
+	text, _ := reply.(string)
+	result = KeyType(text)
+	return
+}
+
+// ObjectEncoding returns the internal representation of the value of the key or an empty encoding when there is no key.
+func (client *Client) ObjectEncoding(key string) (result Encoding, err error) {
+	reply, err := client.Do("OBJECT", "ENCODING", key)
+	text, _ := reply.([]byte)
+	result = Encoding(text)
+	return
+}
+
+// ObjectFreq returns the logarithmic access counter of the key and false when there is no key.
+// This requires an LFU maxmemory-policy.
+func (client *Client) ObjectFreq(key string) (freq int64, ok bool, err error) {
+	reply, err := client.Do("OBJECT", "FREQ", key)
+	freq, ok = reply.(int64)
+	return
+}
+
+// ObjectIdleTime returns the time since the key was last accessed with second precision and false when there is no key.
+// This requires an LRU maxmemory-policy.
+func (client *Client) ObjectIdleTime(key string) (idle time.Duration, ok bool, err error) {
+	reply, err := client.Do("OBJECT", "IDLETIME", key)
+	n, ok := reply.(int64)
+	idle = time.Duration(n) * time.Second
+	return
+}
+
+// RandomKey returns a key picked at random on a master picked at random, or on the next ones when it has no keys.
+// It returns false when the whole database is empty.
+func (client *Client) RandomKey() (key string, ok bool, err error) {
+	nodes := client.masters()
+
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	r := client.rand()
+	for i := len(names) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		names[i], names[j] = names[j], names[i]
+	}
+
+	for _, name := range names {
+		if key, ok, err = nodes[name].RandomKey(); ok || err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// RandomKey returns a key picked at random on the node and false when it has no keys.
+func (conn *Conn) RandomKey() (key string, ok bool, err error) {
+	reply, err := conn.Do("RANDOMKEY")
+	text, ok := reply.([]byte)
+	key = string(text)
+	return
+}
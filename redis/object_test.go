@@ -0,0 +1,72 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObject(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+	db.result.WriteString("+hash\r\n$8\r\nlistpack\r\n:5\r\n$-1\r\n:60\r\n")
+
+	if kind, err := client.Type("foo"); err != nil || kind != TypeHash {
+		t.Fatal(kind, err)
+	}
+
+	if encoding, err := client.ObjectEncoding("foo"); err != nil || encoding != EncodingListpack {
+		t.Fatal(encoding, err)
+	}
+
+	if freq, ok, err := client.ObjectFreq("foo"); err != nil || !ok || freq != 5 {
+		t.Fatal(freq, ok, err)
+	}
+
+	if freq, ok, err := client.ObjectFreq("missing"); err != nil || ok || freq != 0 {
+		t.Fatal(freq, ok, err)
+	}
+
+	if idle, ok, err := client.ObjectIdleTime("foo"); err != nil || !ok || idle != time.Minute {
+		t.Fatal(idle, ok, err)
+	}
+}
+
+func TestRandomKey(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	a, b := new(mockDB), new(mockDB)
+	master := client.nodes["tcp://127.0.0.1:6379"]
+	master.db = a
+	other := client.Node("tcp://127.0.0.2:6379")
+	other.db = b
+
+	state := &mapping{
+		id:        1,
+		shards:    true,
+		nodes:     map[string]*Conn{master.address: master, other.address: other},
+		replicas:  make(map[string]*Conn),
+		ids:       make(map[string]string),
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	state.slots.fill(0, 8191, master)
+	state.slots.fill(8192, 16383, other)
+	client.state.Store(state)
+
+	a.result.WriteString("$-1\r\n")
+	b.result.WriteString("$3\r\nfoo\r\n")
+
+	if key, ok, err := client.RandomKey(); err != nil || !ok || key != "foo" {
+		t.Fatal(key, ok, err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultOfflineTimeout defines the default duration a request is held while its node is offline.
+var DefaultOfflineTimeout = time.Second
+
+// ErrOffline is returned when a request held while its node was offline timed out or couldn't be held.
+var ErrOffline = errors.New("node offline")
+
+type offlineRequest struct {
+	request *Request
+	expiry  time.Time
+}
+
+type offlineQueue []offlineRequest
+
+// hold adds the request to the queue or fails it right away when the queue is full.
+func (q offlineQueue) hold(conn *Conn, request *Request) offlineQueue {
+	if len(q) >= conn.MaximumOfflineRequests {
+		request.err = ErrOffline
+		request.finish()
+		return q
+	}
+
+	timeout := conn.OfflineTimeout
+	if 0 == timeout {
+		timeout = DefaultOfflineTimeout
+	}
+
+	// the request isn't held past its deadline
+	expiry := conn.clock().Now().Add(timeout)
+	if deadline := request.deadline; !deadline.IsZero() && deadline.Before(expiry) {
+		expiry = deadline
+	}
+
+	return append(q, offlineRequest{
+		request: request,
+		expiry:  expiry,
+	})
+}
+
+// expire fails the requests held for too long, with ErrDeadlineExceeded when their deadline is over, and keeps the order of the others.
+func (q offlineQueue) expire(now time.Time) offlineQueue {
+	kept := q[:0]
+	for _, item := range q {
+		if now.Before(item.expiry) {
+			kept = append(kept, item)
+			continue
+		}
+
+		item.request.err = ErrOffline
+		if item.expiry.Equal(item.request.deadline) {
+			item.request.err = ErrDeadlineExceeded
+		}
+
+		item.request.finish()
+	}
+
+	return kept
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOffline(t *testing.T) {
+	db := new(mockDB)
+	up := int32(0)
+
+	conn := &Conn{
+		MaximumConnectionRetries: 1,
+		RetryTimeout:             time.Millisecond,
+		MaximumOfflineRequests:   1,
+		OfflineTimeout:           time.Second,
+		db: dialerFunc(func() (net.Conn, error) {
+			if atomic.LoadInt32(&up) == 0 {
+				return nil, fmt.Errorf("no db")
+			}
+
+			return db.dial()
+		}),
+	}
+
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+			t.Error(err, result)
+		}
+
+		close(done)
+	}()
+
+	// the queue is full
+	time.Sleep(10 * time.Millisecond)
+	if _, err := conn.Do("PING"); err != ErrOffline {
+		t.Fatal(err)
+	}
+
+	db.result.WriteString("+PONG\r\n")
+	atomic.StoreInt32(&up, 1)
+	<-done
+}
+
+func TestOfflineTimeout(t *testing.T) {
+	conn := &Conn{
+		MaximumConnectionRetries: 1,
+		RetryTimeout:             time.Millisecond,
+		MaximumOfflineRequests:   1,
+		OfflineTimeout:           5 * time.Millisecond,
+		db:                       new(noDB),
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != ErrOffline {
+		t.Fatal(err)
+	}
+}
+
+func TestOfflineDeadline(t *testing.T) {
+	conn := &Conn{
+		MaximumConnectionRetries: 1,
+		RetryTimeout:             time.Millisecond,
+		MaximumOfflineRequests:   1,
+		OfflineTimeout:           time.Hour,
+		db:                       new(noDB),
+	}
+
+	defer conn.Close()
+
+	// the request is failed once its deadline is over instead of waiting for the offline timeout
+	request := NewRequest("PING")
+	request.SetDeadline(time.Now().Add(5 * time.Millisecond))
+	if err := conn.Send(request); err != ErrDeadlineExceeded {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	client := NewClient(
+		WithAddress("tcp://127.0.0.1:7000", "tcp://127.0.0.1:7001"),
+		WithTimeouts(time.Second, 2*time.Second, 3*time.Second),
+		WithPassword("", "secret"),
+		WithReplicaReads(),
+		WithFlushWindow(time.Millisecond, 16),
+	)
+
+	if !reflect.DeepEqual(client.Address, []string{"tcp://127.0.0.1:7000", "tcp://127.0.0.1:7001"}) {
+		t.Fatal(client.Address)
+	}
+
+	if client.DialTimeout != time.Second || client.ReadTimeout != 2*time.Second || client.WriteTimeout != 3*time.Second {
+		t.Fatal(client.DialTimeout, client.ReadTimeout, client.WriteTimeout)
+	}
+
+	if client.Credentials != (StaticCredentials{Password: "secret"}) || !client.ReplicaReads {
+		t.Fatal(client.Credentials, client.ReplicaReads)
+	}
+
+	// the nodes write their requests in batches
+	if node := client.dial(client.config(), "tcp://127.0.0.1:7000"); node.FlushInterval != time.Millisecond || node.MaximumBatchSize != 16 {
+		t.Fatal(node.FlushInterval, node.MaximumBatchSize)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	env := map[string]string{
+		"REDIS_URL":          "rediss://:secret@127.0.0.1:6380/2, tcp://127.0.0.1:6381",
+		"REDIS_DIAL_TIMEOUT": "200ms",
+	}
+
+	for key, value := range env {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	client, err := NewClientFromEnv(WithMaximumRedirections(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(client.Address, []string{"tcp://:secret@127.0.0.1:6380?db=2", "tcp://127.0.0.1:6381"}) {
+		t.Fatal(client.Address)
+	}
+
+	if client.TLSConfig == nil || client.DialTimeout != 200*time.Millisecond || client.MaximumRedirections != 3 {
+		t.Fatal(client.TLSConfig, client.DialTimeout, client.MaximumRedirections)
+	}
+
+	os.Setenv("REDIS_URL", "http://127.0.0.1")
+	if _, err := NewClientFromEnv(); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOverloaded is returned in fail-fast mode when the request can't be queued because the node has too many pending requests.
+var ErrOverloaded = errors.New("too many pending requests")
+
+// Pending returns the number of requests queued and not yet sent to the node.
+func (conn *Conn) Pending() int {
+	return int(atomic.LoadInt64(&conn.queued))
+}
+
+// InFlight returns the number of requests sent to the node and waiting for a reply.
+func (conn *Conn) InFlight() int {
+	return int(atomic.LoadInt64(&conn.inflight))
+}
+
+// Overloaded returns the number of requests rejected with ErrOverloaded so far.
+func (conn *Conn) Overloaded() int64 {
+	return atomic.LoadInt64(&conn.overloaded)
+}
+
+// enqueue adds the request to the queue of the node and, in fail-fast mode, gives up when the queue stays full.
+// It fails with ErrClosed once the connection is closed.
+func (conn *Conn) enqueue(request *Request) error {
+	conn.closing.RLock()
+	defer conn.closing.RUnlock()
+
+	if conn.closed {
+		return ErrClosed
+	}
+
+	atomic.AddInt64(&conn.queued, 1)
+	feed := conn.queue(request)
+
+	if !conn.FailFast {
+		feed <- request
+		return nil
+	}
+
+	select {
+	case feed <- request:
+		return nil
+	default:
+	}
+
+	if conn.FailFastTimeout != 0 {
+		timer := time.NewTimer(conn.FailFastTimeout)
+		defer timer.Stop()
+
+		select {
+		case feed <- request:
+			return nil
+		case <-timer.C:
+		}
+	}
+
+	conn.dequeued(request)
+	atomic.AddInt64(&conn.overloaded, 1)
+	return ErrOverloaded
+}
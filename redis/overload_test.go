@@ -0,0 +1,70 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFailFast(t *testing.T) {
+	server, client := net.Pipe()
+	dialed := false
+
+	conn := &Conn{
+		MaximumConcurrentRequests: 1,
+		MaximumPendingRequests:    1,
+		RetryTimeout:              time.Millisecond,
+		FailFast:                  true,
+		FailFastTimeout:           time.Millisecond,
+		db: dialerFunc(func() (net.Conn, error) {
+			if dialed {
+				return nil, fmt.Errorf("no db")
+			}
+
+			dialed = true
+			return client, nil
+		}),
+	}
+
+	defer conn.Close()
+	defer server.Close()
+
+	// the other end of the pipe never replies so the worker gets stuck once the maximum number of concurrent requests is reached
+	go io.Copy(ioutil.Discard, server)
+
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			_, err := conn.Do("PING")
+			errs <- err
+		}()
+	}
+
+	for i := 0; conn.Pending() != 1; i++ {
+		if i == 1000 {
+			t.Fatal("expecting a pending request")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := conn.Do("PING"); err != ErrOverloaded {
+		t.Fatal(err)
+	}
+
+	if n := conn.Overloaded(); n != 1 {
+		t.Fatal(n)
+	}
+
+	server.Close()
+	for i := 0; i < 4; i++ {
+		if err := <-errs; err == nil {
+			t.Fatal("expecting an error")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// TopologyStore is implemented to keep the last topology of the cluster across restarts.
+// Load returns an empty topology when none was saved.
+type TopologyStore interface {
+	Load() (Topology, error)
+	Save(topology Topology) error
+}
+
+// TopologyFile implements a TopologyStore saving the topology as JSON in the named file.
+type TopologyFile string
+
+// Load reads the topology from the file.
+func (file TopologyFile) Load() (topology Topology, err error) {
+	data, err := ioutil.ReadFile(string(file))
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, &topology)
+	}
+
+	return
+}
+
+// Save replaces the file atomically with the topology.
+func (file TopologyFile) Save(topology Topology) (err error) {
+	data, err := json.Marshal(topology)
+	if err != nil {
+		return
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(string(file)), filepath.Base(string(file)))
+	if err != nil {
+		return
+	}
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), string(file))
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return
+}
+
+// restore returns the mapping of the cluster saved in the TopologyStore so that requests can be routed before reaching any seed.
+// It returns nil when there is none and must be called while initializing.
+func (client *Client) restore() (state *mapping) {
+	topology, err := client.TopologyStore.Load()
+	if err != nil {
+		log.Println("failed to load the topology:", err)
+		return
+	}
+
+	if !topology.Cluster || len(topology.Ranges) == 0 {
+		return
+	}
+
+	state = &mapping{
+		id:       topology.Epoch,
+		shards:   true,
+		nodes:    make(map[string]*Conn),
+		replicas: make(map[string]*Conn),
+		ids:      make(map[string]string),
+
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	for _, item := range topology.Ranges {
+		if item.First < 0 || item.Last > 16383 || item.First > item.Last {
+			log.Println("ignoring the invalid topology:", item)
+			return nil
+		}
+
+		node := state.nodes[item.Master]
+		if node == nil {
+			if node = client.nodes[item.Master]; node == nil {
+				node = client.connect(item.Master)
+			}
+
+			state.nodes[item.Master] = node
+			for _, name := range item.Replicas {
+				replica := state.replicas[name]
+				if replica == nil {
+					replica = client.connect(name)
+					replica.readonly = true
+					state.replicas[name] = replica
+				}
+
+				state.followers[node] = append(state.followers[node], replica)
+			}
+		}
+
+		state.slots.fill(item.First, item.Last, node)
+	}
+
+	for name, item := range state.nodes {
+		client.nodes[name] = item
+	}
+
+	for name, item := range state.replicas {
+		client.replicas[name] = item
+	}
+
+	return
+}
+
+// persist saves every new topology in the TopologyStore.
+func (client *Client) persist(last, next Topology) {
+	if err := client.TopologyStore.Save(next); err != nil {
+		log.Println("failed to save the topology:", err)
+	}
+}
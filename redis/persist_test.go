@@ -0,0 +1,69 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTopologyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "topology")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := TopologyFile(filepath.Join(dir, "topology.json"))
+	db := new(mockDB)
+
+	client := &Client{
+		TopologyStore: file,
+	}
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("*2\r\n*3\r\n:0\r\n:8191\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n*4\r\n:8192\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:6380\r\n*2\r\n$9\r\n127.0.0.1\r\n:6381\r\n")
+	if _, err := client.migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; ; i++ {
+		if topology, err := file.Load(); err == nil && topology.Cluster {
+			break
+		}
+
+		if i == 1000 {
+			t.Fatal("expecting the topology to be saved")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	client.Close()
+
+	// a new client routes requests with the saved mapping right away
+	restarted := &Client{
+		TopologyStore: file,
+	}
+
+	defer restarted.Close()
+
+	topology := restarted.Topology()
+	if !topology.Cluster || topology.Epoch != 1 || len(topology.Ranges) != 2 {
+		t.Fatal(topology)
+	}
+
+	if node := restarted.Route(NewRequest("GET", "foo")); node.Address() != "tcp://127.0.0.1:6380" {
+		t.Fatal(node.Address())
+	}
+
+	if replicas := topology.Ranges[1].Replicas; len(replicas) != 1 || replicas[0] != "tcp://127.0.0.1:6381" {
+		t.Fatal(replicas)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"sync"
+)
+
+// part holds the commands of a request sent to one node with their index in the request.
+type part struct {
+	request *Request
+	indexes []int
+	slot    int
+}
+
+// partition groups the commands of the request by the node serving their slot.
+// Commands without keys follow the previous command or the first one with keys.
+// It returns nil when the request must be sent as a whole to a single node.
+func (client *Client) partition(state *mapping, request *Request) (parts []*part) {
+	if !client.SplitPipelines || !state.shards || request.Len() < 2 || request.key != nil || request.node != "" {
+		return
+	}
+
+	nodes := make(map[*Conn]*part)
+
+	var last *part
+	var orphans []int
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		if strings.ToUpper(cmd.name) == "MULTI" {
+			return nil
+		}
+
+		keys := cmd.keys()
+		if last == nil && len(keys) == 0 {
+			orphans = append(orphans, i)
+			continue
+		}
+
+		if len(keys) != 0 {
+			s := Slot(argString(cmd.args[keys[0]]))
+			node := state.slots.get(s)
+
+			if last = nodes[node]; last == nil {
+				last = &part{
+					request: &Request{
+						idempotent:  request.idempotent,
+						readonly:    request.readonly,
+						annotations: request.annotations,
+					},
+					slot: s,
+				}
+
+				nodes[node] = last
+				parts = append(parts, last)
+			}
+		}
+
+		// commands before the first one with keys go first to its node
+		for _, j := range orphans {
+			last.add(request, j)
+		}
+
+		orphans = nil
+		last.add(request, i)
+	}
+
+	if len(parts) < 2 {
+		return nil
+	}
+
+	return
+}
+
+func (p *part) add(request *Request, i int) {
+	p.request.commands = append(p.request.commands, request.commands[i])
+	p.indexes = append(p.indexes, i)
+}
+
+// split sends the parts of the request concurrently and stores their results in the original order.
+// Each part follows its own redirections and retries.
+func (client *Client) split(state *mapping, request *Request, parts []*part) (err error) {
+	var wg sync.WaitGroup
+	for _, p := range parts {
+		wg.Add(1)
+		go func(p *part) {
+			p.request.ForceSlot(p.slot)
+			policy := client.keylessPolicy(p.request)
+			_, node := client.target(state, policy, p.request)
+			client.send(state, p.slot, policy, node, p.request)
+			wg.Done()
+		}(p)
+	}
+
+	wg.Wait()
+
+	for _, p := range parts {
+		_, replied := p.request.err.(ReplyError)
+		for j, i := range p.indexes {
+			cmd := &p.request.commands[j]
+
+			// commands of a part that couldn't be read share its failure
+			if p.request.err != nil && !replied && cmd.err == nil {
+				cmd.err = p.request.err
+			}
+
+			request.commands[i].result, request.commands[i].err = cmd.result, cmd.err
+		}
+	}
+
+	for i := range request.commands {
+		if err = request.commands[i].err; err != nil {
+			break
+		}
+	}
+
+	request.err = err
+	return
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSplitPipelines(t *testing.T) {
+	a, b := new(mockDB), new(mockDB)
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	state.slots.fill(0, Slot("foo")-1, state.nodes["tcp://127.0.0.1:7000"])
+	state.slots.fill(Slot("foo"), 16383, state.nodes["tcp://127.0.0.1:7001"])
+
+	client := &Client{
+		SplitPipelines: true,
+		nodes:          state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	a.result.WriteString("$1\r\n1\r\n+PONG\r\n")
+	b.result.WriteString("$1\r\n2\r\n$1\r\n3\r\n")
+
+	request := NewRequest("GET", "foo")
+	request.Add("GET", "bar")
+	request.Add("PING")
+	request.Add("GET", "{foo}x")
+
+	if err := client.Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	results := ""
+	for i := 0; i < request.Len(); i++ {
+		result, _ := request.Result(i)
+		results += fmt.Sprintf("%s ", result)
+	}
+
+	if results != "2 1 PONG 3 " {
+		t.Fatal(results)
+	}
+
+	// transactions stay on a single node
+	request = NewRequest("MULTI")
+	request.Add("GET", "foo")
+	request.Add("GET", "bar")
+	request.Add("EXEC")
+	if parts := client.partition(state, request); parts != nil {
+		t.Fatal(parts)
+	}
+
+	// WAIT stays with the write it follows
+	request = NewRequest("SET", "bar", 1)
+	request.Add("WAIT", 1, 0)
+	request.Add("SET", "foo", 2)
+	parts := client.partition(state, request)
+	if len(parts) != 2 || fmt.Sprint(parts[0].indexes) != "[0 1]" {
+		t.Fatal(parts)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDeniedCommands defines the patterns of the commands rejected by a Policy without any denied command.
+// Patterns are matched like DefaultRedactedCommands.
+var DefaultDeniedCommands = []string{
+	"KEYS",
+	"FLUSHALL",
+	"FLUSHDB",
+	"DEBUG",
+	"CONFIG",
+	"SHUTDOWN",
+}
+
+// PolicyError is returned when a command is rejected by a Policy before being sent.
+// Key is set when the command was rejected by a KeyRule.
+type PolicyError struct {
+	Command string
+	Key     string
+	Rule    string
+}
+
+func (e *PolicyError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("command %s on key '%s' rejected by the policy '%s'", e.Command, e.Key, e.Rule)
+	}
+
+	return fmt.Sprintf("command %s rejected by the policy '%s'", e.Command, e.Rule)
+}
+
+// Policy implements a Middleware rejecting commands with PolicyError to protect shared databases from dangerous commands.
+// Commands matching the patterns of Deny are always rejected while those matching Privileged are only allowed in requests marked as privileged.
+// Keys optionally restricts the commands on keys matching a pattern, which rejects the commands that aren't known since their keys can't be found.
+type Policy struct {
+	Deny       []string
+	Privileged []string
+	Keys       []KeyRule
+}
+
+// KeyRule restricts the commands on the keys matching Pattern e.g. session:* or cfg:*.
+// RequireTTL rejects writes that would leave a key without expiration unless the same request sets one afterwards e.g. HSET followed by EXPIRE.
+type KeyRule struct {
+	Pattern    string
+	DenyWrites bool
+	RequireTTL bool
+}
+
+// MarkPrivileged allows the request to send the commands restricted to privileged requests by a Policy.
+func (request *Request) MarkPrivileged() {
+	request.privileged = true
+}
+
+// Send checks the request before sending it further down the chain.
+func (policy *Policy) Send(node *Conn, request *Request, next Sender) error {
+	if err := policy.Check(request); err != nil {
+		request.err = err
+		return err
+	}
+
+	return next.Send(request)
+}
+
+// Check returns PolicyError when a command of the request is rejected.
+func (policy *Policy) Check(request *Request) error {
+	deny := policy.Deny
+	if len(deny) == 0 {
+		deny = DefaultDeniedCommands
+	}
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		if rule, ok := matchAny(deny, cmd); ok {
+			return &PolicyError{
+				Command: strings.ToUpper(cmd.name),
+				Rule:    rule,
+			}
+		}
+
+		if request.privileged {
+			continue
+		}
+
+		if rule, ok := matchAny(policy.Privileged, cmd); ok {
+			return &PolicyError{
+				Command: strings.ToUpper(cmd.name),
+				Rule:    rule,
+			}
+		}
+	}
+
+	if len(policy.Keys) != 0 {
+		return policy.checkKeys(request)
+	}
+
+	return nil
+}
+
+// checkKeys returns PolicyError when a write of the request is rejected by a KeyRule.
+func (policy *Policy) checkKeys(request *Request) error {
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		name := strings.ToUpper(cmd.name)
+		if !cmd.known() {
+			return &PolicyError{
+				Command: name,
+				Rule:    "unknown command",
+			}
+		}
+
+		if readCommands[name] {
+			continue
+		}
+
+		for _, j := range cmd.keys() {
+			key := argString(cmd.args[j])
+			for _, rule := range policy.Keys {
+				if !rule.DenyWrites && !rule.RequireTTL || !matchKey(rule.Pattern, key) {
+					continue
+				}
+
+				if rule.DenyWrites || !expires(cmd) && !expiredLater(request.commands[i+1:], key) {
+					return &PolicyError{
+						Command: name,
+						Key:     key,
+						Rule:    rule.Pattern,
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// expires returns true when the command leaves its key with an expiration or deletes it.
+func expires(cmd *command) bool {
+	switch strings.ToUpper(cmd.name) {
+	case "EXPIRE", "PEXPIRE", "EXPIREAT", "PEXPIREAT", "SETEX", "PSETEX", "DEL", "UNLINK", "GETDEL":
+		return true
+	case "SET", "GETEX":
+		for j := 1; j < len(cmd.args); j++ {
+			switch strings.ToUpper(argString(cmd.args[j])) {
+			case "EX", "PX", "EXAT", "PXAT", "KEEPTTL":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// expiredLater returns true when one of the commands sets an expiration on the key.
+func expiredLater(commands []command, key string) bool {
+	for i := range commands {
+		cmd := &commands[i]
+		if len(cmd.args) != 0 && argString(cmd.args[0]) == key && expires(cmd) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchAny returns the first pattern matching the command.
+func matchAny(patterns []string, cmd *command) (string, bool) {
+	for _, pattern := range patterns {
+		if _, ok := matchCommand(pattern, cmd.name, cmd.args); ok {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	db := new(mockDB)
+
+	client := &Client{
+		Middleware: []Middleware{
+			&Policy{
+				Deny:       []string{"FLUSHALL", "CONFIG SET *"},
+				Privileged: []string{"DEBUG"},
+			},
+		},
+	}
+
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	for _, item := range []struct {
+		name string
+		args []interface{}
+		rule string
+	}{
+		{"flushall", nil, "FLUSHALL"},
+		{"CONFIG", []interface{}{"set", "maxmemory", "1"}, "CONFIG SET *"},
+		{"DEBUG", []interface{}{"SLEEP", 1}, "DEBUG"},
+	} {
+		_, err := client.Do(item.name, item.args...)
+		if e, ok := err.(*PolicyError); !ok || e.Rule != item.rule {
+			t.Fatal(item.name, err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&db.writes); n != 0 {
+		t.Fatal("nothing should be sent instead of", n)
+	}
+
+	db.result.WriteString("+OK\r\n$7\r\ndefault\r\n")
+
+	request := NewRequest("DEBUG", "SLEEP", 0)
+	request.MarkPrivileged()
+	if err := client.Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Do("CONFIG", "GET", "maxmemory-policy"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyRules(t *testing.T) {
+	policy := &Policy{
+		Keys: []KeyRule{
+			{Pattern: "session:*", RequireTTL: true},
+			{Pattern: "cfg:*", DenyWrites: true},
+		},
+	}
+
+	check := func(key string, commands ...[]interface{}) error {
+		request := new(Request)
+		for _, cmd := range commands {
+			request.Add(cmd[0].(string), cmd[1:]...)
+		}
+
+		err := policy.Check(request)
+		if e, ok := err.(*PolicyError); ok && e.Key != key {
+			t.Fatal(e)
+		}
+
+		return err
+	}
+
+	if err := check("", []interface{}{"GET", "cfg:a"}, []interface{}{"SET", "session:a", "x", "EX", 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := check("", []interface{}{"HSET", "session:a", "f", "v"}, []interface{}{"EXPIRE", "session:a", 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := check("session:a", []interface{}{"SET", "session:a", "x"}); err == nil {
+		t.Fatal("expecting a missing TTL error")
+	}
+
+	if err := check("session:b", []interface{}{"MSET", "other", "x", "session:b", "y"}); err == nil {
+		t.Fatal("expecting a missing TTL error")
+	}
+
+	if err := check("cfg:a", []interface{}{"DEL", "cfg:a"}); err == nil {
+		t.Fatal("expecting a denied write error")
+	}
+
+	if err := check("", []interface{}{"WAIT", 1, 0}, []interface{}{"SELECT", "cfg:a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := check("", []interface{}{"MODULE.SET", "cfg:a"}); err == nil {
+		t.Fatal("expecting an unknown command error")
+	}
+}
+
+func TestPolicyNodePaths(t *testing.T) {
+	a, b := new(mockDB), new(mockDB)
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	state.slots.fill(0, 8191, state.nodes["tcp://127.0.0.1:7000"])
+	state.slots.fill(8192, 16383, state.nodes["tcp://127.0.0.1:7001"])
+
+	client := &Client{
+		Middleware: []Middleware{
+			&Policy{
+				Deny: []string{"FLUSHALL"},
+			},
+		},
+		KeylessPolicies: map[string]KeylessPolicy{
+			"FLUSHALL": KeylessBroadcast,
+		},
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	if _, err := client.Do("FLUSHALL"); err == nil {
+		t.Fatal("broadcast wasn't rejected")
+	}
+
+	conn, err := client.Conn()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Release()
+
+	if _, err := conn.Do("FLUSHALL"); err == nil {
+		t.Fatal("leased connection wasn't rejected")
+	}
+
+	if n := atomic.LoadInt32(&a.writes) + atomic.LoadInt32(&b.writes); n != 0 {
+		t.Fatal("nothing should be sent instead of", n)
+	}
+
+	// rejected right away or when EXEC is sent
+	for _, f := range []func(tx *Tx) error{
+		func(tx *Tx) (err error) {
+			_, err = tx.Do("FLUSHALL")
+			return
+		},
+		func(tx *Tx) error {
+			return tx.Queue("FLUSHALL")
+		},
+	} {
+		_, err := client.Transaction("a", f)
+		if _, ok := err.(*PolicyError); !ok {
+			t.Fatal(err)
+		}
+	}
+}
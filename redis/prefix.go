@@ -0,0 +1,198 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PrefixClient implements a view of a client where every key is transparently prefixed.
+// Keys returned by SCAN, KEYS, the blocking and multiple pops and the reads of streams are stripped of the prefix.
+// RANDOMKEY isn't supported since it would pick keys outside of the prefix.
+type PrefixClient struct {
+	sender Sender
+	prefix string
+}
+
+// WithPrefix returns a view of the client that prefixes all keys.
+func (client *Client) WithPrefix(prefix string) *PrefixClient {
+	return &PrefixClient{
+		sender: client,
+		prefix: prefix,
+	}
+}
+
+// Do executes the specified command (with optional arguments) with prefixed keys and waits to decode the reply.
+func (client *PrefixClient) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := newRequest(name, args)
+	if err = client.Send(request); err == nil {
+		result = request.commands[len(request.commands)-1].result
+	}
+
+	release(request)
+	return
+}
+
+// Send sends the specified request with prefixed keys and waits for the reply.
+// Commands that aren't known fail without being sent since their keys can't be found.
+func (client *PrefixClient) Send(request *Request) (err error) {
+	view, err := client.rewrite(request)
+	if err != nil {
+		request.err = err
+		return
+	}
+
+	err = client.sender.Send(view)
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		cmd.result, cmd.err = client.strip(cmd.name, view.commands[i].result), view.commands[i].err
+	}
+
+	request.err = view.err
+	return
+}
+
+// rewrite returns a copy of the request with prefixed keys.
+func (client *PrefixClient) rewrite(request *Request) (result *Request, err error) {
+	result = request.clone()
+
+	// keep routing on the key when it was set explicitly
+	if len(result.key) != 0 {
+		result.route(client.prefix + string(result.key))
+	}
+
+	for i := range result.commands {
+		cmd := &result.commands[i]
+		args := make([]interface{}, len(cmd.args))
+		copy(args, cmd.args)
+		cmd.args = args
+
+		switch strings.ToUpper(cmd.name) {
+		case "SCAN":
+			match := false
+			for j := 1; j+1 < len(args); j++ {
+				if strings.ToUpper(argString(args[j])) == "MATCH" {
+					args[j+1] = escapeGlob(client.prefix) + argString(args[j+1])
+					match = true
+				}
+			}
+
+			if !match {
+				cmd.args = append(args, "MATCH", escapeGlob(client.prefix)+"*")
+			}
+		case "KEYS":
+			if len(args) != 0 {
+				args[0] = escapeGlob(client.prefix) + argString(args[0])
+			}
+		case "RANDOMKEY":
+			err = fmt.Errorf("cannot prefix %s, which returns keys outside of the prefix", strings.ToUpper(cmd.name))
+			return
+		default:
+			if !cmd.known() {
+				err = fmt.Errorf("cannot prefix the keys of unknown command %s", strings.ToUpper(cmd.name))
+				return
+			}
+
+			for _, j := range cmd.keys() {
+				args[j] = client.key(args[j])
+			}
+		}
+	}
+
+	return
+}
+
+func (client *PrefixClient) key(arg interface{}) interface{} {
+	switch arg := arg.(type) {
+	case string:
+		return client.prefix + arg
+	case []byte:
+		return append([]byte(client.prefix), arg...)
+	}
+
+	return client.prefix + fmt.Sprint(arg)
+}
+
+// strip removes the prefix of the keys returned by SCAN, KEYS, the pops naming the key they popped from and the reads of streams.
+func (client *PrefixClient) strip(name string, result interface{}) interface{} {
+	switch strings.ToUpper(name) {
+	case "SCAN":
+		items, ok := result.([]interface{})
+		if !ok || len(items) != 2 {
+			break
+		}
+
+		return []interface{}{items[0], client.stripKeys(items[1])}
+	case "KEYS":
+		return client.stripKeys(result)
+	case "BLPOP", "BRPOP", "BZPOPMIN", "BZPOPMAX", "LMPOP", "BLMPOP", "ZMPOP", "BZMPOP":
+		return client.stripFirst(result)
+	case "XREAD", "XREADGROUP":
+		// one reply per stream starting with its key
+		items, ok := result.([]interface{})
+		if !ok {
+			break
+		}
+
+		streams := make([]interface{}, len(items))
+		for i, item := range items {
+			streams[i] = client.stripFirst(item)
+		}
+
+		return streams
+	}
+
+	return result
+}
+
+func (client *PrefixClient) stripKeys(result interface{}) interface{} {
+	items, ok := result.([]interface{})
+	if !ok {
+		return result
+	}
+
+	keys := make([]interface{}, len(items))
+	for i, item := range items {
+		keys[i] = client.stripKey(item)
+	}
+
+	return keys
+}
+
+// stripFirst removes the prefix of the key that comes first in the reply.
+func (client *PrefixClient) stripFirst(result interface{}) interface{} {
+	items, ok := result.([]interface{})
+	if !ok || len(items) == 0 {
+		return result
+	}
+
+	reply := make([]interface{}, len(items))
+	copy(reply, items)
+	reply[0] = client.stripKey(reply[0])
+	return reply
+}
+
+func (client *PrefixClient) stripKey(item interface{}) interface{} {
+	if key, ok := item.([]byte); ok {
+		return bytes.TrimPrefix(key, []byte(client.prefix))
+	}
+
+	return item
+}
+
+func escapeGlob(text string) string {
+	var buffer bytes.Buffer
+	for _, c := range text {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			buffer.WriteByte('\\')
+		}
+
+		buffer.WriteRune(c)
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixRewrite(t *testing.T) {
+	client := (&Client{}).WithPrefix("svc:")
+
+	test := func(request *Request, expected ...[]interface{}) {
+		result, err := client.rewrite(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range expected {
+			if !reflect.DeepEqual(result.commands[i].args, expected[i]) {
+				t.Fatalf("unexpected arguments %v instead of %v", result.commands[i].args, expected[i])
+			}
+		}
+	}
+
+	test(NewRequest("GET", "foo"), []interface{}{"svc:foo"})
+	test(NewRequest("SET", []byte("foo"), "bar"), []interface{}{[]byte("svc:foo"), "bar"})
+	test(NewRequest("MSET", "a", 1, "b", 2), []interface{}{"svc:a", 1, "svc:b", 2})
+	test(NewRequest("DEL", "a", "b"), []interface{}{"svc:a", "svc:b"})
+	test(NewRequest("BLPOP", "a", "b", 0), []interface{}{"svc:a", "svc:b", 0})
+	test(NewRequest("EVALSHA", "sha", 2, "a", "b", "arg"), []interface{}{"sha", 2, "svc:a", "svc:b", "arg"})
+	test(NewRequest("ZUNIONSTORE", "out", 2, "a", "b", "WEIGHTS", 1, 2), []interface{}{"svc:out", 2, "svc:a", "svc:b", "WEIGHTS", 1, 2})
+	test(NewRequest("SCAN", "0"), []interface{}{"0", "MATCH", "svc:*"})
+	test(NewRequest("SCAN", "0", "MATCH", "user:*", "COUNT", 10), []interface{}{"0", "MATCH", "svc:user:*", "COUNT", 10})
+	test(NewRequest("KEYS", "*"), []interface{}{"svc:*"})
+	test(NewRequest("PING"), []interface{}{})
+	test(NewRequest("WAIT", 1, 100), []interface{}{1, 100})
+	test(NewRequest("SELECT", 2), []interface{}{2})
+
+	if _, err := client.rewrite(NewRequest("MODULE.CMD", "foo")); err == nil {
+		t.Fatal("unknown command was prefixed")
+	}
+
+	if _, err := client.rewrite(NewRequest("RANDOMKEY")); err == nil {
+		t.Fatal("RANDOMKEY was sent")
+	}
+
+	original := NewRequest("GET", "foo")
+	client.rewrite(original)
+	if !reflect.DeepEqual(original.commands[0].args, []interface{}{"foo"}) {
+		t.Fatal("original request was modified")
+	}
+
+	scan := []interface{}{[]byte("0"), []interface{}{[]byte("svc:a"), []byte("svc:b")}}
+	expected := []interface{}{[]byte("0"), []interface{}{[]byte("a"), []byte("b")}}
+	if result := client.strip("SCAN", scan); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %v", result)
+	}
+
+	replies := []struct {
+		name     string
+		result   interface{}
+		expected interface{}
+	}{
+		{"BLPOP", []interface{}{[]byte("svc:a"), []byte("x")}, []interface{}{[]byte("a"), []byte("x")}},
+		{"BLPOP", nil, nil},
+		{"BZPOPMIN", []interface{}{[]byte("svc:z"), []byte("m"), []byte("1")}, []interface{}{[]byte("z"), []byte("m"), []byte("1")}},
+		{"LMPOP", []interface{}{[]byte("svc:l"), []interface{}{[]byte("x")}}, []interface{}{[]byte("l"), []interface{}{[]byte("x")}}},
+		{"XREAD", []interface{}{[]interface{}{[]byte("svc:s"), []interface{}{}}}, []interface{}{[]interface{}{[]byte("s"), []interface{}{}}}},
+	}
+
+	for _, reply := range replies {
+		if result := client.strip(reply.name, reply.result); !reflect.DeepEqual(result, reply.expected) {
+			t.Fatalf("%s: unexpected result %v", reply.name, result)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPriorityWeight defines the default number of interactive requests sent for each batch request when both are pending.
+var DefaultPriorityWeight = 8
+
+// Priority is the class of a request sharing a connection with others.
+type Priority int
+
+const (
+	// Interactive requests are latency-critical e.g. user-facing lookups.
+	Interactive Priority = iota
+
+	// Batch requests yield to interactive ones but still get a share of the connection e.g. bulk loading.
+	Batch
+)
+
+// SetPriority sets the class of the request, Interactive by default.
+func (request *Request) SetPriority(priority Priority) {
+	request.priority = priority
+}
+
+// Priority returns the class of the request.
+func (request *Request) Priority() Priority {
+	return request.priority
+}
+
+// PendingBatch returns the number of batch requests queued and not yet sent to the node.
+func (conn *Conn) PendingBatch() int {
+	return int(atomic.LoadInt64(&conn.queuedBatch))
+}
+
+// queue returns the queue of the class of the request.
+func (conn *Conn) queue(request *Request) chan *Request {
+	if request.priority == Batch {
+		atomic.AddInt64(&conn.queuedBatch, 1)
+		return conn.batch
+	}
+
+	return conn.feed
+}
+
+// dequeued updates the queue depths once the request is taken out of its queue.
+func (conn *Conn) dequeued(request *Request) {
+	atomic.AddInt64(&conn.queued, -1)
+	if request.priority == Batch {
+		atomic.AddInt64(&conn.queuedBatch, -1)
+	}
+}
+
+// scheduler picks the next request to send with weighted fairness between classes.
+type scheduler struct {
+	weight int
+
+	// streak counts interactive requests taken in a row while batch ones were waiting.
+	streak int
+}
+
+// next returns the next request or expired once the timer fires first.
+// ok is false once the connection is closed.
+func (s *scheduler) next(conn *Conn, timer <-chan time.Time) (request *Request, ok, expired bool) {
+	if s.streak >= s.weight || len(conn.feed) == 0 {
+		select {
+		case request = <-conn.batch:
+			s.streak = 0
+			ok = true
+			return
+		default:
+		}
+	}
+
+	select {
+	case request, ok = <-conn.feed:
+	default:
+		select {
+		case request, ok = <-conn.feed:
+		case request = <-conn.batch:
+			ok = true
+		case <-timer:
+			ok = true
+			expired = true
+			return
+		}
+	}
+
+	if request != nil && request.priority != Batch && len(conn.batch) != 0 {
+		s.streak++
+	} else {
+		s.streak = 0
+	}
+
+	return
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestPriority(t *testing.T) {
+	conn := &Conn{
+		feed:  make(chan *Request, 8),
+		batch: make(chan *Request, 8),
+	}
+
+	for i := 0; i < 4; i++ {
+		request := NewRequest("GET", "batch")
+		request.SetPriority(Batch)
+		conn.enqueue(request)
+		conn.enqueue(NewRequest("GET", "interactive"))
+	}
+
+	if n := conn.Stats().PendingBatch; n != 4 {
+		t.Fatal(n)
+	}
+
+	queues := scheduler{weight: 2}
+
+	order := ""
+	for i := 0; i < 8; i++ {
+		request, ok, expired := queues.next(conn, nil)
+		if !ok || expired {
+			t.Fatal("expecting a request")
+		}
+
+		conn.dequeued(request)
+		if request.Priority() == Batch {
+			order += "b"
+		} else {
+			order += "i"
+		}
+	}
+
+	// batch requests get one turn every 2 interactive ones and the rest once no interactive ones are left
+	if order != "iibiibbb" {
+		t.Fatal(order)
+	}
+
+	if n := conn.Pending(); n != 0 {
+		t.Fatal(n)
+	}
+}
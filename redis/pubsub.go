@@ -0,0 +1,409 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSubscriberBufferSize defines the default number of messages held by a subscriber before its overflow policy applies.
+var DefaultSubscriberBufferSize = 1000
+
+// ErrSubscriberClosed is returned when changing the subscriptions of a closed subscriber.
+var ErrSubscriberClosed = errors.New("subscriber closed")
+
+// Message is a message published on a channel.
+// Pattern is the pattern matched by the channel for subscriptions made with PSubscribe.
+type Message struct {
+	Channel string
+	Pattern string
+	Payload []byte
+}
+
+// OverflowPolicy defines what a subscriber does with the messages received while its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock stops reading from the connection until the consumer catches up.
+	// Redis may then disconnect the subscriber once its output buffer limit is reached.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest pending message to make room for the new one.
+	OverflowDropOldest
+
+	// OverflowDropNewest discards the new message.
+	OverflowDropNewest
+
+	// OverflowSpill passes the new message to the Spill function of the subscriber.
+	OverflowSpill
+)
+
+// SubscriberStats holds the statistics of a subscriber.
+// Dropped counts the messages discarded because the consumer fell behind and Spilled those passed to the Spill function.
+// Unhandled counts the messages received without a handler when handlers are used and Duplicates the copies dropped by a ClusterSubscriber.
+type SubscriberStats struct {
+	Received   int64
+	Dropped    int64
+	Spilled    int64
+	Unhandled  int64
+	Duplicates int64
+	Reconnects int64
+	Pending    int
+}
+
+// Subscriber receives the messages published on channels over a connection dedicated to Pub/Sub.
+// Messages are delivered in order on the channel returned by Messages, which holds up to BufferSize of them.
+// The subscriptions are made again when the connection is established again but the messages published in the meantime are lost.
+// The read timeout of the node doesn't apply while waiting for messages.
+type Subscriber struct {
+	BufferSize int
+	Overflow   OverflowPolicy
+
+	// Spill is called on the reader goroutine with the messages that don't fit in the buffer with OverflowSpill.
+	// They are dropped when it isn't set.
+	Spill func(Message)
+
+	// Workers is the number of goroutines calling the functions registered with Handle.
+	Workers int
+
+	node     *Conn
+	once     sync.Once
+	mu       sync.Mutex
+	fd       net.Conn
+	closed   bool
+	channels map[string]bool
+	patterns map[string]bool
+	inbox
+
+	hmu      sync.RWMutex
+	handlers map[string]*handler
+	dispatch sync.Once
+
+	reconnects int64
+	unhandled  int64
+}
+
+// inbox holds the messages received until they are consumed.
+type inbox struct {
+	messages chan Message
+	done     chan struct{}
+	received int64
+	dropped  int64
+	spilled  int64
+}
+
+// Subscriber returns a subscriber to the channels of the node serving the first slot.
+// Messages published with PUBLISH are broadcast to every node of a cluster so any of them can be subscribed to.
+func (client *Client) Subscriber() (s *Subscriber, err error) {
+	node := client.load().slots.get(0)
+	if node == nil {
+		err = fmt.Errorf("no node serving slot %d", 0)
+		return
+	}
+
+	s = node.Subscriber()
+	return
+}
+
+// Subscriber returns a subscriber to the channels of the node with a connection of its own.
+func (conn *Conn) Subscriber() *Subscriber {
+	return &Subscriber{
+		node:     conn,
+		channels: make(map[string]bool),
+		patterns: make(map[string]bool),
+	}
+}
+
+// Messages returns the channel of the messages received, which is closed with the subscriber.
+func (s *Subscriber) Messages() <-chan Message {
+	s.once.Do(s.initialize)
+	return s.messages
+}
+
+// Subscribe subscribes to the channels.
+func (s *Subscriber) Subscribe(channels ...string) error {
+	return s.change("SUBSCRIBE", s.channels, true, channels)
+}
+
+// PSubscribe subscribes to the channels matching the glob-style patterns.
+func (s *Subscriber) PSubscribe(patterns ...string) error {
+	return s.change("PSUBSCRIBE", s.patterns, true, patterns)
+}
+
+// Unsubscribe unsubscribes from the channels or from all of them when none is specified.
+func (s *Subscriber) Unsubscribe(channels ...string) error {
+	return s.change("UNSUBSCRIBE", s.channels, false, channels)
+}
+
+// PUnsubscribe unsubscribes from the patterns or from all of them when none is specified.
+func (s *Subscriber) PUnsubscribe(patterns ...string) error {
+	return s.change("PUNSUBSCRIBE", s.patterns, false, patterns)
+}
+
+// Stats returns the statistics of the subscriber.
+func (s *Subscriber) Stats() SubscriberStats {
+	s.once.Do(s.initialize)
+
+	stats := s.inbox.stats()
+	stats.Unhandled = atomic.LoadInt64(&s.unhandled)
+	stats.Reconnects = atomic.LoadInt64(&s.reconnects)
+	return stats
+}
+
+// Close closes the connection of the subscriber and its channel of messages once the pending ones are delivered.
+func (s *Subscriber) Close() error {
+	s.once.Do(s.initialize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+
+		if s.fd != nil {
+			s.fd.Close()
+		}
+	}
+
+	return nil
+}
+
+// change updates the subscriptions and sends the command when connected.
+func (s *Subscriber) change(command string, names map[string]bool, subscribe bool, args []string) (err error) {
+	s.once.Do(s.initialize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSubscriberClosed
+	}
+
+	if !subscribe && len(args) == 0 {
+		for name := range names {
+			delete(names, name)
+		}
+	}
+
+	for _, name := range args {
+		if subscribe {
+			names[name] = true
+		} else {
+			delete(names, name)
+		}
+	}
+
+	if s.fd == nil {
+		return
+	}
+
+	values := make([]interface{}, len(args))
+	for i := range args {
+		values[i] = args[i]
+	}
+
+	err = NewEncoder(s.fd).Encode(command, values...)
+	return
+}
+
+func (s *Subscriber) initialize() {
+	s.inbox.open(s.BufferSize)
+
+	go func() {
+		defer close(s.messages)
+
+		// failures counts the connections dropped before anything was read to back off
+		failures := 0
+		for n := 0; ; n++ {
+			fd := s.connect(failures)
+			if fd == nil {
+				return
+			}
+
+			if n != 0 {
+				atomic.AddInt64(&s.reconnects, 1)
+			}
+
+			failures++
+
+			decoder := NewDecoder(fd)
+			for {
+				reply, err := decoder.Decode()
+				if err != nil {
+					break
+				}
+
+				failures = 0
+				if message, ok := parseMessage(reply); ok {
+					s.deliver(message, s.Overflow, s.Spill)
+				}
+			}
+
+			s.mu.Lock()
+			s.fd = nil
+			s.mu.Unlock()
+
+			fd.Close()
+		}
+	}()
+}
+
+// connect establishes the connection and subscribes again until it succeeds or the subscriber is closed.
+// It waits before the first attempt after failures.
+func (s *Subscriber) connect(failures int) net.Conn {
+	timeout := s.node.RetryTimeout
+	if 0 == timeout {
+		timeout = DefaultRetryTimeout
+	}
+
+	for n := failures; ; n++ {
+		if n != 0 {
+			select {
+			case <-time.After(time.Duration(n) * timeout):
+			case <-s.done:
+				return nil
+			}
+		}
+
+		fd, err := s.node.connect()
+		if err != nil {
+			log.Println("subscriber connection error:", err)
+			continue
+		}
+
+		noReadTimeout(fd)
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			fd.Close()
+			return nil
+		}
+
+		if err = s.resubscribe(fd); err != nil {
+			s.mu.Unlock()
+			fd.Close()
+			continue
+		}
+
+		s.fd = fd
+		s.mu.Unlock()
+		return fd
+	}
+}
+
+// resubscribe sends the subscriptions on the new connection and must be called with the lock held.
+func (s *Subscriber) resubscribe(fd net.Conn) (err error) {
+	encoder := NewEncoder(fd)
+	for command, names := range map[string]map[string]bool{"SUBSCRIBE": s.channels, "PSUBSCRIBE": s.patterns} {
+		if len(names) == 0 {
+			continue
+		}
+
+		args := make([]interface{}, 0, len(names))
+		for name := range names {
+			args = append(args, name)
+		}
+
+		if err = encoder.Buffer(command, args...); err != nil {
+			return
+		}
+	}
+
+	err = encoder.Flush()
+	return
+}
+
+func (box *inbox) open(size int) {
+	if 0 == size {
+		size = DefaultSubscriberBufferSize
+	}
+
+	box.messages = make(chan Message, size)
+	box.done = make(chan struct{})
+}
+
+func (box *inbox) stats() SubscriberStats {
+	return SubscriberStats{
+		Received: atomic.LoadInt64(&box.received),
+		Dropped:  atomic.LoadInt64(&box.dropped),
+		Spilled:  atomic.LoadInt64(&box.spilled),
+		Pending:  len(box.messages),
+	}
+}
+
+// deliver passes the message to the consumer according to the overflow policy.
+func (box *inbox) deliver(message Message, overflow OverflowPolicy, spill func(Message)) {
+	atomic.AddInt64(&box.received, 1)
+
+	if overflow == OverflowBlock {
+		select {
+		case box.messages <- message:
+		case <-box.done:
+		}
+
+		return
+	}
+
+	for {
+		select {
+		case box.messages <- message:
+			return
+		default:
+		}
+
+		switch overflow {
+		case OverflowDropOldest:
+			select {
+			case <-box.messages:
+				atomic.AddInt64(&box.dropped, 1)
+			default:
+			}
+
+			continue
+		case OverflowSpill:
+			if spill != nil {
+				atomic.AddInt64(&box.spilled, 1)
+				spill(message)
+				return
+			}
+		}
+
+		atomic.AddInt64(&box.dropped, 1)
+		return
+	}
+}
+
+// parseMessage returns the message of a message or pmessage reply.
+func parseMessage(reply interface{}) (message Message, ok bool) {
+	items, _ := reply.([]interface{})
+	if len(items) < 3 {
+		return
+	}
+
+	kind, _ := items[0].([]byte)
+	switch string(kind) {
+	case "message":
+		channel, _ := items[1].([]byte)
+		payload, _ := items[2].([]byte)
+		message, ok = Message{Channel: string(channel), Payload: payload}, true
+	case "pmessage":
+		if len(items) < 4 {
+			return
+		}
+
+		pattern, _ := items[1].([]byte)
+		channel, _ := items[2].([]byte)
+		payload, _ := items[3].([]byte)
+		message, ok = Message{Channel: string(channel), Pattern: string(pattern), Payload: payload}, true
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSubscriber(t *testing.T) {
+	tests := []struct {
+		overflow OverflowPolicy
+		received []string
+		dropped  int64
+		spilled  int64
+	}{
+		{OverflowBlock, []string{"0", "1", "2", "3", "4"}, 0, 0},
+		{OverflowDropOldest, []string{"3", "4"}, 3, 0},
+		{OverflowDropNewest, []string{"0", "1"}, 3, 0},
+		{OverflowSpill, []string{"0", "1"}, 0, 3},
+	}
+
+	for i, test := range tests {
+		db := new(mockDB)
+		db.result.WriteString("*3\r\n$9\r\nsubscribe\r\n$1\r\na\r\n:1\r\n")
+		for j := 0; j < 5; j++ {
+			db.result.WriteString(fmt.Sprintf("*3\r\n$7\r\nmessage\r\n$1\r\na\r\n$1\r\n%d\r\n", j))
+		}
+
+		var spilled []string
+		s := (&Conn{db: db, RetryTimeout: time.Hour}).Subscriber()
+		s.BufferSize = 2
+		s.Overflow = test.overflow
+		s.Spill = func(m Message) {
+			spilled = append(spilled, string(m.Payload))
+		}
+
+		if err := s.Subscribe("a"); err != nil {
+			t.Fatal(err)
+		}
+
+		var received []string
+		if test.overflow == OverflowBlock {
+			for len(received) != 5 {
+				m := <-s.Messages()
+				received = append(received, string(m.Payload))
+			}
+		} else {
+			for s.Stats().Received != 5 {
+				time.Sleep(time.Millisecond)
+			}
+
+			for len(received) != 2 {
+				m := <-s.Messages()
+				received = append(received, string(m.Payload))
+			}
+		}
+
+		stats := s.Stats()
+		if fmt.Sprint(received) != fmt.Sprint(test.received) || stats.Dropped != test.dropped || stats.Spilled != test.spilled || len(spilled) != int(test.spilled) {
+			t.Fatal(i, received, stats, spilled)
+		}
+
+		s.Close()
+		if _, ok := <-s.Messages(); ok {
+			t.Fatal(i, "expecting the messages to be closed")
+		}
+
+		if err := s.Subscribe("b"); err != ErrSubscriberClosed {
+			t.Fatal(i, err)
+		}
+	}
+}
+
+func TestSubscriberNoReadTimeout(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	fd := &countedConn{
+		Conn: &deadlineConn{Conn: a, read: time.Millisecond},
+		conn: new(Conn),
+	}
+
+	noReadTimeout(fd)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Write([]byte("+OK\r\n"))
+	}()
+
+	if reply, err := NewDecoder(fd).Decode(); err != nil || reply != OK {
+		t.Fatal(reply, err)
+	}
+}
+
+func TestSubscriberUnmappedSlot(t *testing.T) {
+	state := &mapping{
+		shards: true,
+		nodes:  map[string]*Conn{},
+	}
+
+	client := &Client{
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	if s, err := client.Subscriber(); err == nil || s != nil {
+		t.Fatal(s, err)
+	}
+}
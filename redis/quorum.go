@@ -0,0 +1,65 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrNoQuorum is returned when the nodes asked for the mapping of the cluster don't agree on it.
+var ErrNoQuorum = errors.New("no quorum on the mapping of the cluster")
+
+// clusterSlots returns the CLUSTER SLOTS reply of the node or, with a TopologyQuorum, the reply agreed upon by more than half of the nodes asked.
+// The other nodes are picked among the known masters then replicas.
+func (client *Client) clusterSlots(last *mapping, node *Conn) (result interface{}, err error) {
+	n := client.TopologyQuorum
+	if n <= 1 {
+		result, err = node.Do("CLUSTER", "SLOTS")
+		return
+	}
+
+	nodes := map[string]*Conn{node.address: node}
+	for _, known := range []map[string]*Conn{last.nodes, last.replicas} {
+		for name, other := range known {
+			if len(nodes) < n && !other.Quarantined() {
+				nodes[name] = other
+			}
+		}
+	}
+
+	results, _ := each(nodes, func(name string, node *Conn) (interface{}, error) {
+		return node.Do("CLUSTER", "SLOTS")
+	})
+
+	votes := make(map[string]int)
+	for _, reply := range results {
+		key := fingerprint(reply)
+		if votes[key]++; 2*votes[key] > len(nodes) {
+			result = reply
+			return
+		}
+	}
+
+	err = ErrNoQuorum
+	return
+}
+
+// fingerprint returns the ranges of slots of a CLUSTER SLOTS reply and their masters in a comparable form.
+// Replicas are left out since nodes may briefly disagree on them without affecting the routing.
+func fingerprint(reply interface{}) string {
+	items, err := parseClusterSlots(reply)
+	if err != nil {
+		return fmt.Sprint(reply)
+	}
+
+	ranges := make([]string, len(items))
+	for i, item := range items {
+		ranges[i] = fmt.Sprintf("%d-%d:%s", item.First, item.Last, item.Master.Address)
+	}
+
+	sort.Strings(ranges)
+	return strings.Join(ranges, ",")
+}
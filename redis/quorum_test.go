@@ -0,0 +1,62 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTopologyQuorum(t *testing.T) {
+	client := &Client{TopologyQuorum: 3}
+	defer client.Close()
+
+	client.load()
+
+	slots := func(host string) string {
+		return fmt.Sprintf("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$%d\r\n%s\r\n:6379\r\n", len(host), host)
+	}
+
+	state := &mapping{
+		id:        1,
+		shards:    true,
+		nodes:     make(map[string]*Conn),
+		replicas:  make(map[string]*Conn),
+		ids:       make(map[string]string),
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	var dbs []*mockDB
+	for i := 1; i <= 3; i++ {
+		db := new(mockDB)
+		node := client.Node(fmt.Sprintf("tcp://127.0.0.%d:6379", i))
+		node.db = db
+		state.nodes[node.address] = node
+		dbs = append(dbs, db)
+	}
+
+	node := state.nodes["tcp://127.0.0.1:6379"]
+	state.slots.fill(0, 16383, node)
+	client.state.Store(state)
+
+	dbs[0].result.WriteString(slots("127.0.0.2"))
+	dbs[1].result.WriteString(slots("127.0.0.2"))
+	dbs[2].result.WriteString(slots("127.0.0.1"))
+
+	result, err := client.clusterSlots(state, node)
+	if err != nil || fingerprint(result) != "0-16383:tcp://127.0.0.2:6379" {
+		t.Fatal(fingerprint(result), err)
+	}
+
+	dbs[0].result.WriteString(slots("127.0.0.1"))
+	dbs[1].result.WriteString(slots("127.0.0.2"))
+	dbs[2].result.WriteString(slots("127.0.0.3"))
+
+	client.mu.Lock()
+	next, err := client.reconfigure(state, node)
+	client.mu.Unlock()
+
+	if err != ErrNoQuorum || next != state {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultReadyInterval defines the default delay between the checks of WaitUntilReady.
+var DefaultReadyInterval = 100 * time.Millisecond
+
+// ReadyOptions defines when WaitUntilReady considers the cluster ready.
+// Quorum is the number of masters that must reply to PING and defaults to more than half of them.
+// ReplicasInSync also waits for every replica of the cluster to be online with its master.
+type ReadyOptions struct {
+	Quorum         int
+	ReplicasInSync bool
+	Interval       time.Duration
+}
+
+// WaitUntilReady blocks until every slot of the cluster is served, enough masters reply to PING and optionally the replicas are in sync.
+// It returns the reason why the cluster isn't ready when it still isn't after the timeout.
+// Without a cluster, it waits for the database to reply to PING.
+func (client *Client) WaitUntilReady(timeout time.Duration, options ReadyOptions) (err error) {
+	interval := options.Interval
+	if 0 == interval {
+		interval = DefaultReadyInterval
+	}
+
+	clock := client.clock()
+	deadline := clock.Now().Add(timeout)
+	for {
+		if err = client.ready(options); err == nil {
+			return
+		}
+
+		if clock.Now().Add(interval).After(deadline) {
+			err = fmt.Errorf("not ready after %s: %v", timeout, err)
+			return
+		}
+
+		clock.Sleep(interval)
+	}
+}
+
+// ready returns why the cluster isn't ready or nil.
+func (client *Client) ready(options ReadyOptions) (err error) {
+	node := client.load().slots.get(0)
+	if node == nil {
+		err = fmt.Errorf("slot %d not served", 0)
+		return
+	}
+
+	ranges, err := node.ClusterSlots()
+	if _, ok := err.(ReplyError); ok {
+		// cluster support is disabled
+		_, err = node.Do("PING")
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	covered := 0
+	masters := make(map[string]*Conn)
+	replicas := make(map[string]int)
+	for _, item := range ranges {
+		covered += item.Last - item.First + 1
+		masters[item.Master.Address] = client.Node(item.Master.Address)
+		replicas[item.Master.Address] = len(item.Replicas)
+	}
+
+	if covered < 16384 {
+		err = fmt.Errorf("%d slots not served", 16384-covered)
+		return
+	}
+
+	quorum := options.Quorum
+	if 0 == quorum {
+		quorum = len(masters)/2 + 1
+	}
+
+	results, _ := each(masters, func(name string, node *Conn) (interface{}, error) {
+		if _, err := node.Do("PING"); err != nil || !options.ReplicasInSync {
+			return nil, err
+		}
+
+		info, err := node.Info("replication")
+		if err != nil {
+			return nil, err
+		}
+
+		online := 0
+		for _, slave := range info.Replication.Slaves {
+			if slave.State == "online" {
+				online++
+			}
+		}
+
+		return online >= replicas[name], nil
+	})
+
+	if len(results) < quorum {
+		err = fmt.Errorf("%d of %d masters replied", len(results), len(masters))
+		return
+	}
+
+	for name, result := range results {
+		if synced, ok := result.(bool); ok && !synced {
+			err = fmt.Errorf("replicas of %s not in sync", name)
+			return
+		}
+	}
+
+	return
+}
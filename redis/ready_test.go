@@ -0,0 +1,38 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitUntilReady(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n+PONG\r\n")
+	if err := client.WaitUntilReady(time.Second, ReadyOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	db.result.WriteString("*1\r\n*3\r\n:0\r\n:8191\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n")
+	if err := client.WaitUntilReady(0, ReadyOptions{}); err == nil {
+		t.Fatal("expecting slots not to be served")
+	}
+
+	// the slot table is empty while the cluster is being set up
+	empty := &Client{}
+	empty.once.Do(func() {})
+	empty.state.Store(&mapping{shards: true})
+
+	if err := empty.WaitUntilReady(0, ReadyOptions{}); err == nil || !strings.Contains(err.Error(), "slot 0 not served") {
+		t.Fatal(err)
+	}
+}
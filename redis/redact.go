@@ -0,0 +1,113 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultRedactedCommands defines the patterns of the commands whose arguments are hidden when printed in logs, traces or errors.
+// A pattern is a list of words matched against the command name and its first arguments, ignoring case and where words can be globs.
+// Every argument after the matched words is redacted e.g. "SET session:*" hides the values of session keys.
+var DefaultRedactedCommands = []string{
+	"AUTH",
+	"HELLO",
+	"CONFIG SET requirepass",
+	"CONFIG SET masterauth",
+	"ACL SETUSER *",
+}
+
+// DefaultRedactedReplies defines the patterns of the commands whose replies are hidden in traces, matched like DefaultRedactedCommands.
+// CONFIG GET is included since its patterns like * also return the passwords.
+var DefaultRedactedReplies = []string{
+	"HELLO",
+	"CONFIG GET",
+	"ACL GETUSER",
+	"ACL LIST",
+}
+
+const redacted = "(redacted)"
+
+// Redact returns the arguments of the command with the sensitive values replaced according to DefaultRedactedCommands.
+func Redact(name string, args []interface{}) []interface{} {
+	for _, pattern := range DefaultRedactedCommands {
+		if n, ok := matchCommand(pattern, name, args); ok {
+			result := make([]interface{}, len(args))
+			copy(result, args[:n])
+
+			for i := n; i < len(args); i++ {
+				result[i] = redacted
+			}
+
+			return result
+		}
+	}
+
+	return args
+}
+
+// redactedReply returns true when the reply of the command must be hidden according to DefaultRedactedReplies.
+func redactedReply(cmd *command) bool {
+	for _, pattern := range DefaultRedactedReplies {
+		if _, ok := matchCommand(pattern, cmd.name, cmd.args); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchCommand returns the number of arguments matched by the words of the pattern after the command name.
+func matchCommand(pattern, name string, args []interface{}) (n int, ok bool) {
+	words := strings.Fields(pattern)
+	if len(words) == 0 || len(words)-1 > len(args) || !matchWord(words[0], name) {
+		return
+	}
+
+	for i, word := range words[1:] {
+		if !matchWord(word, argString(args[i])) {
+			return
+		}
+	}
+
+	n, ok = len(words)-1, true
+	return
+}
+
+func matchWord(pattern, text string) bool {
+	ok, err := path.Match(strings.ToUpper(pattern), strings.ToUpper(text))
+	return ok && err == nil
+}
+
+// String returns the commands of the request with their arguments redacted.
+func (request *Request) String() string {
+	var buffer bytes.Buffer
+	for i := range request.commands {
+		if i != 0 {
+			buffer.WriteString("; ")
+		}
+
+		buffer.WriteString(request.commands[i].String())
+	}
+
+	return buffer.String()
+}
+
+// String returns the command with its arguments redacted.
+func (cmd *command) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(cmd.name)
+
+	for _, arg := range Redact(cmd.name, cmd.args) {
+		if s, ok := arg.([]byte); ok {
+			arg = string(s)
+		}
+
+		fmt.Fprintf(&buffer, " %v", arg)
+	}
+
+	return buffer.String()
+}
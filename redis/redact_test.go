@@ -0,0 +1,33 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	request := NewRequest("AUTH", "user", "secret")
+	request.Add("config", "set", "requirepass", "secret")
+	request.Add("CONFIG", "SET", "maxmemory", "1gb")
+	request.Add("SET", "session:42", []byte("token"))
+
+	expected := "AUTH (redacted) (redacted); config set requirepass (redacted); CONFIG SET maxmemory 1gb; SET session:42 token"
+	if text := request.String(); text != expected {
+		t.Fatal(text)
+	}
+
+	patterns := DefaultRedactedCommands
+	defer func() {
+		DefaultRedactedCommands = patterns
+	}()
+
+	DefaultRedactedCommands = append(DefaultRedactedCommands, "SET session:*")
+
+	expected = "SET session:42 (redacted)"
+	if text := NewRequest("SET", "session:42", []byte("token")).String(); text != expected {
+		t.Fatal(text)
+	}
+
+	if args := Redact("GET", []interface{}{"session:42"}); args[0] != "session:42" {
+		t.Fatal(args)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "time"
+
+// DefaultDrainDelay defines the default delay before the connections replaced by Reload are closed.
+// This gives the requests already sent to them the time to complete.
+var DefaultDrainDelay = 10 * time.Second
+
+// Reload applies the addresses and the connection settings of the configuration to the live client.
+// This is meant to follow updates of the blueprint of the client without restarting.
+// New connections are created with the settings and atomically replace the current ones, which are closed after DefaultDrainDelay.
+// The settings are published with the mapping of the slots so the fields of the client keep their initial values.
+// Settings used when sending requests like the retry policies, the router or the hedge aren't changed.
+func (client *Client) Reload(config *Client) {
+	state := client.load()
+	if state.closed {
+		return
+	}
+
+	// later changes of the configuration don't affect the client
+	address := config.Address
+	if config = config.config(); len(address) == 0 {
+		config.Address = client.settings().Address
+	}
+
+	if state.shards {
+		client.mu.Lock()
+		client.rebuild(config)
+		client.mu.Unlock()
+		return
+	}
+
+	// without a cluster, the addresses are dialed without the lock like when the client starts
+	nodes, seeds := client.seeds(config)
+	primary := client.seed(seeds)
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	last := client.state.Load().(*mapping)
+	if last.closed || last.shards {
+		for _, item := range nodes {
+			item.Close()
+		}
+
+		if !last.closed {
+			client.rebuild(config)
+		}
+
+		return
+	}
+
+	retired := client.known()
+
+	// the state shares the map of nodes of the client
+	client.nodes = nodes
+	client.replicas = make(map[string]*Conn)
+
+	next := &mapping{
+		id:     last.id + 1,
+		nodes:  nodes,
+		config: config,
+	}
+
+	next.slots.fill(0, 16383, primary)
+
+	client.state.Store(next)
+	client.retire(retired)
+}
+
+// Apply applies the options to the live client like Reload does.
+func (client *Client) Apply(options ...Option) {
+	client.load()
+
+	config := client.settings().config()
+	for _, option := range options {
+		option(config)
+	}
+
+	client.Reload(config)
+}
+
+// SetAddresses changes the addresses used to connect to the database or to discover the cluster.
+func (client *Client) SetAddresses(address ...string) {
+	client.Apply(WithAddress(address...))
+}
+
+// settings returns the connection settings of the current state, which aren't changed once published.
+func (client *Client) settings() *Client {
+	if state, ok := client.state.Load().(*mapping); ok && state.config != nil {
+		return state.config
+	}
+
+	return client
+}
+
+// publish stores a copy of the current state with the connection settings and must be called with the lock held.
+func (client *Client) publish(config *Client) {
+	next := *client.state.Load().(*mapping)
+	next.config = config
+	client.state.Store(&next)
+}
+
+// config returns a client holding the connection settings of the client.
+func (client *Client) config() *Client {
+	return &Client{
+		Address:                   client.Address,
+		MaximumConcurrentRequests: client.MaximumConcurrentRequests,
+		MaximumPendingRequests:    client.MaximumPendingRequests,
+		MaximumConnectionRetries:  client.MaximumConnectionRetries,
+		RetryTimeout:              client.RetryTimeout,
+		Breaker:                   client.Breaker,
+		FailFast:                  client.FailFast,
+		FailFastTimeout:           client.FailFastTimeout,
+		MaximumOfflineRequests:    client.MaximumOfflineRequests,
+		OfflineTimeout:            client.OfflineTimeout,
+		MaximumBatchSize:          client.MaximumBatchSize,
+		FlushInterval:             client.FlushInterval,
+		MaximumReplySize:          client.MaximumReplySize,
+		StrictProtocol:            client.StrictProtocol,
+		NoEvict:                   client.NoEvict,
+		NoTouch:                   client.NoTouch,
+		DialTimeout:               client.DialTimeout,
+		ReadTimeout:               client.ReadTimeout,
+		WriteTimeout:              client.WriteTimeout,
+		TLSConfig:                 client.TLSConfig,
+		Credentials:               client.Credentials,
+	}
+}
+
+// known returns every node and replica known by the client and must be called with the lock held.
+func (client *Client) known() (nodes []*Conn) {
+	nodes = make([]*Conn, 0, len(client.nodes)+len(client.replicas))
+	for _, item := range client.nodes {
+		nodes = append(nodes, item)
+	}
+
+	for _, item := range client.replicas {
+		nodes = append(nodes, item)
+	}
+
+	return
+}
+
+// rebuild replaces every connection of the cluster by a new one with the settings and retires the old ones.
+// It must be called with the lock held.
+func (client *Client) rebuild(config *Client) {
+	last := client.state.Load().(*mapping)
+	retired := client.known()
+
+	next := &mapping{
+		id:       last.id + 1,
+		shards:   true,
+		nodes:    make(map[string]*Conn),
+		replicas: make(map[string]*Conn),
+		ids:      last.ids,
+
+		followers: make(map[*Conn][]*Conn),
+		config:    config,
+	}
+
+	replaced := make(map[*Conn]*Conn)
+	for name, item := range last.nodes {
+		next.nodes[name] = client.dial(config, name)
+		replaced[item] = next.nodes[name]
+	}
+
+	for name, item := range last.replicas {
+		next.replicas[name] = client.dial(config, name)
+		next.replicas[name].readonly = true
+		replaced[item] = next.replicas[name]
+	}
+
+	for master, replicas := range last.followers {
+		for _, replica := range replicas {
+			next.followers[replaced[master]] = append(next.followers[replaced[master]], replaced[replica])
+		}
+	}
+
+	for i := 0; i < 16384; {
+		// ranges of slots served by the same node
+		node, j := last.slots.get(i), i+1
+		for j < 16384 && last.slots.get(j) == node {
+			j++
+		}
+
+		next.slots.fill(i, j-1, replaced[node])
+		i = j
+	}
+
+	client.nodes = make(map[string]*Conn)
+	client.replicas = make(map[string]*Conn)
+
+	for name, item := range next.nodes {
+		client.nodes[name] = item
+	}
+
+	for name, item := range next.replicas {
+		client.replicas[name] = item
+	}
+
+	// the new addresses may not be part of the cluster yet
+	for _, address := range config.Address {
+		if name := nodeName(address); client.nodes[name] == nil {
+			client.nodes[name] = client.dial(config, address)
+		}
+	}
+
+	client.state.Store(next)
+	client.retire(retired)
+}
+
+// retire closes the connections once the requests already sent to them are done.
+func (client *Client) retire(nodes []*Conn) {
+	delay := DefaultDrainDelay
+	go func() {
+		client.clock().Sleep(delay)
+
+		for _, item := range nodes {
+			item.Close()
+		}
+	}()
+}
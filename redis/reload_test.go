@@ -0,0 +1,107 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	client := &Client{
+		Address: []string{"tcp://127.0.0.1:7000"},
+	}
+
+	defer client.Close()
+
+	delay := DefaultDrainDelay
+	DefaultDrainDelay = time.Millisecond
+	defer func() {
+		DefaultDrainDelay = delay
+	}()
+
+	last := client.load().slots.get(0)
+
+	client.Apply(WithMaximumPendingRequests(8), WithAddress("tcp://127.0.0.1:7001"))
+
+	node := client.load().slots.get(0)
+	if node == last || node.address != "tcp://127.0.0.1:7001" || node.MaximumPendingRequests != 8 {
+		t.Fatal("expecting a new connection with the new settings")
+	}
+
+	// in a cluster, every node is replaced and keeps its slots
+	master, replica := client.connect("tcp://127.0.0.1:7002"), client.connect("tcp://127.0.0.1:7003")
+	state := &mapping{
+		shards:    true,
+		nodes:     map[string]*Conn{"tcp://127.0.0.1:7002": master},
+		replicas:  map[string]*Conn{"tcp://127.0.0.1:7003": replica},
+		followers: map[*Conn][]*Conn{master: []*Conn{replica}},
+		config:    client.load().config,
+	}
+
+	state.slots.fill(100, 200, master)
+	client.state.Store(state)
+
+	client.Reload(&Client{
+		RetryTimeout: time.Second,
+	})
+
+	next := client.load()
+	node = next.slots.get(150)
+	if node == master || node != next.nodes["tcp://127.0.0.1:7002"] || node.RetryTimeout != time.Second {
+		t.Fatal("expecting the master to be replaced")
+	}
+
+	if next.slots.get(0) != nil || next.slots.get(201) != nil {
+		t.Fatal("unexpected slots")
+	}
+
+	if replicas := next.followers[node]; len(replicas) != 1 || replicas[0] == replica || !replicas[0].readonly {
+		t.Fatal("expecting the replica to be replaced")
+	}
+
+	if client.nodes["tcp://127.0.0.1:7001"] == nil {
+		t.Fatal("expecting the addresses to be kept")
+	}
+
+	// the settings are published with the state instead of changing the fields read by the client
+	if client.RetryTimeout != 0 || client.Address[0] != "tcp://127.0.0.1:7000" || client.settings().RetryTimeout != time.Second {
+		t.Fatal("expecting the settings to be published")
+	}
+}
+
+func TestReloadConcurrent(t *testing.T) {
+	client := &Client{
+		Address: []string{"tcp://127.0.0.1:7000"},
+	}
+
+	defer client.Close()
+
+	delay := DefaultDrainDelay
+	DefaultDrainDelay = time.Millisecond
+	defer func() {
+		DefaultDrainDelay = delay
+	}()
+
+	client.load()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			client.Node(fmt.Sprintf("tcp://127.0.0.1:%d", 8000+i))
+		}
+
+		close(done)
+	}()
+
+	for i := 0; i < 10; i++ {
+		client.Apply(WithTimeouts(time.Second, time.Duration(i+1)*time.Second, time.Second))
+	}
+
+	<-done
+
+	if node := client.Node("tcp://127.0.0.1:9000"); node == nil {
+		t.Fatal("expecting a node")
+	}
+}
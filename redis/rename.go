@@ -0,0 +1,97 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+)
+
+// CopyKey copies the value of the key to another key, which is overwritten only when replace is set, and returns false when nothing was copied.
+// In a cluster, keys of different slots are copied with DUMP and RESTORE when CrossSlotFallback is set or fail with CrossSlotError otherwise.
+// COPY requires Redis 6.2.
+func (client *Client) CopyKey(src, dst string, replace bool) (ok bool, err error) {
+	fallback, err := client.fallback(src, dst)
+	if err != nil {
+		return
+	}
+
+	if fallback {
+		ok, err = client.transfer(src, dst, replace)
+		return
+	}
+
+	args := []interface{}{src, dst}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+
+	reply, err := client.Do("COPY", args...)
+	n, _ := reply.(int64)
+	ok = n == 1
+	return
+}
+
+// RenameKey renames the key, overwriting the other key, and fails when there is no key.
+// In a cluster, keys of different slots are moved with DUMP, RESTORE and DEL when CrossSlotFallback is set or fail with CrossSlotError otherwise.
+// The fallback isn't atomic: both keys exist for a moment and a failure may leave them both.
+func (client *Client) RenameKey(src, dst string) (err error) {
+	fallback, err := client.fallback(src, dst)
+	if err != nil {
+		return
+	}
+
+	if fallback {
+		ok, err := client.transfer(src, dst, true)
+		if err == nil && !ok {
+			err = ReplyError("ERR no such key")
+		}
+
+		if err == nil {
+			_, err = client.Do("DEL", src)
+		}
+
+		return err
+	}
+
+	err = expectOK(client.Do("RENAME", src, dst))
+	return
+}
+
+// fallback returns true when the keys must be moved with DUMP and RESTORE because they belong to different slots of the cluster.
+func (client *Client) fallback(src, dst string) (bool, error) {
+	if !client.load().shards || Slot(src) == Slot(dst) {
+		return false, nil
+	}
+
+	if !client.CrossSlotFallback {
+		return false, &CrossSlotError{
+			Keys:  []string{src, dst},
+			Slots: []int{Slot(src), Slot(dst)},
+		}
+	}
+
+	return true, nil
+}
+
+// transfer copies the key to the other key with DUMP and RESTORE and returns false when there is no key or the other key exists without replace.
+func (client *Client) transfer(src, dst string, replace bool) (ok bool, err error) {
+	data, ttl, ok, err := client.dump(src)
+	if err != nil || !ok {
+		return
+	}
+
+	args := []interface{}{dst, ttl, data}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+
+	if _, err = client.Do("RESTORE", args...); err != nil {
+		if e, busy := err.(ReplyError); busy && strings.HasPrefix(string(e), "BUSYKEY") {
+			err = nil
+		}
+
+		ok = false
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestRenameKey(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	node := client.nodes["tcp://127.0.0.1:6379"]
+	node.db = db
+
+	state := &mapping{
+		id:        1,
+		shards:    true,
+		nodes:     map[string]*Conn{node.address: node},
+		replicas:  make(map[string]*Conn),
+		ids:       make(map[string]string),
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	state.slots.fill(0, 16383, node)
+	client.state.Store(state)
+
+	if _, err := client.CopyKey("a", "b", false); err == nil {
+		t.Fatal("expecting a cross slot error")
+	} else if _, ok := err.(*CrossSlotError); !ok {
+		t.Fatal(err)
+	}
+
+	db.result.WriteString(":1\r\n+OK\r\n")
+	if ok, err := client.CopyKey("{a}1", "{a}2", true); err != nil || !ok {
+		t.Fatal(ok, err)
+	}
+
+	if err := client.RenameKey("{a}1", "{a}2"); err != nil {
+		t.Fatal(err)
+	}
+
+	WithCrossSlotFallback()(client)
+
+	db.result.WriteString("$4\r\ndata\r\n:-1\r\n+OK\r\n:1\r\n")
+	if err := client.RenameKey("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	db.result.WriteString("$4\r\ndata\r\n:100\r\n-BUSYKEY Target key name already exists.\r\n")
+	if ok, err := client.CopyKey("a", "b", false); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	db.result.WriteString("$-1\r\n:-2\r\n")
+	if err := client.RenameKey("a", "b"); err == nil {
+		t.Fatal("expecting no such key")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultReplicationAckInterval defines the default interval between the REPLCONF ACK sent to the master.
+var DefaultReplicationAckInterval = time.Second
+
+// ReplicationCommand defines a write command streamed by the master.
+// Offset is the replication offset once the command is applied.
+type ReplicationCommand struct {
+	Offset int64
+	Name   string
+	Args   [][]byte
+}
+
+// ReplicaReader connects to a master like a replica would with PSYNC and streams the write commands it propagates.
+// This allows capturing the changes made to a dataset e.g. to feed a cache or an index.
+type ReplicaReader struct {
+	Master *Conn
+
+	// ReplicationID and Offset are set as the stream goes and resume it with a partial resynchronization when set.
+	// A full resynchronization is requested when empty.
+	ReplicationID string
+	Offset        int64
+
+	// RDB is called with the snapshot sent by the master on a full resynchronization.
+	// The snapshot is skipped when not set and can be decoded with the rdb package.
+	RDB func(io.Reader) error
+
+	AckInterval time.Duration
+}
+
+// Run synchronizes with the master and sends every command it propagates to the channel until stop is closed.
+// PINGs of the master are consumed but SELECT commands are streamed to track the database of the next commands.
+// It returns the error that broke the stream and can be called again to resume.
+func (reader *ReplicaReader) Run(commands chan<- ReplicationCommand, stop <-chan struct{}) (err error) {
+	fd, err := reader.Master.connect()
+	if err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	stopped := int32(0)
+	go func() {
+		select {
+		case <-stop:
+			atomic.StoreInt32(&stopped, 1)
+		case <-done:
+		}
+
+		fd.Close()
+	}()
+
+	// a closed connection is expected when stopping
+	defer func() {
+		if atomic.LoadInt32(&stopped) != 0 {
+			err = nil
+		}
+	}()
+
+	encoder := NewEncoder(fd)
+	decoder := NewDecoder(fd)
+
+	if err = encoder.Encode("REPLCONF", "capa", "psync2"); err != nil {
+		return
+	}
+
+	// masters that don't know this capability reply with an error but still accept PSYNC
+	if _, err = decoder.Decode(); err != nil {
+		if _, ok := err.(ReplyError); !ok {
+			return
+		}
+	}
+
+	if err = reader.sync(encoder, decoder); err != nil {
+		return
+	}
+
+	interval := reader.AckInterval
+	if 0 == interval {
+		interval = DefaultReplicationAckInterval
+	}
+
+	var mu sync.Mutex
+	offset := reader.Offset
+
+	ack := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return encoder.Encode("REPLCONF", "ACK", atomic.LoadInt64(&offset))
+	}
+
+	// the master considers the replica gone without acknowledgements
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if ack() != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		var reply interface{}
+		if reply, err = decoder.Decode(); err != nil {
+			return
+		}
+
+		items, ok := reply.([]interface{})
+		if !ok || len(items) == 0 {
+			err = fmt.Errorf("unexpected replication stream '%v'", reply)
+			return
+		}
+
+		// the offset counts the bytes of each command like they were sent
+		n := int64(len(strconv.Itoa(len(items))) + 3)
+		cmd := ReplicationCommand{
+			Args: make([][]byte, 0, len(items)-1),
+		}
+
+		for i, item := range items {
+			arg, _ := item.([]byte)
+			n += int64(len(strconv.Itoa(len(arg))) + len(arg) + 5)
+
+			if i == 0 {
+				cmd.Name = strings.ToUpper(string(arg))
+			} else {
+				cmd.Args = append(cmd.Args, arg)
+			}
+		}
+
+		cmd.Offset = atomic.AddInt64(&offset, n)
+		reader.Offset = cmd.Offset
+
+		switch {
+		case cmd.Name == "PING":
+			continue
+		case cmd.Name == "REPLCONF":
+			if len(cmd.Args) != 0 && strings.ToUpper(string(cmd.Args[0])) == "GETACK" {
+				if err = ack(); err != nil {
+					return
+				}
+			}
+
+			continue
+		}
+
+		select {
+		case commands <- cmd:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// sync sends PSYNC and reads the snapshot of a full resynchronization.
+func (reader *ReplicaReader) sync(encoder *Encoder, decoder *Decoder) (err error) {
+	id, offset := "?", int64(-1)
+	if reader.ReplicationID != "" {
+		id, offset = reader.ReplicationID, reader.Offset+1
+	}
+
+	if err = encoder.Encode("PSYNC", id, offset); err != nil {
+		return
+	}
+
+	reply, err := decoder.Decode()
+	if err != nil {
+		return
+	}
+
+	status, _ := reply.(string)
+	fields := strings.Fields(status)
+
+	switch {
+	case len(fields) != 0 && fields[0] == "CONTINUE":
+		// the replication ID changes after a failover
+		if len(fields) > 1 {
+			reader.ReplicationID = fields[1]
+		}
+
+		return
+	case len(fields) == 3 && fields[0] == "FULLRESYNC":
+		reader.ReplicationID = fields[1]
+		if reader.Offset, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+			return
+		}
+	default:
+		err = fmt.Errorf("unexpected PSYNC reply '%v'", reply)
+		return
+	}
+
+	// the master sends newlines while the snapshot is being prepared
+	var line []byte
+	for {
+		if line, err = decoder.reader.ReadBytes('\n'); err != nil {
+			return
+		}
+
+		if line = bytes.TrimRight(line, "\r\n"); len(line) != 0 {
+			break
+		}
+	}
+
+	if line[0] != '$' {
+		err = fmt.Errorf("unexpected snapshot header '%s'", line)
+		return
+	}
+
+	size, err := strconv.ParseInt(string(line[1:]), 10, 64)
+	if err != nil {
+		return
+	}
+
+	snapshot := io.LimitReader(decoder.reader, size)
+	if reader.RDB != nil {
+		if err = reader.RDB(snapshot); err != nil {
+			return
+		}
+	}
+
+	// the snapshot isn't followed by a terminator
+	_, err = io.Copy(ioutil.Discard, snapshot)
+	return
+}
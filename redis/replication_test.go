@@ -0,0 +1,65 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestReplicaReader(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	reader := &ReplicaReader{
+		Master: &Conn{
+			db: dialerFunc(func() (net.Conn, error) {
+				return client, nil
+			}),
+		},
+	}
+
+	go func() {
+		decoder := NewDecoder(server)
+		decoder.Decode()
+		server.Write([]byte("+OK\r\n"))
+
+		if cmd, _ := decoder.Decode(); len(cmd.([]interface{})) != 3 {
+			t.Error(cmd)
+		}
+
+		server.Write([]byte("+FULLRESYNC abc 100\r\n\n\n$5\r\nREDIS"))
+		server.Write([]byte("*1\r\n$4\r\nPING\r\n*3\r\n$3\r\nSET\r\n$1\r\na\r\n$1\r\n1\r\n"))
+		io.Copy(ioutil.Discard, server)
+	}()
+
+	snapshot := ""
+	reader.RDB = func(r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		snapshot = string(data)
+		return err
+	}
+
+	commands := make(chan ReplicationCommand)
+	stop := make(chan struct{})
+	errs := make(chan error)
+	go func() {
+		errs <- reader.Run(commands, stop)
+	}()
+
+	cmd := <-commands
+	if cmd.Name != "SET" || len(cmd.Args) != 2 || string(cmd.Args[1]) != "1" || cmd.Offset != 100+14+27 {
+		t.Fatal(cmd)
+	}
+
+	if snapshot != "REDIS" || reader.ReplicationID != "abc" {
+		t.Fatal(snapshot, reader.ReplicationID)
+	}
+
+	close(stop)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+}
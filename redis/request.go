@@ -3,8 +3,11 @@
 package redis
 
 import (
+	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 )
 
 type command struct {
@@ -12,6 +15,8 @@ type command struct {
 	args   []interface{}
 	err    error
 	result interface{}
+	buffer []byte
+	stream bool
 }
 
 // Request defines a set of Redis commands that must be executed in sequence.
@@ -23,7 +28,22 @@ type Request struct {
 	moved    bool
 	redirect bool
 	address  string
+	node     string
 	done     chan struct{}
+	callback func()
+
+	idempotent bool
+	readonly   bool
+	privileged bool
+	priority   Priority
+	budget     *Budget
+	deadline   time.Time
+
+	// routed is the topology used for the last attempt and stale is set when it changed for the slot before the reply.
+	routed *mapping
+	stale  bool
+
+	annotations map[string]string
 }
 
 // NewRequest creates a new request that holds the specified command.
@@ -38,6 +58,62 @@ func NewRequest(name string, args ...interface{}) *Request {
 	}
 }
 
+// requests caches the requests used by Do.
+var requests = sync.Pool{
+	New: func() interface{} {
+		return new(Request)
+	},
+}
+
+// newRequest returns a cached request holding the specified command.
+func newRequest(name string, args []interface{}) (request *Request) {
+	request = requests.Get().(*Request)
+	request.Add(name, args...)
+	return
+}
+
+// release puts back the request in the cache once it is done.
+func release(request *Request) {
+	request.Reset()
+	requests.Put(request)
+}
+
+// Reset removes all commands and replies from the request so that it can be reused.
+// A request must not be reset or reused before it is done, i.e. before Send has returned.
+func (request *Request) Reset() {
+	// drop references to arguments and replies
+	for i := range request.commands {
+		request.commands[i] = command{}
+	}
+
+	*request = Request{
+		commands: request.commands[:0],
+	}
+}
+
+// Annotate attaches metadata to the request like the caller or a trace ID.
+// Annotations aren't sent to Redis but are available to hooks like the router, the retry policy and the slow command log.
+func (request *Request) Annotate(key, value string) {
+	annotations := make(map[string]string, len(request.annotations)+1)
+	for k, v := range request.annotations {
+		annotations[k] = v
+	}
+
+	annotations[key] = value
+	request.annotations = annotations
+}
+
+// Annotation returns the value of the annotation or an empty string when there is none.
+func (request *Request) Annotation(key string) string {
+	return request.annotations[key]
+}
+
+// Annotations returns the annotations of the request.
+// The map must not be modified.
+func (request *Request) Annotations() map[string]string {
+	return request.annotations
+}
+
 // Len returns the number of commands in the request.
 func (request *Request) Len() int {
 	return len(request.commands)
@@ -51,9 +127,42 @@ func (request *Request) Add(name string, args ...interface{}) {
 	})
 }
 
-func (request *Request) encode(encoder *Encoder) (err error) {
+// clone returns a new request holding the same commands.
+func (request *Request) clone() *Request {
+	result := &Request{
+		commands: make([]command, len(request.commands)),
+		key:      request.key,
+		hash:     request.hash,
+		node:     request.node,
+
+		idempotent:  request.idempotent,
+		readonly:    request.readonly,
+		privileged:  request.privileged,
+		priority:    request.priority,
+		budget:      request.budget,
+		deadline:    request.deadline,
+		annotations: request.annotations,
+	}
+
+	for i := range request.commands {
+		result.commands[i] = command{
+			name: request.commands[i].name,
+			args: request.commands[i].args,
+		}
+	}
+
+	return result
+}
+
+// encode writes the commands of the request with the timeouts of blocking commands lowered to the time left at now.
+func (request *Request) encode(encoder *Encoder, now time.Time) (err error) {
 	for i := range request.commands {
-		err = request.commands[i].encode(encoder)
+		if cmd := &request.commands[i]; !request.deadline.IsZero() && cmd.blocking() {
+			err = encoder.Buffer(cmd.name, cmd.clamp(request.deadline, now)...)
+		} else {
+			err = cmd.encode(encoder)
+		}
+
 		if err != nil {
 			break
 		}
@@ -63,14 +172,35 @@ func (request *Request) encode(encoder *Encoder) (err error) {
 	return
 }
 
+// replies returns the number of commands that got a reply, even an error.
+func (request *Request) replies() (n int) {
+	for i := range request.commands {
+		if _, ok := request.commands[i].err.(ReplyError); ok || request.commands[i].err == nil {
+			n++
+		}
+	}
+
+	return
+}
+
+// encode buffers the command and relies on the connection to flush it.
 func (cmd *command) encode(encoder *Encoder) error {
-	return encoder.Encode(cmd.name, cmd.args...)
+	return encoder.Buffer(cmd.name, cmd.args...)
 }
 
 func (request *Request) decode(decoder *Decoder) (err error) {
+	// always read every reply to keep the stream in sync but only report the first error
 	for i := range request.commands {
-		err = request.commands[i].decode(decoder)
-		if err != nil {
+		if e := request.commands[i].decode(decoder); e != nil && err == nil {
+			err = e
+		}
+
+		// the stream is lost after a reply that is too large
+		if decoder.truncated {
+			for j := i + 1; j < len(request.commands); j++ {
+				request.commands[j].err = request.commands[i].err
+			}
+
 			break
 		}
 	}
@@ -94,7 +224,12 @@ func (request *Request) decode(decoder *Decoder) (err error) {
 }
 
 func (cmd *command) decode(decoder *Decoder) error {
-	cmd.result, cmd.err = decoder.Decode()
+	if cmd.stream {
+		cmd.result, cmd.err = decoder.stream()
+	} else {
+		cmd.result, cmd.err = decoder.DecodeBuffer(cmd.buffer)
+	}
+
 	return cmd.err
 }
 
@@ -108,24 +243,30 @@ func (request *Request) Send(s Sender) error {
 	return s.Send(request)
 }
 
+// Key returns the first key of the i-th command or an empty string when it has none.
 func (request *Request) Key(i int) string {
 	c := &request.commands[i]
 
-	if c.name == "EVALSHA" {
-		r, ok := c.args[2].(string)
-		if !ok {
-			log.Fatalln("expecting string", c.args)
-		}
+	keys := c.keys()
+	if len(keys) == 0 {
+		return ""
+	}
 
+	switch r := c.args[keys[0]].(type) {
+	case string:
 		return r
+	case []byte:
+		return string(r)
 	}
 
-	r, ok := c.args[0].(string)
-	if !ok {
-		log.Fatalln("expecting string", c.args[0])
-	}
+	log.Fatalln("expecting string", c)
+	return ""
+}
 
-	return r
+// Buffer sets the slice where the bulk string reply of the i-th command is stored when large enough instead of allocating a new one.
+// The buffer must not be used until the request is done.
+func (request *Request) Buffer(i int, buffer []byte) {
+	request.commands[i].buffer = buffer
 }
 
 func (request *Request) Args(i int) []interface{} {
@@ -138,9 +279,50 @@ func (request *Request) Result(i int) (interface{}, error) {
 	return r.result, r.err
 }
 
+// route sets the key used to find the slot of the request.
+func (request *Request) route(key string) {
+	request.key = []byte(key)
+	request.hash = slot(request.key)
+}
+
+// ForceSlot sends the request to the node serving the slot instead of the one of its first key.
+// Sending the request fails when the slot isn't between 0 and 16383.
+func (request *Request) ForceSlot(n int) {
+	request.key = []byte{}
+	request.hash = n
+}
+
+// checkSlot returns an error when the slot forced on the request doesn't exist.
+func (request *Request) checkSlot() (err error) {
+	if request.hash < 0 || request.hash >= 16384 {
+		err = fmt.Errorf("invalid slot %d", request.hash)
+	}
+
+	return
+}
+
+// ForceNode sends the request to the node at the specified address e.g. tcp://127.0.0.1:6379.
+// This is useful for commands that target a specific node or that have no key.
+func (request *Request) ForceNode(address string) {
+	request.node = address
+}
+
 func (request *Request) slot() int {
 	if request.key == nil {
-		request.key = []byte(request.Key(0))
+		cmd := &request.commands[0]
+
+		switch {
+		case len(cmd.keys()) != 0:
+			request.key = []byte(request.Key(0))
+		case !cmd.known() && len(cmd.args) != 0:
+			// unknown commands e.g. of modules usually take a key first and MOVED corrects the guess otherwise
+			request.key = []byte(argString(cmd.args[0]))
+		default:
+			// commands without keys go to slot 0
+			request.key = []byte{}
+			return 0
+		}
+
 		request.hash = slot(request.key)
 	}
 
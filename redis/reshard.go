@@ -0,0 +1,162 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultReshardBatchSize defines the default number of keys moved by each MIGRATE during a reshard.
+var DefaultReshardBatchSize = 100
+
+// DefaultReshardTimeout defines the default timeout of each MIGRATE during a reshard.
+var DefaultReshardTimeout = 5 * time.Second
+
+// ErrReshardAborted is returned by Reshard when the operation was aborted.
+var ErrReshardAborted = errors.New("reshard aborted")
+
+// ReshardOptions defines the optional parameters of Reshard.
+type ReshardOptions struct {
+	BatchSize int
+	Timeout   time.Duration
+
+	// Progress is called after each batch of keys is moved and when a slot is done.
+	Progress func(ReshardProgress)
+
+	// Abort stops the operation once closed.
+	// The slot being moved is always completed to avoid leaving the cluster in a migrating state.
+	Abort <-chan struct{}
+}
+
+// ReshardProgress defines the state of a reshard.
+type ReshardProgress struct {
+	// Slot is being moved and Keys is the number of its keys moved so far.
+	Slot int
+	Keys int
+
+	// Done is set when the slot is now served by the destination.
+	Done bool
+
+	// Completed is the number of slots moved out of Total.
+	Completed int
+	Total     int
+}
+
+// Reshard moves the slots from one node to another, specified by their IDs, using CLUSTER SETSLOT and MIGRATE.
+// When an error occurs, the slot being moved is left in the migrating state so that the cluster keeps serving its keys.
+func (client *Client) Reshard(from, to string, slots []int, options ReshardOptions) (err error) {
+	for _, slot := range slots {
+		if slot < 0 || slot >= 16384 {
+			err = fmt.Errorf("invalid slot %d", slot)
+			return
+		}
+	}
+
+	_, src, err := client.node(from)
+	if err != nil {
+		return
+	}
+
+	name, dst, err := client.node(to)
+	if err != nil {
+		return
+	}
+
+	// MIGRATE needs the network address of the destination
+	u, err := url.Parse(name)
+	if err != nil {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		return
+	}
+
+	size := options.BatchSize
+	if size == 0 {
+		size = DefaultReshardBatchSize
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultReshardTimeout
+	}
+
+	progress := func(p ReshardProgress) {
+		if options.Progress != nil {
+			options.Progress(p)
+		}
+	}
+
+	for i, slot := range slots {
+		select {
+		case <-options.Abort:
+			err = ErrReshardAborted
+			return
+		default:
+		}
+
+		state := ReshardProgress{
+			Slot:      slot,
+			Completed: i,
+			Total:     len(slots),
+		}
+
+		if err = expectOK(dst.Do("CLUSTER", "SETSLOT", slot, string(SlotImporting), from)); err != nil {
+			return
+		}
+
+		if err = expectOK(src.Do("CLUSTER", "SETSLOT", slot, string(SlotMigrating), to)); err != nil {
+			return
+		}
+
+		for {
+			var reply interface{}
+			reply, err = src.Do("CLUSTER", "GETKEYSINSLOT", slot, size)
+			if err != nil {
+				return
+			}
+
+			keys, _ := reply.([]interface{})
+			if len(keys) == 0 {
+				break
+			}
+
+			args := []interface{}{host, port, "", 0, int64(timeout / time.Millisecond), "KEYS"}
+			args = append(args, keys...)
+
+			if reply, err = src.Do("MIGRATE", args...); err != nil {
+				return
+			}
+
+			// NOKEY is returned when keys expired in the meantime
+			if reply != OK && reply != "NOKEY" {
+				err = fmt.Errorf("unexpected MIGRATE reply '%v'", reply)
+				return
+			}
+
+			state.Keys += len(keys)
+			progress(state)
+		}
+
+		// assign the slot to the destination first so that it can't be lost
+		if err = expectOK(dst.Do("CLUSTER", "SETSLOT", slot, string(SlotNode), to)); err != nil {
+			return
+		}
+
+		if err = expectOK(src.Do("CLUSTER", "SETSLOT", slot, string(SlotNode), to)); err != nil {
+			return
+		}
+
+		state.Done = true
+		state.Completed++
+		progress(state)
+	}
+
+	return
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// reshardClient returns a client of a cluster where the node with ID a serves every slot and the node with ID b none.
+func reshardClient() (client *Client, a, b *mockDB) {
+	a, b = new(mockDB), new(mockDB)
+
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a, address: "tcp://127.0.0.1:7000"},
+			"tcp://127.0.0.1:7001": {db: b, address: "tcp://127.0.0.1:7001"},
+		},
+		ids: map[string]string{
+			"a": "tcp://127.0.0.1:7000",
+			"b": "tcp://127.0.0.1:7001",
+		},
+	}
+
+	state.slots.fill(0, 16383, state.nodes["tcp://127.0.0.1:7000"])
+
+	client = &Client{
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	return
+}
+
+func TestReshard(t *testing.T) {
+	client, a, b := reshardClient()
+	defer client.Close()
+
+	// the source migrates the keys of the slot in batches until it has none left
+	a.result.WriteString("+OK\r\n")
+	a.result.WriteString("*2\r\n$1\r\nx\r\n$1\r\ny\r\n+OK\r\n")
+	a.result.WriteString("*1\r\n$1\r\nz\r\n+NOKEY\r\n")
+	a.result.WriteString("*0\r\n+OK\r\n")
+	b.result.WriteString("+OK\r\n+OK\r\n")
+
+	var progress []ReshardProgress
+	err := client.Reshard("a", "b", []int{5}, ReshardOptions{
+		BatchSize: 2,
+		Progress: func(p ReshardProgress) {
+			progress = append(progress, p)
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReshardProgress{
+		{Slot: 5, Keys: 2, Total: 1},
+		{Slot: 5, Keys: 3, Total: 1},
+		{Slot: 5, Keys: 3, Done: true, Completed: 1, Total: 1},
+	}
+
+	if !reflect.DeepEqual(progress, expected) {
+		t.Fatal(progress)
+	}
+
+	if n, m := atomic.LoadInt32(&a.writes), atomic.LoadInt32(&b.writes); n != 7 || m != 2 {
+		t.Fatal(n, m)
+	}
+}
+
+func TestReshardFailure(t *testing.T) {
+	client, a, b := reshardClient()
+	defer client.Close()
+
+	if err := client.Reshard("a", "b", []int{16384}, ReshardOptions{}); err == nil {
+		t.Fatal("expecting an invalid slot")
+	}
+
+	// a failed MIGRATE leaves the slot migrating so that its keys are still served
+	a.result.WriteString("+OK\r\n*1\r\n$1\r\nx\r\n-IOERR error or timeout writing to target instance\r\n")
+	b.result.WriteString("+OK\r\n")
+
+	if err := client.Reshard("a", "b", []int{5}, ReshardOptions{}); err == nil {
+		t.Fatal("expecting the error of MIGRATE")
+	}
+
+	if n, m := atomic.LoadInt32(&a.writes), atomic.LoadInt32(&b.writes); n != 3 || m != 1 {
+		t.Fatal(n, m)
+	}
+}
+
+func TestReshardAbort(t *testing.T) {
+	client, a, b := reshardClient()
+	defer client.Close()
+
+	abort := make(chan struct{})
+	close(abort)
+
+	if err := client.Reshard("a", "b", []int{5, 6}, ReshardOptions{Abort: abort}); err != ErrReshardAborted {
+		t.Fatal(err)
+	}
+
+	if n, m := atomic.LoadInt32(&a.writes), atomic.LoadInt32(&b.writes); n != 0 || m != 0 {
+		t.Fatal(n, m)
+	}
+}
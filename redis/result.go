@@ -0,0 +1,124 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ScanResult stores the replies of the commands of the request in the destinations like the Scan of database/sql.
+// The items of an array reply fill as many consecutive destinations.
+// Destinations are pointers to []byte, string, int, int64, uint64, float64, bool or interface{} and nil replies store their zero value.
+// Bulk strings are copied in the memory already held by a *[]byte, which can also be passed to Buffer before sending the request to read the reply right into it.
+func (request *Request) ScanResult(dest ...interface{}) (err error) {
+	n := 0
+	for i := range request.commands {
+		c := &request.commands[i]
+		if c.err != nil {
+			return c.err
+		}
+
+		items, ok := c.result.([]interface{})
+		if !ok {
+			items = []interface{}{c.result}
+		}
+
+		for _, item := range items {
+			if n == len(dest) {
+				return fmt.Errorf("more replies than the %d destinations", len(dest))
+			}
+
+			if err = scanReply(item, dest[n]); err != nil {
+				return fmt.Errorf("reply %d: %v", n, err)
+			}
+
+			n++
+		}
+	}
+
+	if n != len(dest) {
+		err = fmt.Errorf("%d replies for %d destinations", n, len(dest))
+	}
+
+	return
+}
+
+// scanReply stores the reply in the destination.
+func scanReply(reply, dest interface{}) (err error) {
+	if reply == OK {
+		reply = "OK"
+	}
+
+	var text []byte
+	switch r := reply.(type) {
+	case []byte:
+		text = r
+	case string:
+		text = []byte(r)
+	case int64, nil:
+	default:
+		// nested arrays only fit in an interface{}
+		if _, ok := dest.(*interface{}); !ok {
+			return fmt.Errorf("cannot store a reply of type %T in %T", reply, dest)
+		}
+	}
+
+	switch d := dest.(type) {
+	case *interface{}:
+		*d = reply
+	case *[]byte:
+		if reply == nil {
+			*d = (*d)[:0]
+		} else if text != nil {
+			*d = append((*d)[:0], text...)
+		} else {
+			*d = strconv.AppendInt((*d)[:0], reply.(int64), 10)
+		}
+	case *string:
+		if r, ok := reply.(int64); ok {
+			*d = strconv.FormatInt(r, 10)
+		} else {
+			*d = string(text)
+		}
+	case *int64:
+		*d, err = scanInt(reply, text)
+	case *int:
+		var k int64
+		k, err = scanInt(reply, text)
+		*d = int(k)
+	case *uint64:
+		if r, ok := reply.(int64); ok {
+			*d = uint64(r)
+		} else if *d = 0; text != nil {
+			*d, err = strconv.ParseUint(string(text), 10, 64)
+		}
+	case *float64:
+		if r, ok := reply.(int64); ok {
+			*d = float64(r)
+		} else if *d = 0; text != nil {
+			*d, err = strconv.ParseFloat(string(text), 64)
+		}
+	case *bool:
+		var k int64
+		k, err = scanInt(reply, text)
+		*d = k != 0
+	default:
+		err = fmt.Errorf("unsupported destination %T", dest)
+	}
+
+	return
+}
+
+// scanInt returns the integer of an integer or bulk string reply.
+func scanInt(reply interface{}, text []byte) (int64, error) {
+	if r, ok := reply.(int64); ok {
+		return r, nil
+	}
+
+	if text == nil {
+		return 0, nil
+	}
+
+	return parseInteger(text)
+}
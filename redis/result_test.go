@@ -0,0 +1,74 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestScanResult(t *testing.T) {
+	request := NewRequest("GET", "a")
+	request.Add("MGET", "b", "c", "d")
+	request.Add("INCR", "e")
+	request.Add("SET", "f", "g")
+
+	request.commands[0].result = []byte("hello")
+	request.commands[1].result = []interface{}{[]byte("42"), []byte("1.5"), nil}
+	request.commands[2].result = int64(7)
+	request.commands[3].result = OK
+
+	buffer := make([]byte, 0, 16)
+	var (
+		n       int
+		f       float64
+		missing string
+		k       int64
+		status  string
+	)
+
+	if err := request.ScanResult(&buffer, &n, &f, &missing, &k, &status); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buffer) != "hello" || cap(buffer) != 16 || n != 42 || f != 1.5 || missing != "" || k != 7 || status != "OK" {
+		t.Fatal(string(buffer), n, f, missing, k, status)
+	}
+
+	if err := request.ScanResult(&buffer); err == nil {
+		t.Fatal("expecting too many replies")
+	}
+
+	var b bool
+	if err := request.ScanResult(&b, &n, &f, &missing, &k, &status); err == nil {
+		t.Fatal("expecting an invalid integer")
+	}
+
+	request.commands[2].err = ReplyError("ERR value is not an integer or out of range")
+	if err := request.ScanResult(&buffer, &n, &f, &missing, &k, &status); err != request.commands[2].err {
+		t.Fatal(err)
+	}
+}
+
+func TestScanResultTypes(t *testing.T) {
+	request := NewRequest("GET", "a")
+	request.commands[0].result = []byte("18446744073709551615")
+
+	var u uint64
+	if err := request.ScanResult(&u); err != nil || u != 18446744073709551615 {
+		t.Fatal(u, err)
+	}
+
+	// an item of XRANGE is itself an array
+	request = NewRequest("XRANGE", "s", "-", "+")
+	request.commands[0].result = []interface{}{[]interface{}{[]byte("1-0"), []interface{}{[]byte("a"), []byte("1")}}}
+
+	var buffer []byte
+	if err := request.ScanResult(&buffer); err == nil {
+		t.Fatal("expecting an unsupported reply")
+	}
+
+	var item interface{}
+	if err := request.ScanResult(&item); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultRetryDelay defines the default delay multiplicatively increased between the retries of a failed request.
+var DefaultRetryDelay = 10 * time.Millisecond
+
+// RetryPolicy decides whether a request that failed is sent again.
+type RetryPolicy interface {
+	// Retry is called when the attempt-th attempt, starting at 1, failed with the error.
+	// It returns whether the request should be sent again and the delay to wait before doing so.
+	Retry(request *Request, attempt int, err error) (delay time.Duration, ok bool)
+}
+
+// RetryPolicyFunc implements a retry policy with a function.
+type RetryPolicyFunc func(request *Request, attempt int, err error) (time.Duration, bool)
+
+// Retry calls the function.
+func (f RetryPolicyFunc) Retry(request *Request, attempt int, err error) (time.Duration, bool) {
+	return f(request, attempt, err)
+}
+
+// Backoff implements the default retry policy.
+// Redirections of the cluster and requests refused by a closed connection are sent again right away to the node now serving the slot as long as there were less than MaximumRedirections attempts.
+// Other failures are retried up to MaximumRetries times with exponential backoff but only for idempotent requests.
+// Errors replied by Redis are never retried.
+type Backoff struct {
+	MaximumRedirections int
+	MaximumRetries      int
+	Delay               time.Duration
+
+	// Jitter randomly shortens each delay by up to this fraction of it with Rand, which defaults to the source of math/rand.
+	Jitter float64
+	Rand   Rand
+}
+
+// Retry implements the policy.
+func (b *Backoff) Retry(request *Request, attempt int, err error) (delay time.Duration, ok bool) {
+	if request.redirect || err == ErrClosed {
+		max := b.MaximumRedirections
+		if 0 == max {
+			max = DefaultMaximumRedirections
+		}
+
+		ok = attempt < max
+		return
+	}
+
+	if _, replied := err.(ReplyError); replied || err == ErrCircuitOpen || err == ErrOverloaded || err == ErrDeadlineExceeded {
+		return
+	}
+
+	if attempt > b.MaximumRetries || !request.Idempotent() {
+		return
+	}
+
+	delay = b.Delay
+	if 0 == delay {
+		delay = DefaultRetryDelay
+	}
+
+	delay, ok = delay<<uint(attempt-1), true
+	if b.Jitter > 0 {
+		r := b.Rand
+		if r == nil {
+			r = globalRand{}
+		}
+
+		delay -= time.Duration(r.Float64() * b.Jitter * float64(delay))
+	}
+
+	return
+}
+
+// MarkIdempotent allows the request to be retried after a failure even if it modifies the database.
+func (request *Request) MarkIdempotent() {
+	request.idempotent = true
+}
+
+// Idempotent returns true when the request was marked as idempotent or only reads from the database.
+func (request *Request) Idempotent() bool {
+	return request.idempotent || request.ReadOnly()
+}
+
+// MarkReadOnly declares that the request doesn't modify the database, e.g. for a script or a module command.
+// Read-only requests may be served by replicas, hedged and retried after any failure.
+func (request *Request) MarkReadOnly() {
+	request.readonly = true
+}
+
+// ReadOnly returns true when the request was marked as read-only or when all its commands are known to only read from the database.
+func (request *Request) ReadOnly() bool {
+	if request.readonly {
+		return true
+	}
+
+	for i := range request.commands {
+		if !readCommands[strings.ToUpper(request.commands[i].name)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (client *Client) retryPolicy(request *Request) RetryPolicy {
+	if policy, ok := client.RetryPolicies[strings.ToUpper(request.commands[0].name)]; ok {
+		return policy
+	}
+
+	if client.RetryPolicy != nil {
+		return client.RetryPolicy
+	}
+
+	return &Backoff{
+		MaximumRedirections: client.MaximumRedirections,
+	}
+}
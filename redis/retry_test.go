@@ -0,0 +1,110 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := &Backoff{
+		MaximumRedirections: 2,
+		MaximumRetries:      2,
+		Delay:               time.Millisecond,
+	}
+
+	failure := fmt.Errorf("failure")
+
+	request := NewRequest("GET", "foo")
+	if delay, ok := policy.Retry(request, 1, failure); !ok || delay != time.Millisecond {
+		t.Fatal(delay, ok)
+	}
+
+	if delay, ok := policy.Retry(request, 2, failure); !ok || delay != 2*time.Millisecond {
+		t.Fatal(delay, ok)
+	}
+
+	if _, ok := policy.Retry(request, 3, failure); ok {
+		t.Fatal("too many retries")
+	}
+
+	if _, ok := policy.Retry(request, 1, ReplyError("ERR wrong")); ok {
+		t.Fatal("errors replied by Redis shouldn't be retried")
+	}
+
+	request = NewRequest("INCR", "foo")
+	if _, ok := policy.Retry(request, 1, failure); ok {
+		t.Fatal("writes shouldn't be retried")
+	}
+
+	request.MarkIdempotent()
+	if _, ok := policy.Retry(request, 1, failure); !ok {
+		t.Fatal("idempotent writes should be retried")
+	}
+
+	request.redirect = true
+	if _, ok := policy.Retry(request, 2, failure); ok {
+		t.Fatal("too many redirections")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	request := NewRequest("GET", "foo")
+	request.Add("ZRANGE", "bar", 0, -1)
+	if !request.ReadOnly() || !request.Idempotent() {
+		t.Fatal("reads should be read-only")
+	}
+
+	request = NewRequest("EVALSHA", "abc", 1, "foo")
+	if request.ReadOnly() {
+		t.Fatal("scripts may write")
+	}
+
+	request.MarkReadOnly()
+	if !request.ReadOnly() || !request.Idempotent() || !request.clone().ReadOnly() {
+		t.Fatal("marked requests should be read-only")
+	}
+}
+
+func TestRetryClosedNode(t *testing.T) {
+	a, b := new(mockDB), new(mockDB)
+	b.result.WriteString("+OK\r\n")
+
+	old := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+		},
+	}
+
+	old.slots.fill(0, 16383, old.nodes["tcp://127.0.0.1:7000"])
+
+	next := &mapping{
+		id:     1,
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	next.slots.fill(0, 16383, next.nodes["tcp://127.0.0.1:7001"])
+
+	client := &Client{
+		nodes: next.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(next)
+	defer client.Close()
+
+	// the node left the cluster and was closed while the request was routed with the old mapping
+	node := old.nodes["tcp://127.0.0.1:7000"]
+	node.Close()
+
+	request := NewRequest("SET", "a", 1)
+	if err := client.send(old, Slot("a"), KeylessDefault, node, request); err != nil {
+		t.Fatal(err)
+	}
+}
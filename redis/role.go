@@ -0,0 +1,61 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"log"
+)
+
+// Role returns the role of the node reported by ROLE i.e. master, slave or sentinel.
+func (conn *Conn) Role() (role string, err error) {
+	reply, err := conn.Do("ROLE")
+	if err != nil {
+		return
+	}
+
+	items, _ := reply.([]interface{})
+	if len(items) == 0 {
+		err = fmt.Errorf("unexpected ROLE reply '%v'", reply)
+		return
+	}
+
+	name, ok := items[0].([]byte)
+	if !ok {
+		err = fmt.Errorf("unexpected ROLE reply '%v'", reply)
+		return
+	}
+
+	role = string(name)
+	return
+}
+
+// verifyRoles checks the role of every master of the mapping.
+func (client *Client) verifyRoles(state *mapping) {
+	for _, node := range state.nodes {
+		go client.verify(node)
+	}
+}
+
+// verify checks that a master of the current mapping is still a master and refreshes the mapping from the other nodes otherwise.
+// This catches a master demoted by a failover before requests are redirected by the new master.
+func (client *Client) verify(node *Conn) {
+	state := client.state.Load().(*mapping)
+	if !state.shards || state.closed || state.nodes[node.address] != node {
+		return
+	}
+
+	// the connection of the node may be closed while checking and the check gives up like a probe
+	conn := separate(node, DefaultProbeInterval)
+	role, err := conn.Role()
+	conn.Close()
+
+	if err != nil || role == "master" {
+		return
+	}
+
+	log.Println("node", node.address, "is a", role, "instead of a master")
+
+	// the mapping may have just been refreshed from a node that didn't notice yet so failover waits out the throttle
+	client.failover(state, node)
+}
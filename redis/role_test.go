@@ -0,0 +1,55 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyRoles(t *testing.T) {
+	const slave = "*5\r\n$5\r\nslave\r\n$9\r\n127.0.0.2\r\n:6379\r\n$9\r\nconnected\r\n:100\r\n"
+
+	db := new(mockDB)
+	db.result.WriteString(slave)
+
+	if role, err := (&Conn{db: db}).Role(); err != nil || role != "slave" {
+		t.Fatal(role, err)
+	}
+
+	// the roles aren't verified on connect since the mocks share their replies
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	a, b := new(mockDB), new(mockDB)
+	master := client.nodes["tcp://127.0.0.1:6379"]
+	master.db = a
+	other := client.Node("tcp://127.0.0.2:6379")
+	other.db = b
+
+	state := &mapping{
+		id:        1,
+		shards:    true,
+		nodes:     map[string]*Conn{master.address: master, other.address: other},
+		replicas:  make(map[string]*Conn),
+		ids:       make(map[string]string),
+		followers: make(map[*Conn][]*Conn),
+	}
+
+	state.slots.fill(0, 16383, master)
+	client.state.Store(state)
+
+	a.result.WriteString(slave)
+	b.result.WriteString("*1\r\n*3\r\n:0\r\n:16383\r\n*2\r\n$9\r\n127.0.0.2\r\n:6379\r\n")
+
+	client.verify(master)
+	for i := 0; client.load().slots.get(0) != other; i++ {
+		if i == 1000 {
+			t.Fatal("expecting the mapping to be refreshed")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+// Router is implemented to select the node where a request is sent.
+// Returning nil falls back to the default routing of the client.
+// Redirections replied by the nodes are still followed by the client.
+type Router interface {
+	Route(*Request) *Conn
+}
+
+// RouterFunc adapts a function to implement a Router.
+type RouterFunc func(*Request) *Conn
+
+// Route calls the function.
+func (f RouterFunc) Route(request *Request) *Conn {
+	return f(request)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "testing"
+
+func TestRouter(t *testing.T) {
+	db := new(mockDB)
+	node := &Conn{db: db}
+	defer node.Close()
+
+	client := &Client{
+		Router: RouterFunc(func(request *Request) *Conn {
+			if request.commands[0].name == "PING" {
+				return node
+			}
+
+			return nil
+		}),
+	}
+
+	defer client.Close()
+
+	db.result.WriteString("+PONG\r\n")
+	if result, err := client.Do("PING"); err != nil || result != "PONG" {
+		t.Fatal(err, result)
+	}
+
+	if client.Route(NewRequest("GET", "foo")) == node {
+		t.Fatal("unexpected default route")
+	}
+}
+
+func TestForceNode(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	request := NewRequest("RANDOMKEY")
+	request.ForceNode("tcp://127.0.0.1:7000")
+
+	state := client.load()
+	if client.route(state, request.slot(), KeylessDefault, request) != client.Node("tcp://127.0.0.1:7000") {
+		t.Fatal("request wasn't sent to the forced node")
+	}
+
+	request = NewRequest("CLUSTER", "COUNTKEYSINSLOT", 42)
+	request.ForceSlot(42)
+	if request.slot() != 42 {
+		t.Fatal("unexpected slot")
+	}
+
+	for _, n := range []int{-1, 16384} {
+		request = NewRequest("CLUSTER", "COUNTKEYSINSLOT", n)
+		request.ForceSlot(n)
+		if err := client.Send(request); err == nil {
+			t.Fatal("expecting an invalid slot")
+		}
+
+		errs := make(chan error, 1)
+		client.SendAsync(request, func(result interface{}, err error) {
+			errs <- err
+		})
+
+		if err := <-errs; err == nil {
+			t.Fatal("expecting an invalid slot")
+		}
+	}
+}
+
+func TestAnnotatedRoute(t *testing.T) {
+	db := new(mockDB)
+	node := &Conn{db: db}
+	defer node.Close()
+
+	client := &Client{
+		Router: RouterFunc(func(request *Request) *Conn {
+			if request.Annotation("tenant") == "acme" {
+				return node
+			}
+
+			return nil
+		}),
+	}
+
+	defer client.Close()
+
+	// annotations survive the copy of the request made by the prefixed view
+	db.result.WriteString("$3\r\nbar\r\n")
+	request := NewRequest("GET", "foo")
+	request.Annotate("tenant", "acme")
+	if err := client.WithPrefix("acme:").Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := request.Result(0); err != nil || string(result.([]byte)) != "bar" {
+		t.Fatal(err, result)
+	}
+
+	if request.Reset(); request.Annotation("tenant") != "" {
+		t.Fatal("annotations should be cleared")
+	}
+}
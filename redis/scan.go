@@ -0,0 +1,72 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+)
+
+// DefaultScanCount defines the default number of keys hinted to each SCAN call.
+var DefaultScanCount = 1000
+
+// Scan iterates over the keys matching the pattern (or all keys when empty) on every master node.
+// The function is called with each batch of keys and may be called concurrently for different nodes.
+// Iteration on a node stops at the first error returned by the function.
+func (client *Client) Scan(match string, count int, f func(address string, keys []string) error) (err error) {
+	_, err = each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return nil, node.Scan(match, count, func(keys []string) error {
+			return f(name, keys)
+		})
+	})
+
+	return
+}
+
+// Scan iterates over the keys matching the pattern (or all keys when empty).
+// The function is called with each batch of keys and iteration stops at the first error it returns.
+func (conn *Conn) Scan(match string, count int, f func(keys []string) error) (err error) {
+	if count == 0 {
+		count = DefaultScanCount
+	}
+
+	cursor := "0"
+	for {
+		args := []interface{}{cursor}
+		if match != "" {
+			args = append(args, "MATCH", match)
+		}
+
+		args = append(args, "COUNT", count)
+
+		var reply interface{}
+		reply, err = conn.Do("SCAN", args...)
+		if err != nil {
+			return
+		}
+
+		items, ok := reply.([]interface{})
+		if !ok || len(items) != 2 {
+			err = fmt.Errorf("unexpected SCAN reply '%v'", reply)
+			return
+		}
+
+		next, _ := items[0].([]byte)
+		list, _ := items[1].([]interface{})
+
+		keys := make([]string, 0, len(list))
+		for _, item := range list {
+			key, _ := item.([]byte)
+			keys = append(keys, string(key))
+		}
+
+		if len(keys) != 0 {
+			if err = f(keys); err != nil {
+				return
+			}
+		}
+
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"time"
+)
+
+// DefaultSeedDialDelay defines the default delay before dialing the next seed address while the previous ones are still connecting.
+var DefaultSeedDialDelay = 250 * time.Millisecond
+
+// seed dials the seed nodes concurrently and returns the first to connect so that a dead seed doesn't delay the others.
+// Each node is dialed SeedDialDelay after the previous one or right away when all the previous ones failed.
+// It falls back to the first node when none can be reached.
+func (client *Client) seed(nodes []*Conn) *Conn {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+
+	delay := client.SeedDialDelay
+	if 0 == delay {
+		delay = DefaultSeedDialDelay
+	}
+
+	// buffered so that the nodes dialed after the winner don't block
+	results := make(chan *Conn, len(nodes))
+	dial := func(node *Conn) {
+		c, err := node.db.dial()
+		if err != nil {
+			results <- nil
+			return
+		}
+
+		c.Close()
+		results <- node
+	}
+
+	started, failed := 0, 0
+	for failed < len(nodes) {
+		if failed == started {
+			go dial(nodes[started])
+			started++
+		}
+
+		var next <-chan time.Time
+		if started < len(nodes) {
+			next = time.After(delay)
+		}
+
+		select {
+		case node := <-results:
+			if node != nil {
+				return node
+			}
+
+			failed++
+		case <-next:
+			go dial(nodes[started])
+			started++
+		}
+	}
+
+	return nodes[0]
+}
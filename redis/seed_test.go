@@ -0,0 +1,50 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSeed(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	dead := &Conn{
+		db: dialerFunc(func() (net.Conn, error) {
+			<-hang
+			return nil, fmt.Errorf("timeout")
+		}),
+	}
+
+	refused := &Conn{
+		db: dialerFunc(func() (net.Conn, error) {
+			return nil, fmt.Errorf("refused")
+		}),
+	}
+
+	alive := &Conn{db: new(mockDB)}
+
+	// the alive seed is dialed after the delay while the dead one is still connecting
+	client := &Client{
+		SeedDialDelay: time.Millisecond,
+	}
+
+	if node := client.seed([]*Conn{dead, alive}); node != alive {
+		t.Fatal("expecting the alive seed")
+	}
+
+	// the next seed is dialed right away when the previous ones failed
+	client.SeedDialDelay = time.Hour
+
+	if node := client.seed([]*Conn{refused, alive}); node != alive {
+		t.Fatal("expecting the alive seed")
+	}
+
+	if node := client.seed([]*Conn{refused, refused}); node != refused {
+		t.Fatal("expecting the first seed")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultSessionTTL defines the default time a session lives after its last use.
+var DefaultSessionTTL = 30 * time.Minute
+
+// DefaultSessionPrefix defines the default prefix of the keys holding the sessions.
+var DefaultSessionPrefix = "session:"
+
+// Sessions defines the operations of a session store so that web services can swap implementations.
+// Get decodes the session into the value and returns false when there is no session.
+// Touch extends the session and returns false when there is no session.
+type Sessions interface {
+	Get(id string, value interface{}) (bool, error)
+	Set(id string, value interface{}) error
+	Touch(id string) (bool, error)
+	Delete(id string) error
+}
+
+// SessionCodec converts sessions to and from the bytes stored in Redis.
+type SessionCodec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte, value interface{}) error
+}
+
+// SessionCipher optionally encrypts the encoded sessions before they are stored and decrypts them once read.
+type SessionCipher interface {
+	Seal(data []byte) ([]byte, error)
+	Open(data []byte) ([]byte, error)
+}
+
+// JSONCodec encodes sessions as JSON.
+type JSONCodec struct{}
+
+// Encode returns the JSON encoding of the value.
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode parses the JSON data into the value.
+func (JSONCodec) Decode(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+// SessionStore implements Sessions with a string per session expiring after TTL since its last use, which every Get and Touch extends.
+// Prefix, TTL and Codec default to DefaultSessionPrefix, DefaultSessionTTL and JSONCodec. Get requires Redis 6.2.
+type SessionStore struct {
+	Prefix string
+	TTL    time.Duration
+	Codec  SessionCodec
+	Cipher SessionCipher
+
+	client *Client
+}
+
+// SessionStore returns a store keeping its sessions in the database of the client.
+func (client *Client) SessionStore() *SessionStore {
+	return &SessionStore{
+		client: client,
+	}
+}
+
+// Get reads the session into the value and extends it.
+func (store *SessionStore) Get(id string, value interface{}) (ok bool, err error) {
+	data, ok, err := store.client.GetEx(store.key(id), store.ttl())
+	if err != nil || !ok {
+		return
+	}
+
+	if store.Cipher != nil {
+		if data, err = store.Cipher.Open(data); err != nil {
+			return
+		}
+	}
+
+	err = store.codec().Decode(data, value)
+	return
+}
+
+// Set writes the session and resets its time to live.
+func (store *SessionStore) Set(id string, value interface{}) (err error) {
+	data, err := store.codec().Encode(value)
+	if err != nil {
+		return
+	}
+
+	if store.Cipher != nil {
+		if data, err = store.Cipher.Seal(data); err != nil {
+			return
+		}
+	}
+
+	err = expectOK(store.client.Do("SET", store.key(id), data, "PX", int64(store.ttl()/time.Millisecond)))
+	return
+}
+
+// Touch extends the session without reading it.
+func (store *SessionStore) Touch(id string) (bool, error) {
+	return store.client.Expire(store.key(id), store.ttl())
+}
+
+// Delete removes the session.
+func (store *SessionStore) Delete(id string) (err error) {
+	_, err = store.client.Do("DEL", store.key(id))
+	return
+}
+
+func (store *SessionStore) key(id string) string {
+	prefix := store.Prefix
+	if prefix == "" {
+		prefix = DefaultSessionPrefix
+	}
+
+	return prefix + id
+}
+
+func (store *SessionStore) ttl() time.Duration {
+	ttl := store.TTL
+	if 0 == ttl {
+		ttl = DefaultSessionTTL
+	}
+
+	return ttl
+}
+
+func (store *SessionStore) codec() SessionCodec {
+	if store.Codec == nil {
+		return JSONCodec{}
+	}
+
+	return store.Codec
+}
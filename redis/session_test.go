@@ -0,0 +1,103 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// xorCipher is a reversible cipher to check the hooks of the store.
+type xorCipher byte
+
+func (c xorCipher) Seal(data []byte) ([]byte, error) {
+	result := make([]byte, len(data))
+	for i := range data {
+		result[i] = data[i] ^ byte(c)
+	}
+
+	return result, nil
+}
+
+func (c xorCipher) Open(data []byte) ([]byte, error) {
+	return c.Seal(data)
+}
+
+type session struct {
+	User  string
+	Count int
+}
+
+func TestSessionStore(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	var store Sessions = client.SessionStore()
+
+	db.result.WriteString("+OK\r\n$26\r\n{\"User\":\"alice\",\"Count\":2}\r\n$-1\r\n:1\r\n:0\r\n:1\r\n")
+	if err := store.Set("abc", session{"alice", 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	var s session
+	if ok, err := store.Get("abc", &s); err != nil || !ok || s != (session{"alice", 2}) {
+		t.Fatal(s, ok, err)
+	}
+
+	if ok, err := store.Get("missing", &s); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	if ok, err := store.Touch("abc"); err != nil || !ok {
+		t.Fatal(ok, err)
+	}
+
+	if ok, err := store.Touch("missing"); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	if err := store.Delete("abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	// every use resets the time to live to 30 minutes
+	sent := db.written()
+	for _, expected := range []string{
+		"*5\r\n$3\r\nSET\r\n$11\r\nsession:abc\r\n$26\r\n{\"User\":\"alice\",\"Count\":2}\r\n$2\r\nPX\r\n$7\r\n1800000\r\n",
+		"*4\r\n$5\r\nGETEX\r\n$11\r\nsession:abc\r\n$2\r\nPX\r\n$7\r\n1800000\r\n",
+		"*3\r\n$7\r\nPEXPIRE\r\n$11\r\nsession:abc\r\n$7\r\n1800000\r\n",
+	} {
+		if !strings.Contains(sent, expected) {
+			t.Fatalf("expecting %q in %q", expected, sent)
+		}
+	}
+
+	if n := store.(*SessionStore).ttl(); n != DefaultSessionTTL {
+		t.Fatal(n)
+	}
+
+	secure := &SessionStore{Prefix: "s:", TTL: time.Hour, Cipher: xorCipher(0x5a), client: client}
+	if key := secure.key("abc"); key != "s:abc" {
+		t.Fatal(key)
+	}
+
+	sealed, _ := xorCipher(0x5a).Seal([]byte(`{"User":"bob","Count":1}`))
+
+	db.result.WriteString("+OK\r\n$24\r\n")
+	db.result.Write(sealed)
+	db.result.WriteString("\r\n")
+
+	if err := secure.Set("abc", session{"bob", 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := secure.Get("abc", &s); err != nil || !ok || s != (session{"bob", 1}) {
+		t.Fatal(s, ok, err)
+	}
+}
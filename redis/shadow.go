@@ -0,0 +1,120 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"sync"
+)
+
+// DefaultShadowPercentage defines the default percentage of the requests replayed by a Shadow.
+var DefaultShadowPercentage = 100.0
+
+// Shadow replays a percentage of the requests of a client asynchronously against another client.
+// It can be used to validate a new cluster or version of Redis with production traffic.
+type Shadow struct {
+	Client *Client
+
+	// Percentage of requests replayed between 0 and 100, which defaults to DefaultShadowPercentage like the Percentage of Audit.
+	Percentage float64
+
+	// Divergence is called with a copy of the request and its replayed version when their results differ.
+	// Results are discarded when not set.
+	Divergence func(request, shadow *Request)
+
+	MaximumConcurrentRequests int
+	MaximumPendingRequests    int
+
+	once sync.Once
+	stop sync.Once
+	feed chan *Request
+	done chan struct{}
+}
+
+func (shadow *Shadow) initialize() {
+	pending := shadow.MaximumPendingRequests
+	if 0 == pending {
+		pending = DefaultMaximumPendingRequests
+	}
+
+	shadow.feed = make(chan *Request, pending)
+	shadow.done = make(chan struct{})
+
+	requests := shadow.MaximumConcurrentRequests
+	if 0 == requests {
+		requests = DefaultMaximumConcurrentRequests
+	}
+
+	for i := 0; i < requests; i++ {
+		go func() {
+			for {
+				select {
+				case request := <-shadow.feed:
+					replay := request.clone()
+					shadow.Client.Send(replay)
+
+					if shadow.Divergence != nil && !sameResults(request, replay) {
+						shadow.Divergence(request, replay)
+					}
+				case <-shadow.done:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// send replays the request when sampled and drops it when too many requests are pending.
+func (shadow *Shadow) send(r Rand, request *Request) {
+	percentage := shadow.Percentage
+	if 0 == percentage {
+		percentage = DefaultShadowPercentage
+	}
+
+	if r.Float64()*100 >= percentage {
+		return
+	}
+
+	shadow.once.Do(shadow.initialize)
+
+	// keep a copy of the results since the request belongs to the caller
+	result := copyResults(request)
+
+	select {
+	case shadow.feed <- result:
+	default:
+	}
+}
+
+// close stops replaying requests.
+func (shadow *Shadow) close() {
+	// make sure a shadow that was never used won't start
+	shadow.once.Do(func() {})
+	shadow.stop.Do(func() {
+		if shadow.done != nil {
+			close(shadow.done)
+		}
+	})
+}
+
+// copyResults returns a copy of the request holding its results.
+func copyResults(request *Request) (result *Request) {
+	result = request.clone()
+	for i := range request.commands {
+		result.commands[i].result = request.commands[i].result
+		result.commands[i].err = request.commands[i].err
+	}
+
+	return
+}
+
+// sameResults compares the results of the requests like a MirrorClient compares those of its dark reads.
+func sameResults(a, b *Request) bool {
+	for i := range a.commands {
+		x, y := &a.commands[i], &b.commands[i]
+		if difference(x.result, x.err, y.result, y.err) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSameResults(t *testing.T) {
+	a := NewRequest("GET", "foo")
+	b := a.clone()
+
+	a.commands[0].result = []byte("bar")
+	b.commands[0].result = []byte("bar")
+	if !sameResults(a, b) {
+		t.Fatal("expecting same results")
+	}
+
+	b.commands[0].result = nil
+	if sameResults(a, b) {
+		t.Fatal("expecting different results")
+	}
+
+	b.commands[0].result = []byte("bar")
+	b.commands[0].err = errors.New("failure")
+	if sameResults(a, b) {
+		t.Fatal("expecting different errors")
+	}
+
+	a.commands[0].err = errors.New("failure")
+	if !sameResults(a, b) {
+		t.Fatal("expecting the same errors")
+	}
+}
+
+func TestShadowPercentage(t *testing.T) {
+	db := new(mockDB)
+	other := new(Client)
+	defer other.Close()
+
+	other.load()
+	other.nodes["tcp://127.0.0.1:6379"].db = db
+
+	divergences := make(chan *Request, 1)
+	shadow := &Shadow{
+		Client: other,
+		Divergence: func(request, replay *Request) {
+			divergences <- replay
+		},
+	}
+
+	defer shadow.close()
+
+	// every request is replayed by default
+	db.result.WriteString("$3\r\nbaz\r\n")
+
+	request := NewRequest("GET", "foo")
+	request.commands[0].result = []byte("bar")
+	shadow.send(NewRand(1), request)
+
+	if replay := <-divergences; string(replay.commands[0].result.([]byte)) != "baz" {
+		t.Fatal(replay.commands[0].result)
+	}
+}
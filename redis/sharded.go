@@ -0,0 +1,261 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultVirtualNodes defines the default number of points each instance gets on the consistent hashing ring.
+var DefaultVirtualNodes = 160
+
+// ShardedClient implements a client that distributes keys over independent Redis instances using consistent hashing.
+// It is meant for deployments that don't run Redis cluster.
+// Like the cluster, keys that contain a {tag} are hashed using only the tag so that related keys end up on the same instance.
+type ShardedClient struct {
+	Address                   []string
+	VirtualNodes              int
+	MaximumConcurrentRequests int
+	MaximumPendingRequests    int
+	MaximumConnectionRetries  int
+	RetryTimeout              time.Duration
+
+	// Breaker configures the circuit breaker of each instance.
+	Breaker Breaker
+
+	// FailFast returns ErrOverloaded instead of blocking when an instance already has too many pending requests.
+	FailFast        bool
+	FailFastTimeout time.Duration
+
+	// MaximumOfflineRequests is the number of requests held by each instance while it is unreachable.
+	MaximumOfflineRequests int
+	OfflineTimeout         time.Duration
+
+	// MaximumReplySize is the number of bytes of the largest reply accepted from an instance.
+	MaximumReplySize int64
+
+	// StrictProtocol validates the framing of the replies of an instance.
+	StrictProtocol bool
+
+	// DialTimeout, ReadTimeout and WriteTimeout optionally bound the time it takes to connect to, read from and write to an instance.
+	// They are overridden by the options in the query of an address.
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// TLSConfig optionally encrypts the connections.
+	TLSConfig *tls.Config
+
+	// Credentials optionally provides the credentials used to authenticate with each instance.
+	Credentials CredentialsProvider
+
+	// Hash is used to place keys and instances on the ring and defaults to CRC32.
+	Hash func(key []byte) uint32
+
+	mu    sync.Mutex
+	once  sync.Once
+	nodes map[string]*Conn
+	ring  hashRing
+}
+
+type point struct {
+	hash uint32
+	node *Conn
+}
+
+type hashRing []point
+
+func (r hashRing) Len() int {
+	return len(r)
+}
+
+func (r hashRing) Less(i, j int) bool {
+	return r[i].hash < r[j].hash
+}
+
+func (r hashRing) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+func (client *ShardedClient) initialize() {
+	if len(client.Address) == 0 {
+		log.Panicf("no address")
+	}
+
+	if client.Hash == nil {
+		client.Hash = crc32.ChecksumIEEE
+	}
+
+	n := client.VirtualNodes
+	if 0 == n {
+		n = DefaultVirtualNodes
+	}
+
+	client.nodes = make(map[string]*Conn)
+
+	for _, address := range client.Address {
+		node := (&Conn{
+			MaximumConcurrentRequests: client.MaximumConcurrentRequests,
+			MaximumPendingRequests:    client.MaximumPendingRequests,
+			MaximumConnectionRetries:  client.MaximumConnectionRetries,
+			RetryTimeout:              client.RetryTimeout,
+			Breaker:                   client.Breaker,
+			FailFast:                  client.FailFast,
+			FailFastTimeout:           client.FailFastTimeout,
+			MaximumOfflineRequests:    client.MaximumOfflineRequests,
+			OfflineTimeout:            client.OfflineTimeout,
+			MaximumReplySize:          client.MaximumReplySize,
+			StrictProtocol:            client.StrictProtocol,
+			Credentials:               client.Credentials,
+		}).mustConfigure(address, dialOptions{
+			dial:  client.DialTimeout,
+			read:  client.ReadTimeout,
+			write: client.WriteTimeout,
+			tls:   client.TLSConfig,
+		})
+
+		client.nodes[address] = node
+
+		for i := 0; i < n; i++ {
+			client.ring = append(client.ring, point{
+				hash: client.Hash([]byte(fmt.Sprintf("%s-%d", address, i))),
+				node: node,
+			})
+		}
+	}
+
+	sort.Sort(client.ring)
+}
+
+// Do executes the specified command (with optional arguments) to the Redis instance and waits to decode the reply.
+func (client *ShardedClient) Do(name string, args ...interface{}) (result interface{}, err error) {
+	request := newRequest(name, args)
+	if err = client.Send(request); err == nil {
+		result = request.commands[len(request.commands)-1].result
+	}
+
+	release(request)
+	return
+}
+
+// CrossShardError is returned without sending a request whose keys belong to different instances of a ShardedClient.
+// This applies to all the keys of the commands of the request since they are all sent to the same instance.
+// Keys holds the keys of the request and Instances holds the address of the instance of each key.
+type CrossShardError struct {
+	Keys      []string
+	Instances []string
+}
+
+func (e *CrossShardError) Error() string {
+	return fmt.Sprintf("keys %q hash to different instances %v: use a hash tag like {user}.name and {user}.email to keep related keys on the same instance", e.Keys, e.Instances)
+}
+
+// Send sends the specified request to the Redis instance that owns its first key and waits for the reply.
+// Requests without keys are sent to the first instance and those with keys on different instances fail with CrossShardError.
+func (client *ShardedClient) Send(request *Request) (err error) {
+	client.once.Do(client.initialize)
+
+	if err = client.crossShard(request); err != nil {
+		request.err = err
+		return
+	}
+
+	return client.node(request).Send(request)
+}
+
+// crossShard returns an error when the keys of the request belong to different instances unless it is routed explicitly.
+func (client *ShardedClient) crossShard(request *Request) error {
+	if _, ok := client.nodes[request.node]; ok || request.key != nil {
+		return nil
+	}
+
+	var first *Conn
+	cross := false
+	e := new(CrossShardError)
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		for _, j := range cmd.keys() {
+			key := argString(cmd.args[j])
+			node := client.locate([]byte(key))
+			if first == nil {
+				first = node
+			}
+
+			cross = cross || node != first
+			e.Keys = append(e.Keys, key)
+			e.Instances = append(e.Instances, node.address)
+		}
+	}
+
+	if cross {
+		return e
+	}
+
+	return nil
+}
+
+// LuaScript loads a script into the script cache of every instance.
+func (client *ShardedClient) LuaScript(code string) (id string, err error) {
+	client.once.Do(client.initialize)
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	id, err = loadScript(client.nodes, code)
+	return
+}
+
+// Close tears down the connections to the Redis instances.
+func (client *ShardedClient) Close() {
+	if client == nil {
+		return
+	}
+
+	client.once.Do(client.initialize)
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	for _, item := range client.nodes {
+		item.Close()
+	}
+}
+
+func (client *ShardedClient) node(request *Request) *Conn {
+	if node, ok := client.nodes[request.node]; ok {
+		return node
+	}
+
+	key := request.key
+	if key == nil {
+		if len(request.commands[0].args) == 0 {
+			return client.nodes[client.Address[0]]
+		}
+
+		key = []byte(request.Key(0))
+	}
+
+	return client.locate(key)
+}
+
+// locate returns the instance that owns the key.
+func (client *ShardedClient) locate(key []byte) *Conn {
+	hash := client.Hash(tag(key))
+
+	// find the first point of the ring after the key and wrap around
+	i := sort.Search(len(client.ring), func(k int) bool {
+		return client.ring[k].hash >= hash
+	})
+
+	if i == len(client.ring) {
+		i = 0
+	}
+
+	return client.ring[i].node
+}
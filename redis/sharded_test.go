@@ -0,0 +1,71 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShardedRing(t *testing.T) {
+	client := &ShardedClient{
+		Address: []string{
+			"tcp://127.0.0.1:7000",
+			"tcp://127.0.0.1:7001",
+			"tcp://127.0.0.1:7002",
+		},
+	}
+
+	client.once.Do(client.initialize)
+	defer client.Close()
+
+	count := make(map[*Conn]int)
+	for i := 0; i < 3000; i++ {
+		count[client.node(NewRequest("GET", fmt.Sprintf("key-%d", i)))]++
+	}
+
+	if len(count) != 3 {
+		t.Fatalf("keys aren't distributed over all instances: %v", count)
+	}
+
+	for _, n := range count {
+		if n < 500 {
+			t.Fatalf("keys aren't distributed evenly: %v", count)
+		}
+	}
+
+	a := client.node(NewRequest("GET", "{user1}name"))
+	b := client.node(NewRequest("GET", "{user1}email"))
+	if a != b {
+		t.Fatal("keys with the same tag should be on the same instance")
+	}
+
+	if client.node(NewRequest("PING")) != client.nodes[client.Address[0]] {
+		t.Fatal("requests without keys should go to the first instance")
+	}
+
+	// keys on different instances can't be sent together
+	var x, y string
+	for i := 0; x == "" || y == ""; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if client.locate([]byte(key)) == a {
+			x = key
+		} else {
+			y = key
+		}
+	}
+
+	if err := client.Send(NewRequest("MGET", x, y)); err == nil {
+		t.Fatal("expecting a cross shard error")
+	}
+
+	request := NewRequest("GET", x)
+	request.Add("GET", y)
+	if _, ok := client.Send(request).(*CrossShardError); !ok {
+		t.Fatal("expecting a cross shard error")
+	}
+
+	if err := client.crossShard(NewRequest("MGET", "{user1}name", "{user1}email")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+)
+
+// ClusterNode defines a node listed by CLUSTER SLOTS or CLUSTER SHARDS.
+// Address is formatted like the addresses of the client e.g. tcp://127.0.0.1:6379.
+// Role, Offset and Health are only reported by CLUSTER SHARDS with Health being one of online, failed or loading.
+type ClusterNode struct {
+	ID       string
+	Address  string
+	Hostname string
+	Role     string
+	Offset   int64
+	Health   string
+}
+
+// ClusterSlotRange defines consecutive slots served by a master and its replicas as reported by CLUSTER SLOTS.
+type ClusterSlotRange struct {
+	First    int
+	Last     int
+	Master   ClusterNode
+	Replicas []ClusterNode
+}
+
+// ClusterShard defines a master and its replicas as reported by CLUSTER SHARDS.
+// Slots holds the first and last slot of each range served by the shard.
+type ClusterShard struct {
+	Slots [][2]int
+	Nodes []ClusterNode
+}
+
+// ClusterSlots returns the ranges of slots of the cluster as seen by the node serving the first slot.
+func (client *Client) ClusterSlots() (result []ClusterSlotRange, err error) {
+	node, err := client.first()
+	if err != nil {
+		return
+	}
+
+	result, err = node.ClusterSlots()
+	return
+}
+
+// ClusterShards returns the shards of the cluster as seen by the node serving the first slot.
+// This requires Redis 7.0.
+func (client *Client) ClusterShards() (result []ClusterShard, err error) {
+	node, err := client.first()
+	if err != nil {
+		return
+	}
+
+	result, err = node.ClusterShards()
+	return
+}
+
+// first returns the node serving the first slot.
+func (client *Client) first() (node *Conn, err error) {
+	if node = client.load().slots.get(0); node == nil {
+		err = fmt.Errorf("no node serving slot %d", 0)
+	}
+
+	return
+}
+
+// ClusterSlots returns the ranges of slots of the cluster as seen by the node.
+func (conn *Conn) ClusterSlots() (result []ClusterSlotRange, err error) {
+	reply, err := conn.Do("CLUSTER", "SLOTS")
+	if err != nil {
+		return
+	}
+
+	result, err = parseClusterSlots(reply)
+	return
+}
+
+// ClusterShards returns the shards of the cluster as seen by the node.
+func (conn *Conn) ClusterShards() (result []ClusterShard, err error) {
+	reply, err := conn.Do("CLUSTER", "SHARDS")
+	if err != nil {
+		return
+	}
+
+	result, err = parseClusterShards(reply)
+	return
+}
+
+func parseClusterSlots(reply interface{}) (result []ClusterSlotRange, err error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		err = fmt.Errorf("unexpected CLUSTER SLOTS reply '%v'", reply)
+		return
+	}
+
+	result = make([]ClusterSlotRange, len(items))
+	for i := range items {
+		item, ok := items[i].([]interface{})
+		if !ok || len(item) < 3 {
+			err = fmt.Errorf("unexpected CLUSTER SLOTS range '%v'", items[i])
+			return
+		}
+
+		first, ok1 := item[0].(int64)
+		last, ok2 := item[1].(int64)
+		if !ok1 || !ok2 {
+			err = fmt.Errorf("unexpected CLUSTER SLOTS range '%v'", items[i])
+			return
+		}
+
+		r := ClusterSlotRange{
+			First: int(first),
+			Last:  int(last),
+		}
+
+		for j, value := range item[2:] {
+			node, ok := parseClusterSlotsNode(value)
+			if !ok {
+				err = fmt.Errorf("unexpected CLUSTER SLOTS node '%v'", value)
+				return
+			}
+
+			if j == 0 {
+				r.Master = node
+			} else {
+				r.Replicas = append(r.Replicas, node)
+			}
+		}
+
+		result[i] = r
+	}
+
+	return
+}
+
+// parseClusterSlotsNode parses a node formatted as [ip, port, id, [metadata...]].
+func parseClusterSlotsNode(value interface{}) (node ClusterNode, ok bool) {
+	m, _ := value.([]interface{})
+	if len(m) < 2 {
+		return
+	}
+
+	ip, ok1 := m[0].([]byte)
+	port, ok2 := m[1].(int64)
+	if !ok1 || !ok2 {
+		return
+	}
+
+	node.Address = fmt.Sprintf("tcp://%s:%d", ip, port)
+
+	// node IDs are only available since Redis 4.0 and metadata since Redis 7.0
+	if len(m) > 2 {
+		id, _ := m[2].([]byte)
+		node.ID = string(id)
+	}
+
+	if len(m) > 3 {
+		fields := parseFields(m[3])
+		node.Hostname = fields["hostname"]
+	}
+
+	ok = true
+	return
+}
+
+func parseClusterShards(reply interface{}) (result []ClusterShard, err error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		err = fmt.Errorf("unexpected CLUSTER SHARDS reply '%v'", reply)
+		return
+	}
+
+	result = make([]ClusterShard, len(items))
+	for i := range items {
+		fields, ok := items[i].([]interface{})
+		if !ok {
+			err = fmt.Errorf("unexpected CLUSTER SHARDS shard '%v'", items[i])
+			return
+		}
+
+		var shard ClusterShard
+		for j := 0; j+1 < len(fields); j += 2 {
+			key, _ := fields[j].([]byte)
+			values, _ := fields[j+1].([]interface{})
+
+			switch string(key) {
+			case "slots":
+				for k := 0; k+1 < len(values); k += 2 {
+					first, _ := values[k].(int64)
+					last, _ := values[k+1].(int64)
+					shard.Slots = append(shard.Slots, [2]int{int(first), int(last)})
+				}
+			case "nodes":
+				for _, value := range values {
+					shard.Nodes = append(shard.Nodes, parseClusterShardsNode(value))
+				}
+			}
+		}
+
+		result[i] = shard
+	}
+
+	return
+}
+
+// parseClusterShardsNode parses a node formatted as a list of fields and values.
+func parseClusterShardsNode(value interface{}) (node ClusterNode) {
+	m, _ := value.([]interface{})
+
+	var port int64
+	for j := 0; j+1 < len(m); j += 2 {
+		key, _ := m[j].([]byte)
+		switch string(key) {
+		case "id":
+			text, _ := m[j+1].([]byte)
+			node.ID = string(text)
+		case "ip":
+			text, _ := m[j+1].([]byte)
+			node.Address = string(text)
+		case "port":
+			port, _ = m[j+1].(int64)
+		case "hostname":
+			text, _ := m[j+1].([]byte)
+			node.Hostname = string(text)
+		case "role":
+			text, _ := m[j+1].([]byte)
+			node.Role = string(text)
+		case "replication-offset":
+			node.Offset, _ = m[j+1].(int64)
+		case "health":
+			text, _ := m[j+1].([]byte)
+			node.Health = string(text)
+		}
+	}
+
+	node.Address = fmt.Sprintf("tcp://%s:%d", node.Address, port)
+	return
+}
+
+// parseFields returns the fields of a flat list of names and values.
+func parseFields(value interface{}) (result map[string]string) {
+	result = make(map[string]string)
+
+	m, _ := value.([]interface{})
+	for j := 0; j+1 < len(m); j += 2 {
+		key, _ := m[j].([]byte)
+		text, _ := m[j+1].([]byte)
+		result[string(key)] = string(text)
+	}
+
+	return
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterSlots(t *testing.T) {
+	reply, err := Unmarshal([]byte("*1\r\n*4\r\n:0\r\n:5460\r\n" +
+		"*4\r\n$9\r\n127.0.0.1\r\n:30001\r\n$2\r\nm1\r\n*2\r\n$8\r\nhostname\r\n$5\r\nhost1\r\n" +
+		"*3\r\n$9\r\n127.0.0.1\r\n:30004\r\n$2\r\nr1\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseClusterSlots(reply)
+	expected := []ClusterSlotRange{
+		{
+			First:    0,
+			Last:     5460,
+			Master:   ClusterNode{ID: "m1", Address: "tcp://127.0.0.1:30001", Hostname: "host1"},
+			Replicas: []ClusterNode{{ID: "r1", Address: "tcp://127.0.0.1:30004"}},
+		},
+	}
+
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Fatal(result, err)
+	}
+
+	if _, err := parseClusterSlots([]interface{}{[]interface{}{int64(0)}}); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
+
+func TestClusterShards(t *testing.T) {
+	reply, err := Unmarshal([]byte("*1\r\n*4\r\n$5\r\nslots\r\n*4\r\n:0\r\n:10\r\n:20\r\n:30\r\n$5\r\nnodes\r\n*1\r\n" +
+		"*12\r\n$2\r\nid\r\n$2\r\nm1\r\n$4\r\nport\r\n:30001\r\n$2\r\nip\r\n$9\r\n127.0.0.1\r\n" +
+		"$4\r\nrole\r\n$6\r\nmaster\r\n$18\r\nreplication-offset\r\n:72156\r\n$6\r\nhealth\r\n$6\r\nonline\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseClusterShards(reply)
+	expected := []ClusterShard{
+		{
+			Slots: [][2]int{{0, 10}, {20, 30}},
+			Nodes: []ClusterNode{{ID: "m1", Address: "tcp://127.0.0.1:30001", Role: "master", Offset: 72156, Health: "online"}},
+		},
+	}
+
+	if err != nil || !reflect.DeepEqual(result, expected) {
+		t.Fatal(result, err)
+	}
+}
+
+func TestClusterSlotsUnserved(t *testing.T) {
+	client := &Client{}
+	client.once.Do(func() {})
+	client.state.Store(&mapping{shards: true})
+
+	if _, err := client.ClusterSlots(); err == nil {
+		t.Fatal("expecting no node serving slot 0")
+	}
+
+	if _, err := client.ClusterShards(); err == nil {
+		t.Fatal("expecting no node serving slot 0")
+	}
+}
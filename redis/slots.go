@@ -0,0 +1,45 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+// slotPageSize defines the number of slots per page of a slot table.
+const slotPageSize = 128
+
+type slotPage [slotPageSize]*Conn
+
+// slotTable maps the slots of the cluster to their node.
+// Pages are shared between successive mappings and only the page holding a slot is copied when it moves.
+type slotTable [16384 / slotPageSize]*slotPage
+
+func (t *slotTable) get(slot int) *Conn {
+	page := t[slot/slotPageSize]
+	if page == nil {
+		return nil
+	}
+
+	return page[slot%slotPageSize]
+}
+
+// set changes the slot in a copy of its page so that other tables sharing the page are unaffected.
+func (t *slotTable) set(slot int, node *Conn) {
+	page := new(slotPage)
+	if last := t[slot/slotPageSize]; last != nil {
+		*page = *last
+	}
+
+	page[slot%slotPageSize] = node
+	t[slot/slotPageSize] = page
+}
+
+// fill sets a range of slots in place and must only be used on a table being built.
+func (t *slotTable) fill(first, last int, node *Conn) {
+	for i := first; i <= last; i++ {
+		page := t[i/slotPageSize]
+		if page == nil {
+			page = new(slotPage)
+			t[i/slotPageSize] = page
+		}
+
+		page[i%slotPageSize] = node
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import "testing"
+
+func TestSlotTable(t *testing.T) {
+	a, b := &Conn{}, &Conn{}
+
+	var first slotTable
+	first.fill(0, 16383, a)
+
+	second := first
+	second.set(200, b)
+
+	if first.get(200) != a || second.get(200) != b || second.get(201) != a {
+		t.Fatal("unexpected slot mapping")
+	}
+
+	// only the page of the slot is copied
+	if first[0] != second[0] || first[1] == second[1] {
+		t.Fatal("unexpected page sharing")
+	}
+}
+
+func BenchmarkSlotUpdate(b *testing.B) {
+	var table slotTable
+	table.fill(0, 16383, &Conn{})
+
+	node := &Conn{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		next := table
+		next.set(i%16384, node)
+		table = next
+	}
+}
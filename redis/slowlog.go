@@ -0,0 +1,124 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SlowLogEntry defines a command logged by the SLOWLOG of a Redis instance.
+type SlowLogEntry struct {
+	// Address is the node that logged the entry.
+	Address    string
+	ID         int64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLog returns the last n entries of the slow log of every master node, most recent first.
+// Nodes that failed are reported with NodeErrors while the entries of the others are still returned.
+func (client *Client) SlowLog(n int) (result []SlowLogEntry, err error) {
+	results, err := each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return node.SlowLog(n)
+	})
+
+	for name, item := range results {
+		for _, entry := range item.([]SlowLogEntry) {
+			entry.Address = name
+			result = append(result, entry)
+		}
+	}
+
+	sort.Sort(slowLogByTime(result))
+	return
+}
+
+// SlowLogReset clears the slow log of every master node.
+func (client *Client) SlowLogReset() (err error) {
+	_, err = each(client.masters(), func(name string, node *Conn) (interface{}, error) {
+		return nil, node.SlowLogReset()
+	})
+
+	return
+}
+
+// SlowLog returns the last n entries of the slow log.
+func (conn *Conn) SlowLog(n int) (result []SlowLogEntry, err error) {
+	reply, err := conn.Do("SLOWLOG", "GET", n)
+	if err != nil {
+		return
+	}
+
+	result, err = parseSlowLog(reply)
+	return
+}
+
+// SlowLogReset clears the slow log.
+func (conn *Conn) SlowLogReset() (err error) {
+	_, err = conn.Do("SLOWLOG", "RESET")
+	return
+}
+
+func parseSlowLog(reply interface{}) (result []SlowLogEntry, err error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		err = fmt.Errorf("unexpected SLOWLOG reply '%v'", reply)
+		return
+	}
+
+	result = make([]SlowLogEntry, len(items))
+	for i := range items {
+		item, ok := items[i].([]interface{})
+		if !ok || len(item) < 4 {
+			err = fmt.Errorf("unexpected SLOWLOG entry '%v'", items[i])
+			return
+		}
+
+		id, _ := item[0].(int64)
+		at, _ := item[1].(int64)
+		us, _ := item[2].(int64)
+
+		entry := SlowLogEntry{
+			ID:       id,
+			Time:     time.Unix(at, 0),
+			Duration: time.Duration(us) * time.Microsecond,
+		}
+
+		args, _ := item[3].([]interface{})
+		for _, arg := range args {
+			text, _ := arg.([]byte)
+			entry.Args = append(entry.Args, string(text))
+		}
+
+		// client address and name are only available since Redis 4.0
+		if len(item) >= 6 {
+			addr, _ := item[4].([]byte)
+			name, _ := item[5].([]byte)
+			entry.ClientAddr = string(addr)
+			entry.ClientName = string(name)
+		}
+
+		result[i] = entry
+	}
+
+	return
+}
+
+type slowLogByTime []SlowLogEntry
+
+func (s slowLogByTime) Len() int {
+	return len(s)
+}
+
+func (s slowLogByTime) Less(i, j int) bool {
+	return s[i].Time.After(s[j].Time)
+}
+
+func (s slowLogByTime) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSlowLog(t *testing.T) {
+	reply := []interface{}{
+		[]interface{}{
+			int64(14), int64(1309448221), int64(15000),
+			[]interface{}{[]byte("KEYS"), []byte("*")},
+			[]byte("127.0.0.1:58217"), []byte("worker"),
+		},
+		[]interface{}{
+			int64(13), int64(1309448128), int64(30),
+			[]interface{}{[]byte("PING")},
+		},
+	}
+
+	result, err := parseSlowLog(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SlowLogEntry{
+		{
+			ID:         14,
+			Time:       time.Unix(1309448221, 0),
+			Duration:   15 * time.Millisecond,
+			Args:       []string{"KEYS", "*"},
+			ClientAddr: "127.0.0.1:58217",
+			ClientName: "worker",
+		},
+		{
+			ID:       13,
+			Time:     time.Unix(1309448128, 0),
+			Duration: 30 * time.Microsecond,
+			Args:     []string{"PING"},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if _, err := parseSlowLog([]interface{}{int64(1)}); err == nil {
+		t.Fatal("expecting an error")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+)
+
+// ErrStaleReply is returned when FailStaleReplies is set and the slot of the request moved to another node before its reply arrived.
+var ErrStaleReply = errors.New("slot moved while waiting for the reply")
+
+// Epoch returns the version of the topology of the cluster used to send the request the last time or zero when it wasn't sent yet.
+func (request *Request) Epoch() int64 {
+	if request.routed == nil {
+		return 0
+	}
+
+	return request.routed.id
+}
+
+// Stale returns true when the slot of the request moved to another node before its reply arrived.
+// A read may then have missed the writes acknowledged by the new node during a failover.
+func (request *Request) Stale() bool {
+	return request.stale
+}
+
+// stale flags the request when the topology changed for its slot while it was sent and fails it when FailStaleReplies is set.
+func (client *Client) stale(slot int, request *Request, err error) error {
+	current, ok := client.state.Load().(*mapping)
+	if err != nil || request.routed == nil || !ok || current.closed || current == request.routed || !current.shards {
+		return err
+	}
+
+	if !request.keyed() || current.slots.get(slot) == request.routed.slots.get(slot) {
+		return err
+	}
+
+	request.stale = true
+	if client.FailStaleReplies {
+		request.err = ErrStaleReply
+		err = request.err
+	}
+
+	return err
+}
+
+// keyed returns true when the request is routed by a key.
+func (request *Request) keyed() bool {
+	if len(request.key) != 0 {
+		return true
+	}
+
+	for i := range request.commands {
+		if len(request.commands[i].keys()) != 0 {
+			return true
+		}
+	}
+
+	return false
+}
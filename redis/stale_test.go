@@ -0,0 +1,67 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestStale(t *testing.T) {
+	a := new(mockDB)
+	b := new(mockDB)
+
+	state := &mapping{
+		id:     1,
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: a},
+			"tcp://127.0.0.1:7001": {db: b},
+		},
+	}
+
+	state.slots.fill(0, 16383, state.nodes["tcp://127.0.0.1:7000"])
+
+	// the slot fails over to the other node while the request is sent
+	next := &mapping{
+		id:     2,
+		shards: true,
+		nodes:  state.nodes,
+	}
+
+	next.slots.fill(0, 16383, state.nodes["tcp://127.0.0.1:7001"])
+
+	client := &Client{
+		nodes: state.nodes,
+	}
+
+	defer client.Close()
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+
+	client.Middleware = []Middleware{
+		MiddlewareFunc(func(node *Conn, request *Request, sender Sender) error {
+			err := sender.Send(request)
+			client.state.Store(next)
+			return err
+		}),
+	}
+
+	a.result.WriteString("$1\r\n1\r\n$1\r\n1\r\n")
+
+	request := NewRequest("GET", "a")
+	if err := client.Send(request); err != nil {
+		t.Fatal(err)
+	}
+
+	if !request.Stale() || request.Epoch() != 1 {
+		t.Fatal(request.Stale(), request.Epoch())
+	}
+
+	client.FailStaleReplies = true
+	client.state.Store(state)
+
+	if _, err := client.Do("GET", "a"); err != ErrStaleReply {
+		t.Fatal(err)
+	}
+}
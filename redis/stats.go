@@ -0,0 +1,212 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats holds the statistics of a connection.
+type ConnStats struct {
+	BytesRead    int64
+	BytesWritten int64
+	Commands     int64
+	Replies      int64
+	Reconnects   int64
+	Overloaded   int64
+	Pending      int
+	InFlight     int
+
+	// PendingBatch is the number of pending requests with Batch priority.
+	PendingBatch int
+
+	// The failed requests are counted by class: redirections, other replied errors, timeouts, other network errors and the rest.
+	Redirects     int64
+	ReplyErrors   int64
+	Timeouts      int64
+	NetworkErrors int64
+	OtherErrors   int64
+
+	// LastError is the last error that wasn't replied by Redis and when it happened.
+	LastError     error
+	LastErrorTime time.Time
+
+	// ConnectedSince is when the current connection was established or zero when disconnected.
+	ConnectedSince time.Time
+
+	// Healthy is false when the node is quarantined or failed its last health check.
+	Healthy bool
+}
+
+// Stats holds the statistics of the nodes of a client indexed by address.
+// The statistics of all nodes are also summed with the most recent error.
+// Unhealthy is the number of nodes that aren't healthy and Latencies holds the latency histogram of each command.
+type Stats struct {
+	ConnStats
+	Nodes     map[string]ConnStats
+	Unhealthy int
+	Latencies map[string]*Histogram
+}
+
+type counters struct {
+	read     int64
+	written  int64
+	commands int64
+	replies  int64
+	connects int64
+	since    int64
+
+	redirects int64
+	failures  int64
+	timeouts  int64
+	network   int64
+	other     int64
+
+	mu      sync.Mutex
+	err     error
+	errTime time.Time
+}
+
+// count increments the counter of the class of the error.
+func (c *counters) count(err error) {
+	switch {
+	case err == nil:
+		return
+	case IsRedirect(err):
+		atomic.AddInt64(&c.redirects, 1)
+	case IsTimeout(err):
+		atomic.AddInt64(&c.timeouts, 1)
+	case IsNetworkError(err):
+		atomic.AddInt64(&c.network, 1)
+	default:
+		if _, ok := err.(ReplyError); ok {
+			atomic.AddInt64(&c.failures, 1)
+		} else {
+			atomic.AddInt64(&c.other, 1)
+		}
+	}
+}
+
+func (c *counters) fail(err error) {
+	c.mu.Lock()
+	c.err, c.errTime = err, time.Now()
+	c.mu.Unlock()
+}
+
+// countedConn counts the bytes read and written on the connection.
+type countedConn struct {
+	net.Conn
+	conn *Conn
+}
+
+func (c *countedConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	atomic.AddInt64(&c.conn.stats.read, int64(n))
+	return
+}
+
+func (c *countedConn) Write(b []byte) (n int, err error) {
+	n, err = c.Conn.Write(b)
+	atomic.AddInt64(&c.conn.stats.written, int64(n))
+	return
+}
+
+func (c *countedConn) Close() error {
+	atomic.StoreInt64(&c.conn.stats.since, 0)
+	return c.Conn.Close()
+}
+
+// Stats returns the statistics of the connection.
+func (conn *Conn) Stats() (result ConnStats) {
+	s := &conn.stats
+
+	result = ConnStats{
+		BytesRead:    atomic.LoadInt64(&s.read),
+		BytesWritten: atomic.LoadInt64(&s.written),
+		Commands:     atomic.LoadInt64(&s.commands),
+		Replies:      atomic.LoadInt64(&s.replies),
+		Overloaded:   conn.Overloaded(),
+		Pending:      conn.Pending(),
+		PendingBatch: conn.PendingBatch(),
+		InFlight:     conn.InFlight(),
+
+		Redirects:     atomic.LoadInt64(&s.redirects),
+		ReplyErrors:   atomic.LoadInt64(&s.failures),
+		Timeouts:      atomic.LoadInt64(&s.timeouts),
+		NetworkErrors: atomic.LoadInt64(&s.network),
+		OtherErrors:   atomic.LoadInt64(&s.other),
+
+		Healthy: conn.Healthy(),
+	}
+
+	if n := atomic.LoadInt64(&s.connects); n > 1 {
+		result.Reconnects = n - 1
+	}
+
+	if since := atomic.LoadInt64(&s.since); since != 0 {
+		result.ConnectedSince = time.Unix(0, since)
+	}
+
+	s.mu.Lock()
+	result.LastError, result.LastErrorTime = s.err, s.errTime
+	s.mu.Unlock()
+	return
+}
+
+// Stats returns the statistics of the connections to the master nodes and replicas of the cluster.
+func (client *Client) Stats() (result Stats) {
+	client.load()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	result.Nodes = make(map[string]ConnStats)
+	for _, nodes := range []map[string]*Conn{client.nodes, client.replicas} {
+		for name, node := range nodes {
+			result.Nodes[name] = node.Stats()
+		}
+	}
+
+	for _, item := range result.Nodes {
+		result.add(item)
+		if !item.Healthy {
+			result.Unhealthy++
+		}
+	}
+
+	result.Healthy = result.Unhealthy == 0
+
+	client.latencyMu.RLock()
+	result.Latencies = make(map[string]*Histogram, len(client.latencies))
+	for name, h := range client.latencies {
+		result.Latencies[name] = h.snapshot()
+	}
+
+	client.latencyMu.RUnlock()
+
+	return
+}
+
+func (s *ConnStats) add(item ConnStats) {
+	s.BytesRead += item.BytesRead
+	s.BytesWritten += item.BytesWritten
+	s.Commands += item.Commands
+	s.Replies += item.Replies
+	s.Reconnects += item.Reconnects
+	s.Overloaded += item.Overloaded
+	s.Pending += item.Pending
+	s.PendingBatch += item.PendingBatch
+	s.InFlight += item.InFlight
+	s.Redirects += item.Redirects
+	s.ReplyErrors += item.ReplyErrors
+	s.Timeouts += item.Timeouts
+	s.NetworkErrors += item.NetworkErrors
+	s.OtherErrors += item.OtherErrors
+
+	if item.LastErrorTime.After(s.LastErrorTime) {
+		s.LastError, s.LastErrorTime = item.LastError, item.LastErrorTime
+	}
+}
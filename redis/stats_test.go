@@ -0,0 +1,68 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("+PONG\r\n-ERR unknown\r\n")
+
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Do("UNKNOWN"); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	stats := conn.Stats()
+	if stats.Commands != 2 || stats.Replies != 2 {
+		t.Fatal(stats)
+	}
+
+	if stats.BytesRead != 21 || stats.BytesWritten == 0 {
+		t.Fatal(stats)
+	}
+
+	if stats.ConnectedSince.IsZero() || stats.LastError != nil {
+		t.Fatal(stats)
+	}
+
+	db.err = fmt.Errorf("failure")
+	if _, err := conn.Do("PING"); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	if stats = conn.Stats(); stats.LastError == nil || stats.LastErrorTime.IsZero() {
+		t.Fatal(stats)
+	}
+}
+
+func TestErrorStats(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	if _, err := conn.Do("GET", "key"); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	db.err = io.EOF
+	if _, err := conn.Do("GET", "key"); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	if stats := conn.Stats(); stats.ReplyErrors != 1 || stats.NetworkErrors != 1 || stats.Redirects != 0 {
+		t.Fatal(stats)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+)
+
+// stream reads a bulk string reply directly from the connection.
+// Other replies are only decoded once it is closed.
+type stream struct {
+	reader  io.Reader
+	decoder *Decoder
+	once    sync.Once
+	done    chan struct{}
+	err     error
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
+
+// Close skips what is left of the reply to keep the connection in sync and releases it.
+func (s *stream) Close() error {
+	s.once.Do(func() {
+		_, s.err = io.Copy(ioutil.Discard, s.reader)
+		if s.err == nil {
+			_, s.err = s.decoder.getLine()
+		}
+
+		close(s.done)
+	})
+
+	return s.err
+}
+
+// stream decodes the header of a bulk string reply and returns a reader for its content.
+// Other replies are decoded normally and the maximum reply size doesn't apply to the content.
+func (decoder *Decoder) stream() (result interface{}, err error) {
+	decoder.size, decoder.depth = 0, 0
+
+	line, err := decoder.getLine()
+	if err != nil {
+		return
+	}
+
+	if len(line) == 0 || line[0] != '$' {
+		result, err = decoder.parse(line, nil)
+		return
+	}
+
+	// streamed replies are meant to be large
+	n, err := decoder.length(line[1:], math.MaxInt64)
+	if n < 0 || err != nil {
+		return
+	}
+
+	result = &stream{
+		reader:  io.LimitReader(decoder.reader, n),
+		decoder: decoder,
+		done:    make(chan struct{}),
+	}
+
+	return
+}
+
+// DoStream executes the specified command and returns a reader over its bulk string reply instead of reading it entirely in memory.
+// The reader is nil when the reply is nil e.g. for a key that doesn't exist.
+// The reader must be closed since no other reply is read from the connection until then.
+func (conn *Conn) DoStream(name string, args ...interface{}) (reader io.ReadCloser, err error) {
+	request := NewRequest(name, args...)
+	request.commands[0].stream = true
+
+	if err = conn.Send(request); err != nil {
+		return
+	}
+
+	reader, err = streamResult(request)
+	return
+}
+
+// GetStream returns a reader over the value of the key which is read from the connection as it is consumed.
+// The value is streamed on a connection leased from the node so that the other requests aren't held up until the reader is closed.
+// The reader is nil when the key doesn't exist and it must be closed.
+func (client *Client) GetStream(key string) (reader io.ReadCloser, err error) {
+	state := client.load()
+
+	slot := 0
+	if state.shards {
+		slot = Slot(key)
+	}
+
+	node := state.slots.get(slot)
+	if node == nil {
+		err = fmt.Errorf("no node serving slot %d", slot)
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		if reader, err = client.getStream(node, key); attempt != 0 {
+			return
+		}
+
+		if node, err = client.moved(slot, err); node == nil {
+			return
+		}
+	}
+}
+
+func (client *Client) getStream(node *Conn, key string) (reader io.ReadCloser, err error) {
+	conn := client.lease(node)
+
+	request := NewRequest("GET", key)
+	request.commands[0].stream = true
+
+	if err = client.sendNode(conn, request); err == nil {
+		reader, err = streamResult(request)
+	}
+
+	s, ok := reader.(*stream)
+	if !ok {
+		client.release(node, conn, err)
+		return
+	}
+
+	reader = &leasedStream{
+		stream: s,
+		release: func(err error) {
+			client.release(node, conn, err)
+		},
+	}
+
+	return
+}
+
+// leasedStream gives back the leased connection of the stream once it is closed.
+type leasedStream struct {
+	*stream
+	release func(error)
+}
+
+func (s *leasedStream) Close() (err error) {
+	err = s.stream.Close()
+	if s.release != nil {
+		s.release(err)
+		s.release = nil
+	}
+
+	return
+}
+
+func streamResult(request *Request) (reader io.ReadCloser, err error) {
+	switch result := request.commands[0].result.(type) {
+	case *stream:
+		reader = result
+	case []byte:
+		reader = ioutil.NopCloser(bytes.NewReader(result))
+	case nil:
+	default:
+		err = fmt.Errorf("expecting a bulk string reply instead of %v", result)
+	}
+
+	return
+}
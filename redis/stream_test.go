@@ -0,0 +1,121 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	db.result.WriteString("$11\r\nhello world\r\n+PONG\r\n$-1\r\n")
+
+	reader, err := conn.DoStream("GET", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the next reply waits for the stream to be closed
+	done := make(chan struct{})
+	go func() {
+		if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+			t.Error(err, result)
+		}
+
+		close(done)
+	}()
+
+	buffer := make([]byte, 5)
+	if _, err := reader.Read(buffer); err != nil || string(buffer) != "hello" {
+		t.Fatal(err, string(buffer))
+	}
+
+	select {
+	case <-done:
+		t.Fatal("reply decoded before the stream was closed")
+	default:
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	<-done
+
+	if reader, err := conn.DoStream("GET", "bar"); err != nil || reader != nil {
+		t.Fatal(err, reader)
+	}
+}
+
+func TestStreamAll(t *testing.T) {
+	db := new(mockDB)
+	conn := &Conn{db: db}
+	defer conn.Close()
+
+	db.result.WriteString("$11\r\nhello world\r\n")
+
+	reader, err := conn.DoStream("GET", "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	if data, err := ioutil.ReadAll(reader); err != nil || string(data) != "hello world" {
+		t.Fatal(err, string(data))
+	}
+}
+
+func TestGetStream(t *testing.T) {
+	db := new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	node := client.nodes["tcp://127.0.0.1:6379"]
+	node.db = db
+
+	db.result.WriteString("$11\r\nhello world\r\n")
+
+	reader, err := client.GetStream("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, 5)
+	if _, err := reader.Read(buffer); err != nil || string(buffer) != "hello" {
+		t.Fatal(err, string(buffer))
+	}
+
+	// the stream is on a leased connection so the shared one isn't held up
+	db.result.WriteString("+PONG\r\n")
+
+	done := make(chan struct{})
+	go func() {
+		if result, err := client.Do("PING"); err != nil || result != "PONG" {
+			t.Error(err, result)
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expecting the reply before the stream is closed")
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(client.leases[node]); n != 1 {
+		t.Fatal("expecting the connection to be released", n)
+	}
+}
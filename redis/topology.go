@@ -0,0 +1,135 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+// Topology describes which nodes serve the slots of the cluster.
+// Epoch is incremented every time the client changes its mapping of the slots.
+type Topology struct {
+	Epoch   int64
+	Cluster bool
+	Ranges  []SlotRange
+}
+
+// SlotRange defines consecutive slots served by a master and its replicas, which are named by address e.g. tcp://127.0.0.1:6379.
+type SlotRange struct {
+	First    int
+	Last     int
+	Master   string
+	Replicas []string
+}
+
+type topologyChange struct {
+	last, next *mapping
+}
+
+type topologyListener struct {
+	f func(old, new Topology)
+}
+
+// Topology returns the current mapping of the slots of the client.
+func (client *Client) Topology() Topology {
+	return client.load().topology()
+}
+
+// OnTopologyChange calls the function with the previous and the new topology every time the mapping of the slots changes.
+// Functions are called in order on a separate goroutine so that they can use the client.
+func (client *Client) OnTopologyChange(f func(old, new Topology)) {
+	client.listen(f)
+}
+
+// listen registers the function called on every change of topology until it is passed to unlisten.
+func (client *Client) listen(f func(old, new Topology)) (listener *topologyListener) {
+	client.load()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	listener = &topologyListener{f}
+	client.listeners = append(client.listeners, listener)
+	return
+}
+
+// unlisten stops calling the function of the listener, which may still get a notification already under way.
+func (client *Client) unlisten(listener *topologyListener) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// notify may be iterating over the current slice
+	listeners := make([]*topologyListener, 0, len(client.listeners))
+	for _, l := range client.listeners {
+		if l != listener {
+			listeners = append(listeners, l)
+		}
+	}
+
+	client.listeners = listeners
+}
+
+// changed queues the notification of the change of mapping and must be called with the lock held.
+func (client *Client) changed(last, next *mapping) {
+	if len(client.listeners) == 0 {
+		return
+	}
+
+	client.changes = append(client.changes, topologyChange{last, next})
+	if !client.notifying {
+		client.notifying = true
+		go client.notify()
+	}
+}
+
+func (client *Client) notify() {
+	for {
+		client.mu.Lock()
+		if len(client.changes) == 0 {
+			client.notifying = false
+			client.mu.Unlock()
+			return
+		}
+
+		change := client.changes[0]
+		client.changes = client.changes[1:]
+		listeners := client.listeners
+		client.mu.Unlock()
+
+		last, next := change.last.topology(), change.next.topology()
+		for _, l := range listeners {
+			l.f(last, next)
+		}
+	}
+}
+
+func (state *mapping) topology() (result Topology) {
+	result.Epoch = state.id
+	result.Cluster = state.shards
+
+	var last *Conn
+	for slot := 0; slot < 16384; slot++ {
+		node := state.slots.get(slot)
+		if node == nil {
+			last = nil
+			continue
+		}
+
+		if node == last {
+			result.Ranges[len(result.Ranges)-1].Last = slot
+			continue
+		}
+
+		last = node
+
+		item := SlotRange{
+			First:  slot,
+			Last:   slot,
+			Master: node.address,
+		}
+
+		for _, replica := range state.followers[node] {
+			item.Replicas = append(item.Replicas, replica.address)
+		}
+
+		result.Ranges = append(result.Ranges, item)
+	}
+
+	return
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestTopologyChange(t *testing.T) {
+	db := new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	changes := make(chan [2]Topology, 1)
+	client.OnTopologyChange(func(old, new Topology) {
+		changes <- [2]Topology{old, new}
+	})
+
+	db.result.WriteString("*2\r\n*3\r\n:0\r\n:8191\r\n*2\r\n$9\r\n127.0.0.1\r\n:6379\r\n*4\r\n:8192\r\n:16383\r\n*2\r\n$9\r\n127.0.0.1\r\n:6380\r\n*2\r\n$9\r\n127.0.0.1\r\n:6381\r\n")
+	if _, err := client.migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	change := <-changes
+	if change[0].Cluster || len(change[0].Ranges) != 1 || change[0].Ranges[0].Last != 16383 {
+		t.Fatal(change[0])
+	}
+
+	ranges := change[1].Ranges
+	if !change[1].Cluster || change[1].Epoch != 1 || len(ranges) != 2 {
+		t.Fatal(change[1])
+	}
+
+	if ranges[0].Last != 8191 || ranges[1].First != 8192 || len(ranges[1].Replicas) != 1 || ranges[1].Master != "tcp://127.0.0.1:6380" || ranges[1].Replicas[0] != "tcp://127.0.0.1:6381" {
+		t.Fatal(ranges)
+	}
+
+	if topology := client.Topology(); topology.Epoch != 1 || len(topology.Ranges) != 2 {
+		t.Fatal(topology)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DefaultTraceSize defines the number of bytes of each frame written by the protocol trace before it is truncated.
+var DefaultTraceSize = 256
+
+// tracer writes the frames of a connection.
+type tracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Trace writes the RESP frames sent and received on the connection to the writer until called with nil.
+// Each frame is quoted on its own line, truncated to DefaultTraceSize bytes and the arguments of sensitive commands are redacted.
+// Received frames are the decoded replies encoded again, with those of DefaultRedactedReplies redacted, and failures without a reply aren't traced.
+func (conn *Conn) Trace(w io.Writer) {
+	if w == nil {
+		conn.tracer.Store((*tracer)(nil))
+		return
+	}
+
+	conn.tracer.Store(&tracer{w: w})
+}
+
+// tracing returns the current tracer or nil.
+func (conn *Conn) tracing() *tracer {
+	t, _ := conn.tracer.Load().(*tracer)
+	return t
+}
+
+// request writes the frames of the request like they are sent but with redacted arguments.
+func (t *tracer) request(address string, request *Request) {
+	var buffer bytes.Buffer
+	encoder := NewEncoder(&buffer)
+
+	for i := range request.commands {
+		cmd := &request.commands[i]
+		encoder.Encode(cmd.name, Redact(cmd.name, cmd.args)...)
+		t.frame(address, '>', buffer.Bytes())
+		buffer.Reset()
+	}
+}
+
+// reply writes a frame for the reply of each command of the request once decoded.
+func (t *tracer) reply(address string, request *Request) {
+	var buffer bytes.Buffer
+	for i := range request.commands {
+		cmd := &request.commands[i]
+
+		e, replied := cmd.err.(ReplyError)
+		switch {
+		case cmd.err != nil && !replied:
+			continue
+		case redactedReply(cmd):
+			writeReply(&buffer, []byte(redacted))
+		case replied:
+			fmt.Fprintf(&buffer, "-%s\r\n", string(e))
+		default:
+			writeReply(&buffer, cmd.result)
+		}
+
+		t.frame(address, '<', buffer.Bytes())
+		buffer.Reset()
+	}
+}
+
+// writeReply encodes the decoded reply like Redis sent it.
+func writeReply(w io.Writer, reply interface{}) {
+	switch reply := reply.(type) {
+	case nil:
+		fmt.Fprintf(w, "$-1\r\n")
+	case string:
+		if reply == OK {
+			fmt.Fprintf(w, "+OK\r\n")
+		} else {
+			fmt.Fprintf(w, "+%s\r\n", reply)
+		}
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", reply)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(reply), reply)
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(reply))
+		for _, item := range reply {
+			writeReply(w, item)
+		}
+	case ReplyError:
+		fmt.Fprintf(w, "-%s\r\n", string(reply))
+	default:
+		// e.g. a streamed bulk string that is read by the caller
+		fmt.Fprintf(w, "(%T)\r\n", reply)
+	}
+}
+
+// frame writes the data sent or received.
+func (t *tracer) frame(address string, direction byte, data []byte) {
+	n := len(data)
+	if n > DefaultTraceSize {
+		data = data[:DefaultTraceSize]
+	}
+
+	t.mu.Lock()
+	if n > len(data) {
+		fmt.Fprintf(t.w, "%s %c %q... (%d bytes)\n", address, direction, data, n)
+	} else {
+		fmt.Fprintf(t.w, "%s %c %q\n", address, direction, data)
+	}
+
+	t.mu.Unlock()
+}
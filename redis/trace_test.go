@@ -0,0 +1,68 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type syncBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.String()
+}
+
+func TestTrace(t *testing.T) {
+	db := new(mockDB)
+	db.result.WriteString("+OK\r\n*2\r\n$11\r\nrequirepass\r\n$6\r\nsecret\r\n:1\r\n+OK\r\n")
+
+	conn := &Conn{db: db, address: "tcp://node"}
+	defer conn.Close()
+
+	trace := new(syncBuffer)
+	conn.Trace(trace)
+
+	if _, err := conn.Do("CONFIG", "SET", "requirepass", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply, err := conn.Do("CONFIG", "GET", "requirepass"); err != nil || len(reply.([]interface{})) != 2 {
+		t.Fatal(reply, err)
+	}
+
+	if _, err := conn.Do("INCR", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	text := trace.String()
+	if strings.Contains(text, "secret") {
+		t.Fatalf("secret should be redacted:\n%s", text)
+	}
+
+	if !strings.Contains(text, `tcp://node > "*4\r\n$6\r\nCONFIG\r\n`) || !strings.Contains(text, `tcp://node < "+OK\r\n"`) || !strings.Contains(text, `tcp://node < ":1\r\n"`) {
+		t.Fatalf("unexpected trace:\n%s", text)
+	}
+
+	conn.Trace(nil)
+	if _, err := conn.Do("PING"); err != nil {
+		t.Fatal(err)
+	}
+
+	if trace.String() != text {
+		t.Fatal("trace should be disabled")
+	}
+}
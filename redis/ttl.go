@@ -0,0 +1,90 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"time"
+)
+
+// TTL defines the time to live of a key.
+// Exists is false when there is no key and Persistent is true when the key has no expiry, in which case Remaining is 0.
+type TTL struct {
+	Exists     bool
+	Persistent bool
+	Remaining  time.Duration
+}
+
+// Expire sets the time to live of the key with millisecond precision and returns false when there is no key.
+// A duration that isn't positive deletes the key like Redis does.
+func (client *Client) Expire(key string, ttl time.Duration) (ok bool, err error) {
+	return client.flag("PEXPIRE", key, int64(ttl/time.Millisecond))
+}
+
+// ExpireAt sets the time when the key expires with millisecond precision and returns false when there is no key.
+func (client *Client) ExpireAt(key string, at time.Time) (ok bool, err error) {
+	return client.flag("PEXPIREAT", key, at.UnixNano()/int64(time.Millisecond))
+}
+
+// Persist removes the expiry of the key and returns false when there is no key or it had no expiry.
+func (client *Client) Persist(key string) (ok bool, err error) {
+	return client.flag("PERSIST", key)
+}
+
+// TTL returns the time to live of the key with second precision.
+func (client *Client) TTL(key string) (TTL, error) {
+	return client.ttl("TTL", key, time.Second)
+}
+
+// PTTL returns the time to live of the key with millisecond precision.
+func (client *Client) PTTL(key string) (TTL, error) {
+	return client.ttl("PTTL", key, time.Millisecond)
+}
+
+// GetEx returns the value of the key and sets its time to live with millisecond precision, or removes its expiry when the duration is 0.
+// It returns false when there is no key. This requires Redis 6.2.
+func (client *Client) GetEx(key string, ttl time.Duration) (value []byte, ok bool, err error) {
+	args := []interface{}{key, "PX", int64(ttl / time.Millisecond)}
+	if ttl == 0 {
+		args = []interface{}{key, "PERSIST"}
+	}
+
+	return client.value("GETEX", args...)
+}
+
+// GetDel returns the value of the key and deletes it or false when there is no key. This requires Redis 6.2.
+func (client *Client) GetDel(key string) (value []byte, ok bool, err error) {
+	return client.value("GETDEL", key)
+}
+
+// flag sends a command replying 1 or 0.
+func (client *Client) flag(name string, args ...interface{}) (ok bool, err error) {
+	result, err := client.Do(name, args...)
+	n, _ := result.(int64)
+	ok = n == 1
+	return
+}
+
+// value sends a command replying a bulk string or nil when there is no key.
+func (client *Client) value(name string, args ...interface{}) (value []byte, ok bool, err error) {
+	result, err := client.Do(name, args...)
+	value, ok = result.([]byte)
+	return
+}
+
+func (client *Client) ttl(name, key string, unit time.Duration) (result TTL, err error) {
+	reply, err := client.Do(name, key)
+	if err != nil {
+		return
+	}
+
+	// -2 when there is no key and -1 when it has no expiry
+	switch n, _ := reply.(int64); {
+	case n == -2:
+	case n == -1:
+		result.Exists, result.Persistent = true, true
+	default:
+		result.Exists, result.Remaining = true, time.Duration(n)*unit
+	}
+
+	return
+}
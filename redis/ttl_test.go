@@ -0,0 +1,64 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	client := &Client{}
+	defer client.Close()
+
+	client.load()
+
+	db := new(mockDB)
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+	db.result.WriteString(":1\r\n:0\r\n:-2\r\n:-1\r\n:1500\r\n:3\r\n$3\r\nbar\r\n$-1\r\n")
+
+	if ok, err := client.Expire("foo", 1500*time.Millisecond); err != nil || !ok {
+		t.Fatal(ok, err)
+	}
+
+	if ok, err := client.ExpireAt("missing", time.Unix(1500000000, 250000000)); err != nil || ok {
+		t.Fatal(ok, err)
+	}
+
+	tests := []struct {
+		f        func(string) (TTL, error)
+		expected TTL
+	}{
+		{client.PTTL, TTL{}},
+		{client.PTTL, TTL{Exists: true, Persistent: true}},
+		{client.PTTL, TTL{Exists: true, Remaining: 1500 * time.Millisecond}},
+		{client.TTL, TTL{Exists: true, Remaining: 3 * time.Second}},
+	}
+
+	for i, test := range tests {
+		if ttl, err := test.f("foo"); err != nil || ttl != test.expected {
+			t.Fatal(i, ttl, err)
+		}
+	}
+
+	if value, ok, err := client.GetEx("foo", time.Minute); err != nil || !ok || string(value) != "bar" {
+		t.Fatal(value, ok, err)
+	}
+
+	if value, ok, err := client.GetDel("missing"); err != nil || ok || value != nil {
+		t.Fatal(value, ok, err)
+	}
+
+	// durations and times are sent as milliseconds
+	sent := db.written()
+	for _, expected := range []string{
+		"*3\r\n$7\r\nPEXPIRE\r\n$3\r\nfoo\r\n$4\r\n1500\r\n",
+		"*3\r\n$9\r\nPEXPIREAT\r\n$7\r\nmissing\r\n$13\r\n1500000000250\r\n",
+		"*4\r\n$5\r\nGETEX\r\n$3\r\nfoo\r\n$2\r\nPX\r\n$5\r\n60000\r\n",
+	} {
+		if !strings.Contains(sent, expected) {
+			t.Fatalf("expecting %q in %q", expected, sent)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaximumIdleTransactions defines the default number of idle connections kept for transactions on each node.
+var DefaultMaximumIdleTransactions = 4
+
+// ErrTxAborted is returned when a transaction isn't executed because a watched key was modified.
+var ErrTxAborted = errors.New("transaction aborted by a watched key")
+
+// Tx defines a transaction running on a connection leased from its node so that WATCH, MULTI and EXEC stay together.
+// Its keys must belong to the slot of the key of the transaction.
+type Tx struct {
+	client *Client
+	conn   *Conn
+	key    string
+	slot   int
+	shards bool
+	queued Request
+}
+
+// Do executes the command right away on the connection of the transaction e.g. WATCH or the reads deciding what to queue.
+func (tx *Tx) Do(name string, args ...interface{}) (result interface{}, err error) {
+	if err = tx.check(name, args); err != nil {
+		return
+	}
+
+	request := NewRequest(name, args...)
+	if err = tx.client.sendNode(tx.conn, request); err == nil {
+		result = request.commands[0].result
+	}
+
+	return
+}
+
+// Queue adds the command to those executed atomically on EXEC.
+func (tx *Tx) Queue(name string, args ...interface{}) (err error) {
+	if err = tx.check(name, args); err == nil {
+		tx.queued.Add(name, args...)
+	}
+
+	return
+}
+
+// check returns CrossSlotError when a key of the command belongs to another slot than the key of the transaction.
+func (tx *Tx) check(name string, args []interface{}) error {
+	if !tx.shards {
+		return nil
+	}
+
+	cmd := command{
+		name: name,
+		args: args,
+	}
+
+	e := &CrossSlotError{
+		Keys:  []string{tx.key},
+		Slots: []int{tx.slot},
+	}
+
+	for _, i := range cmd.keys() {
+		key := argString(args[i])
+		e.Keys = append(e.Keys, key)
+		e.Slots = append(e.Slots, Slot(key))
+	}
+
+	return e.check()
+}
+
+// Transaction calls the function to prepare a transaction on the slot of the key then executes the queued commands with MULTI and EXEC.
+// It returns the result of each queued command or ErrTxAborted when a watched key was modified.
+// When the slot moved, the function is called once more to run the transaction again on the node now serving it.
+func (client *Client) Transaction(key string, f func(tx *Tx) error) (results []interface{}, err error) {
+	state := client.load()
+
+	slot := 0
+	if state.shards {
+		slot = Slot(key)
+	}
+
+	node := state.slots.get(slot)
+	if node == nil {
+		err = fmt.Errorf("no node serving slot %d", slot)
+		return
+	}
+
+	for attempt := 0; ; attempt++ {
+		if results, err = client.transaction(node, key, slot, state.shards, f); attempt != 0 {
+			return
+		}
+
+		if node, err = client.moved(slot, err); node == nil {
+			return
+		}
+
+		state = client.load()
+	}
+}
+
+// moved follows the slot to the node named by a MOVED error, which is returned as is when it isn't one.
+// Requests sent on leased connections don't go through the redirections of the client.
+func (client *Client) moved(slot int, err error) (node *Conn, e error) {
+	reply, ok := err.(ReplyError)
+	if e = err; !ok || !strings.HasPrefix(string(reply), "MOVED") {
+		return
+	}
+
+	request := &Request{
+		address: "tcp://" + string(reply[strings.LastIndex(string(reply), " ")+1:]),
+	}
+
+	if _, node, e = client.redirect(request); e != nil {
+		node = nil
+		return
+	}
+
+	if _, e = client.update(slot, node); e != nil {
+		node = nil
+	}
+
+	return
+}
+
+func (client *Client) transaction(node *Conn, key string, slot int, shards bool, f func(tx *Tx) error) (results []interface{}, err error) {
+	tx := &Tx{
+		client: client,
+		conn:   client.lease(node),
+		key:    key,
+		slot:   slot,
+		shards: shards,
+	}
+
+	defer func() {
+		client.release(node, tx.conn, err)
+	}()
+
+	if err = f(tx); err != nil {
+		return
+	}
+
+	request := NewRequest("MULTI")
+	request.commands = append(request.commands, tx.queued.commands...)
+	request.Add("EXEC")
+
+	if err = client.sendNode(tx.conn, request); err != nil {
+		// a command refused when queued, like MOVED, aborts the transaction and says why better than EXEC
+		for i := 1; i < len(request.commands)-1; i++ {
+			if e := request.commands[i].err; e != nil {
+				err = e
+				break
+			}
+		}
+
+		return
+	}
+
+	exec := request.commands[len(request.commands)-1].result
+	if exec == nil {
+		err = ErrTxAborted
+		return
+	}
+
+	results, _ = exec.([]interface{})
+	return
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	db := new(mockDB)
+
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n$1\r\n1\r\n")
+	db.result.WriteString("+OK\r\n+QUEUED\r\n+QUEUED\r\n*2\r\n+OK\r\n:2\r\n")
+
+	results, err := client.Transaction("a", func(tx *Tx) (err error) {
+		if _, err = tx.Do("WATCH", "a"); err != nil {
+			return
+		}
+
+		value, err := tx.Do("GET", "a")
+		if err != nil {
+			return
+		}
+
+		if err = tx.Queue("SET", "a", string(value.([]byte))+"0"); err != nil {
+			return
+		}
+
+		return tx.Queue("INCR", "b")
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0] != OK || results[1] != int64(2) {
+		t.Fatal(results)
+	}
+
+	// the watched key was modified so EXEC replies with nil and the connection gets UNWATCH before being reused
+	db.result.WriteString("+OK\r\n+QUEUED\r\n*-1\r\n+OK\r\n")
+
+	if _, err = client.Transaction("a", func(tx *Tx) error {
+		return tx.Queue("SET", "a", "1")
+	}); err != ErrTxAborted {
+		t.Fatal(err)
+	}
+
+	if n := len(client.leases); n != 1 {
+		t.Fatal(n)
+	}
+}
+
+func TestTransactionSlot(t *testing.T) {
+	tx := &Tx{
+		key:    "{user}.name",
+		slot:   Slot("{user}.name"),
+		shards: true,
+	}
+
+	if err := tx.Queue("SET", "{user}.age", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := tx.Queue("SET", "other", "1")
+	if e, ok := err.(*CrossSlotError); !ok || len(e.Keys) != 2 || e.Keys[1] != "other" {
+		t.Fatal(err)
+	}
+
+	if n := len(tx.queued.commands); n != 1 {
+		t.Fatal(n)
+	}
+}
+
+func TestTransactionUnmappedSlot(t *testing.T) {
+	state := &mapping{
+		shards: true,
+		nodes: map[string]*Conn{
+			"tcp://127.0.0.1:7000": {db: new(mockDB)},
+		},
+	}
+
+	state.slots.fill(0, Slot("a")-1, state.nodes["tcp://127.0.0.1:7000"])
+
+	client := &Client{
+		nodes: state.nodes,
+	}
+
+	client.once.Do(func() {})
+	client.state.Store(state)
+	defer client.Close()
+
+	if _, err := client.Transaction("a", func(tx *Tx) error {
+		return tx.Queue("SET", "a", "1")
+	}); err == nil {
+		t.Fatal("expecting an error for an unmapped slot")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAddressOptions(t *testing.T) {
+	conn := new(Conn)
+	if err := conn.configure("tcp://:secret@127.0.0.1:6379?dial_timeout=200ms&read_timeout=1s&db=2&pool_size=8&retry_timeout=5ms", dialOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.database != 2 || conn.MaximumConcurrentRequests != 8 || conn.RetryTimeout != 5*time.Millisecond {
+		t.Fatal(conn.database, conn.MaximumConcurrentRequests, conn.RetryTimeout)
+	}
+
+	if conn.Credentials != (StaticCredentials{Password: "secret"}) {
+		t.Fatal(conn.Credentials)
+	}
+
+	if conn.address != "tcp://127.0.0.1:6379" {
+		t.Fatal(conn.address)
+	}
+
+	for _, address := range []string{"tcp://127.0.0.1:6379?db=x", "tcp://127.0.0.1:6379?unknown=1"} {
+		if err := new(Conn).configure(address, dialOptions{}); err == nil {
+			t.Fatal("expecting an error", address)
+		}
+	}
+}
+
+func TestNodeAddress(t *testing.T) {
+	client := &Client{
+		Address: []string{"tcp://127.0.0.1:7000?dial_timeout=1s"},
+	}
+
+	if address := client.nodeAddress("tcp://127.0.0.1:7001"); address != "tcp://127.0.0.1:7001?dial_timeout=1s" {
+		t.Fatal(address)
+	}
+
+	client.load()
+	defer client.Close()
+
+	if client.Node("tcp://127.0.0.1:7000"); len(client.nodes) != 1 {
+		t.Fatal("nodes are named without their options")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	node := &Conn{db: new(mockDB)}
+
+	client := &Client{
+		nodes: map[string]*Conn{
+			"tcp://localhost:6379": node,
+		},
+	}
+
+	if name := client.canonical("tcp://127.0.0.1:6379"); name != "tcp://localhost:6379" {
+		t.Fatal(name)
+	}
+
+	if name := client.canonical("tcp://127.0.0.1:6380"); name != "tcp://127.0.0.1:6380" {
+		t.Fatal(name)
+	}
+
+	if conn := client.lookup("tcp://127.0.0.1:6379"); conn != node || len(client.nodes) != 1 {
+		t.Fatal("expecting the known node")
+	}
+
+	// hostnames are never looked up with the lock held
+	client.mu.Lock()
+	name := client.alias("tcp://localhost:6380")
+	client.mu.Unlock()
+
+	if name != "tcp://localhost:6380" || client.resolved("tcp://localhost:6380") != nil {
+		t.Fatal(name)
+	}
+
+	if addresses := client.resolved("tcp://localhost:6379"); len(addresses) == 0 {
+		t.Fatal("expecting the resolved addresses to be kept")
+	}
+}
+
+func TestSocketOptions(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer c.Close()
+
+		buffer := make([]byte, 14)
+		if _, err := io.ReadFull(c, buffer); err == nil {
+			c.Write([]byte("+PONG\r\n"))
+		}
+	}()
+
+	conn := new(Conn)
+	conn.mustConfigure("tcp://"+listener.Addr().String()+"?keepalive=10s&read_buffer=65536&write_buffer=65536", dialOptions{
+		delayWrites: true,
+	})
+
+	defer conn.Close()
+
+	if result, err := conn.Do("PING"); err != nil || result != "PONG" {
+		t.Fatal(result, err)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// DoWithWait executes the specified command followed by WAIT on the same connection.
+// It returns the reply of the command and the number of replicas that acknowledged the write within the timeout.
+// A timeout of 0 blocks until enough replicas acknowledged it.
+func (client *Client) DoWithWait(replicas int, timeout time.Duration, name string, args ...interface{}) (result interface{}, acknowledged int, err error) {
+	request := newRequest(name, args)
+	defer release(request)
+
+	request.Add("WAIT", replicas, int64(timeout/time.Millisecond))
+	if err = client.Send(request); err != nil {
+		return
+	}
+
+	result = request.commands[0].result
+
+	n, ok := request.commands[1].result.(int64)
+	if !ok {
+		err = fmt.Errorf("unexpected WAIT reply '%v'", request.commands[1].result)
+		return
+	}
+
+	acknowledged = int(n)
+	return
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDoWithWait(t *testing.T) {
+	db := new(mockDB)
+	client := new(Client)
+	defer client.Close()
+
+	client.load()
+	client.nodes["tcp://127.0.0.1:6379"].db = db
+
+	db.result.WriteString("+OK\r\n:1\r\n")
+	result, n, err := client.DoWithWait(2, 100*time.Millisecond, "SET", "a", "1")
+	if err != nil || result != OK || n != 1 {
+		t.Fatal(result, n, err)
+	}
+}
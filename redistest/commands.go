@@ -0,0 +1,835 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+// entry holds a value of type []byte, hash, list or set with its expiration time.
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+type hash map[string][]byte
+
+type list [][]byte
+
+type set map[string]struct{}
+
+// command defines the minimum number of arguments of a command and how many can follow.
+// When variadic is 0, the command takes exactly arity arguments while extra arguments come in groups of variadic otherwise.
+type command struct {
+	arity    int
+	variadic int
+	f        func(server *Server, args [][]byte) interface{}
+}
+
+var commands = map[string]command{
+	"PING":      {0, 1, ping},
+	"ECHO":      {1, 0, echo},
+	"SELECT":    {1, 0, accept},
+	"AUTH":      {1, 1, accept},
+	"ASKING":    {0, 0, accept},
+	"READONLY":  {0, 0, accept},
+	"DEL":       {1, 1, del},
+	"UNLINK":    {1, 1, del},
+	"EXISTS":    {1, 1, exists},
+	"TYPE":      {1, 0, typeOf},
+	"KEYS":      {1, 0, keys},
+	"DBSIZE":    {0, 0, dbsize},
+	"FLUSHALL":  {0, 1, flush},
+	"FLUSHDB":   {0, 1, flush},
+	"EXPIRE":    {2, 0, expire(time.Second)},
+	"PEXPIRE":   {2, 0, expire(time.Millisecond)},
+	"TTL":       {1, 0, ttl(time.Second)},
+	"PTTL":      {1, 0, ttl(time.Millisecond)},
+	"PERSIST":   {1, 0, persist},
+	"GET":       {1, 0, get},
+	"SET":       {2, 1, setString},
+	"SETNX":     {2, 0, setnx},
+	"GETSET":    {2, 0, getset},
+	"MGET":      {1, 1, mget},
+	"MSET":      {2, 2, mset},
+	"INCR":      {1, 0, incr(1)},
+	"DECR":      {1, 0, incr(-1)},
+	"INCRBY":    {2, 0, incr(0)},
+	"DECRBY":    {2, 0, decrby},
+	"APPEND":    {2, 0, appendString},
+	"STRLEN":    {1, 0, strlen},
+	"HSET":      {3, 2, hset},
+	"HGET":      {2, 0, hget},
+	"HMGET":     {2, 1, hmget},
+	"HDEL":      {2, 1, hdel},
+	"HEXISTS":   {2, 0, hexists},
+	"HGETALL":   {1, 0, hgetall},
+	"HKEYS":     {1, 0, hkeys},
+	"HLEN":      {1, 0, hlen},
+	"HINCRBY":   {3, 0, hincrby},
+	"LPUSH":     {2, 1, push(true)},
+	"RPUSH":     {2, 1, push(false)},
+	"LPOP":      {1, 0, pop(true)},
+	"RPOP":      {1, 0, pop(false)},
+	"LLEN":      {1, 0, llen},
+	"LRANGE":    {3, 0, lrange},
+	"LINDEX":    {2, 0, lindex},
+	"SADD":      {2, 1, sadd},
+	"SREM":      {2, 1, srem},
+	"SMEMBERS":  {1, 0, smembers},
+	"SISMEMBER": {2, 0, sismember},
+	"SCARD":     {1, 0, scard},
+	"CLUSTER":   {1, 1, cluster},
+}
+
+// lookup returns the entry of the key unless it expired.
+func (server *Server) lookup(key []byte) *entry {
+	e, ok := server.data[string(key)]
+	if !ok {
+		return nil
+	}
+
+	if !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		delete(server.data, string(key))
+		return nil
+	}
+
+	return e
+}
+
+// value returns the value of the key or creates it with the function when missing.
+// It returns nil when the key holds a value of another type.
+func (server *Server) value(key []byte, create func() interface{}) interface{} {
+	e := server.lookup(key)
+	if e == nil {
+		if create == nil {
+			return nil
+		}
+
+		e = &entry{value: create()}
+		server.data[string(key)] = e
+	}
+
+	return e.value
+}
+
+// clean removes the key when its collection is empty.
+func (server *Server) clean(key []byte, n int) {
+	if n == 0 {
+		delete(server.data, string(key))
+	}
+}
+
+func ping(server *Server, args [][]byte) interface{} {
+	if len(args) != 0 {
+		return args[0]
+	}
+
+	return status("PONG")
+}
+
+func echo(server *Server, args [][]byte) interface{} {
+	return args[0]
+}
+
+func accept(server *Server, args [][]byte) interface{} {
+	return ok
+}
+
+func del(server *Server, args [][]byte) interface{} {
+	n := 0
+	for _, key := range args {
+		if server.lookup(key) != nil {
+			delete(server.data, string(key))
+			n++
+		}
+	}
+
+	return n
+}
+
+func exists(server *Server, args [][]byte) interface{} {
+	n := 0
+	for _, key := range args {
+		if server.lookup(key) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func typeOf(server *Server, args [][]byte) interface{} {
+	e := server.lookup(args[0])
+	if e == nil {
+		return status("none")
+	}
+
+	switch e.value.(type) {
+	case hash:
+		return status("hash")
+	case list:
+		return status("list")
+	case set:
+		return status("set")
+	}
+
+	return status("string")
+}
+
+func keys(server *Server, args [][]byte) interface{} {
+	var names []string
+	for name := range server.data {
+		if match, _ := path.Match(string(args[0]), name); match && server.lookup([]byte(name)) != nil {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return stringArray(names)
+}
+
+func dbsize(server *Server, args [][]byte) interface{} {
+	n := 0
+	for name := range server.data {
+		if server.lookup([]byte(name)) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func flush(server *Server, args [][]byte) interface{} {
+	server.data = make(map[string]*entry)
+	return ok
+}
+
+func expire(unit time.Duration) func(server *Server, args [][]byte) interface{} {
+	return func(server *Server, args [][]byte) interface{} {
+		n, valid := parseInt(args[1])
+		if !valid {
+			return notInt
+		}
+
+		e := server.lookup(args[0])
+		if e == nil {
+			return 0
+		}
+
+		e.expires = time.Now().Add(time.Duration(n) * unit)
+		return 1
+	}
+}
+
+func ttl(unit time.Duration) func(server *Server, args [][]byte) interface{} {
+	return func(server *Server, args [][]byte) interface{} {
+		e := server.lookup(args[0])
+		switch {
+		case e == nil:
+			return -2
+		case e.expires.IsZero():
+			return -1
+		}
+
+		return int64((e.expires.Sub(time.Now()) + unit - 1) / unit)
+	}
+}
+
+func persist(server *Server, args [][]byte) interface{} {
+	e := server.lookup(args[0])
+	if e == nil || e.expires.IsZero() {
+		return 0
+	}
+
+	e.expires = time.Time{}
+	return 1
+}
+
+func get(server *Server, args [][]byte) interface{} {
+	e := server.lookup(args[0])
+	if e == nil {
+		return nil
+	}
+
+	value, isString := e.value.([]byte)
+	if !isString {
+		return wrongType
+	}
+
+	return value
+}
+
+func setString(server *Server, args [][]byte) interface{} {
+	var expires time.Time
+	nx, xx := false, false
+
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToUpper(string(args[i])); option {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i++; i == len(args) {
+				return syntax
+			}
+
+			n, valid := parseInt(args[i])
+			if !valid || n <= 0 {
+				return replyError("ERR invalid expire time in 'set' command")
+			}
+
+			unit := time.Second
+			if option == "PX" {
+				unit = time.Millisecond
+			}
+
+			expires = time.Now().Add(time.Duration(n) * unit)
+		default:
+			return syntax
+		}
+	}
+
+	exists := server.lookup(args[0]) != nil
+	if nx && exists || xx && !exists {
+		return nil
+	}
+
+	server.data[string(args[0])] = &entry{
+		value:   copyBytes(args[1]),
+		expires: expires,
+	}
+
+	return ok
+}
+
+func setnx(server *Server, args [][]byte) interface{} {
+	if server.lookup(args[0]) != nil {
+		return 0
+	}
+
+	server.data[string(args[0])] = &entry{value: copyBytes(args[1])}
+	return 1
+}
+
+func getset(server *Server, args [][]byte) interface{} {
+	last := get(server, args[:1])
+	if _, isError := last.(replyError); isError {
+		return last
+	}
+
+	server.data[string(args[0])] = &entry{value: copyBytes(args[1])}
+	return last
+}
+
+func mget(server *Server, args [][]byte) interface{} {
+	result := make([]interface{}, len(args))
+	for i, key := range args {
+		if e := server.lookup(key); e != nil {
+			if value, isString := e.value.([]byte); isString {
+				result[i] = value
+			}
+		}
+	}
+
+	return result
+}
+
+func mset(server *Server, args [][]byte) interface{} {
+	for i := 0; i < len(args); i += 2 {
+		server.data[string(args[i])] = &entry{value: copyBytes(args[i+1])}
+	}
+
+	return ok
+}
+
+func incr(by int64) func(server *Server, args [][]byte) interface{} {
+	return func(server *Server, args [][]byte) interface{} {
+		delta := by
+		if len(args) > 1 {
+			n, valid := parseInt(args[1])
+			if !valid {
+				return notInt
+			}
+
+			delta = n
+		}
+
+		return server.increment(args[0], delta)
+	}
+}
+
+func decrby(server *Server, args [][]byte) interface{} {
+	n, valid := parseInt(args[1])
+	if !valid {
+		return notInt
+	}
+
+	return server.increment(args[0], -n)
+}
+
+func (server *Server) increment(key []byte, delta int64) interface{} {
+	n := int64(0)
+
+	e := server.lookup(key)
+	if e != nil {
+		value, isString := e.value.([]byte)
+		if !isString {
+			return wrongType
+		}
+
+		var valid bool
+		if n, valid = parseInt(value); !valid {
+			return notInt
+		}
+	} else {
+		e = &entry{}
+		server.data[string(key)] = e
+	}
+
+	n += delta
+	e.value = []byte(strconv.FormatInt(n, 10))
+	return n
+}
+
+func appendString(server *Server, args [][]byte) interface{} {
+	value, isString := server.value(args[0], func() interface{} { return []byte{} }).([]byte)
+	if !isString {
+		return wrongType
+	}
+
+	value = append(copyBytes(value), args[1]...)
+	server.data[string(args[0])].value = value
+	return len(value)
+}
+
+func strlen(server *Server, args [][]byte) interface{} {
+	value := get(server, args)
+	if s, isString := value.([]byte); isString {
+		return len(s)
+	}
+
+	if value == nil {
+		return 0
+	}
+
+	return value
+}
+
+func hset(server *Server, args [][]byte) interface{} {
+	h, isHash := server.value(args[0], func() interface{} { return hash{} }).(hash)
+	if !isHash {
+		return wrongType
+	}
+
+	n := 0
+	for i := 1; i < len(args); i += 2 {
+		if _, exists := h[string(args[i])]; !exists {
+			n++
+		}
+
+		h[string(args[i])] = copyBytes(args[i+1])
+	}
+
+	return n
+}
+
+// hashOf returns the hash of the key, an empty hash when it doesn't exist or nil when the key isn't a hash.
+func (server *Server) hashOf(key []byte) hash {
+	value := server.value(key, nil)
+	if value == nil {
+		return hash{}
+	}
+
+	h, _ := value.(hash)
+	return h
+}
+
+func hget(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	if value, exists := h[string(args[1])]; exists {
+		return value
+	}
+
+	return nil
+}
+
+func hmget(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	result := make([]interface{}, len(args)-1)
+	for i, field := range args[1:] {
+		if value, exists := h[string(field)]; exists {
+			result[i] = value
+		}
+	}
+
+	return result
+}
+
+func hdel(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	n := 0
+	for _, field := range args[1:] {
+		if _, exists := h[string(field)]; exists {
+			delete(h, string(field))
+			n++
+		}
+	}
+
+	server.clean(args[0], len(h))
+	return n
+}
+
+func hexists(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	if _, exists := h[string(args[1])]; exists {
+		return 1
+	}
+
+	return 0
+}
+
+func hgetall(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	result := []interface{}{}
+	for _, field := range sortedKeys(h) {
+		result = append(result, field, h[field])
+	}
+
+	return result
+}
+
+func hkeys(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	return stringArray(sortedKeys(h))
+}
+
+func hlen(server *Server, args [][]byte) interface{} {
+	h := server.hashOf(args[0])
+	if h == nil {
+		return wrongType
+	}
+
+	return len(h)
+}
+
+func hincrby(server *Server, args [][]byte) interface{} {
+	delta, valid := parseInt(args[2])
+	if !valid {
+		return notInt
+	}
+
+	h, isHash := server.value(args[0], func() interface{} { return hash{} }).(hash)
+	if !isHash {
+		return wrongType
+	}
+
+	n := int64(0)
+	if value, exists := h[string(args[1])]; exists {
+		if n, valid = parseInt(value); !valid {
+			return replyError("ERR hash value is not an integer")
+		}
+	}
+
+	n += delta
+	h[string(args[1])] = []byte(strconv.FormatInt(n, 10))
+	return n
+}
+
+func push(left bool) func(server *Server, args [][]byte) interface{} {
+	return func(server *Server, args [][]byte) interface{} {
+		l, isList := server.value(args[0], func() interface{} { return list{} }).(list)
+		if !isList {
+			return wrongType
+		}
+
+		for _, value := range args[1:] {
+			if left {
+				l = append(list{copyBytes(value)}, l...)
+			} else {
+				l = append(l, copyBytes(value))
+			}
+		}
+
+		server.data[string(args[0])].value = l
+		return len(l)
+	}
+}
+
+// listOf returns the list of the key, an empty list when it doesn't exist or nil when the key isn't a list.
+func (server *Server) listOf(key []byte) list {
+	value := server.value(key, nil)
+	if value == nil {
+		return list{}
+	}
+
+	l, _ := value.(list)
+	return l
+}
+
+func pop(left bool) func(server *Server, args [][]byte) interface{} {
+	return func(server *Server, args [][]byte) interface{} {
+		l := server.listOf(args[0])
+		switch {
+		case l == nil:
+			return wrongType
+		case len(l) == 0:
+			return nil
+		}
+
+		var value []byte
+		if left {
+			value, l = l[0], l[1:]
+		} else {
+			value, l = l[len(l)-1], l[:len(l)-1]
+		}
+
+		if len(l) == 0 {
+			server.clean(args[0], 0)
+		} else {
+			server.data[string(args[0])].value = l
+		}
+
+		return value
+	}
+}
+
+func llen(server *Server, args [][]byte) interface{} {
+	l := server.listOf(args[0])
+	if l == nil {
+		return wrongType
+	}
+
+	return len(l)
+}
+
+func lrange(server *Server, args [][]byte) interface{} {
+	start, valid := parseInt(args[1])
+	if !valid {
+		return notInt
+	}
+
+	stop, valid := parseInt(args[2])
+	if !valid {
+		return notInt
+	}
+
+	l := server.listOf(args[0])
+	if l == nil {
+		return wrongType
+	}
+
+	// negative positions count from the end
+	n := int64(len(l))
+	if start < 0 {
+		start += n
+	}
+
+	if stop < 0 {
+		stop += n
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= n {
+		stop = n - 1
+	}
+
+	result := []interface{}{}
+	for i := start; i <= stop; i++ {
+		result = append(result, l[i])
+	}
+
+	return result
+}
+
+func lindex(server *Server, args [][]byte) interface{} {
+	i, valid := parseInt(args[1])
+	if !valid {
+		return notInt
+	}
+
+	l := server.listOf(args[0])
+	if l == nil {
+		return wrongType
+	}
+
+	if i < 0 {
+		i += int64(len(l))
+	}
+
+	if i < 0 || i >= int64(len(l)) {
+		return nil
+	}
+
+	return l[i]
+}
+
+func sadd(server *Server, args [][]byte) interface{} {
+	s, isSet := server.value(args[0], func() interface{} { return set{} }).(set)
+	if !isSet {
+		return wrongType
+	}
+
+	n := 0
+	for _, member := range args[1:] {
+		if _, exists := s[string(member)]; !exists {
+			s[string(member)] = struct{}{}
+			n++
+		}
+	}
+
+	return n
+}
+
+// setOf returns the set of the key, an empty set when it doesn't exist or nil when the key isn't a set.
+func (server *Server) setOf(key []byte) set {
+	value := server.value(key, nil)
+	if value == nil {
+		return set{}
+	}
+
+	s, _ := value.(set)
+	return s
+}
+
+func srem(server *Server, args [][]byte) interface{} {
+	s := server.setOf(args[0])
+	if s == nil {
+		return wrongType
+	}
+
+	n := 0
+	for _, member := range args[1:] {
+		if _, exists := s[string(member)]; exists {
+			delete(s, string(member))
+			n++
+		}
+	}
+
+	server.clean(args[0], len(s))
+	return n
+}
+
+func smembers(server *Server, args [][]byte) interface{} {
+	s := server.setOf(args[0])
+	if s == nil {
+		return wrongType
+	}
+
+	names := make([]string, 0, len(s))
+	for member := range s {
+		names = append(names, member)
+	}
+
+	sort.Strings(names)
+	return stringArray(names)
+}
+
+func sismember(server *Server, args [][]byte) interface{} {
+	s := server.setOf(args[0])
+	if s == nil {
+		return wrongType
+	}
+
+	if _, exists := s[string(args[1])]; exists {
+		return 1
+	}
+
+	return 0
+}
+
+func scard(server *Server, args [][]byte) interface{} {
+	s := server.setOf(args[0])
+	if s == nil {
+		return wrongType
+	}
+
+	return len(s)
+}
+
+func cluster(server *Server, args [][]byte) interface{} {
+	switch strings.ToUpper(string(args[0])) {
+	case "SLOTS":
+		slots := server.slots
+		if len(slots) == 0 {
+			slots = []slotRange{{0, 16383, server}}
+		}
+
+		result := make([]interface{}, len(slots))
+		for i, item := range slots {
+			host, port, _ := net.SplitHostPort(item.owner.Host())
+			n, _ := strconv.Atoi(port)
+			result[i] = []interface{}{item.first, item.last, []interface{}{host, n, item.owner.id}}
+		}
+
+		return result
+	case "KEYSLOT":
+		if len(args) != 2 {
+			return replyError("ERR wrong number of arguments for 'cluster|keyslot' command")
+		}
+
+		return redis.Slot(string(args[1]))
+	case "MYID":
+		return server.id
+	}
+
+	return replyError("ERR unknown subcommand '" + string(args[0]) + "'")
+}
+
+func copyBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
+
+func sortedKeys(h hash) []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func stringArray(items []string) []interface{} {
+	result := make([]interface{}, len(items))
+	for i := range items {
+		result[i] = items[i]
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fault defines how a command fails when injected in the server.
+type Fault struct {
+	// Error is replied instead of executing the command when not empty.
+	Error string
+
+	// Delay is the time waited before replying, which lets clients time out.
+	Delay time.Duration
+
+	// Drop closes the connection instead of replying.
+	Drop bool
+}
+
+// Moved returns the fault of a node that doesn't serve the slot anymore.
+func Moved(slot int, host string) Fault {
+	return Fault{
+		Error: fmt.Sprintf("MOVED %d %s", slot, host),
+	}
+}
+
+// Ask returns the fault of a node migrating the slot to another node.
+func Ask(slot int, host string) Fault {
+	return Fault{
+		Error: fmt.Sprintf("ASK %d %s", slot, host),
+	}
+}
+
+// Timeout returns the fault of a node that is slow to reply.
+func Timeout(delay time.Duration) Fault {
+	return Fault{
+		Delay: delay,
+	}
+}
+
+type injection struct {
+	command string
+	count   int
+	fault   Fault
+}
+
+// Inject makes the next count commands with the name fail, or every command when the name is empty.
+// The fault applies until ClearFaults when count is 0.
+func (server *Server) Inject(command string, count int, fault Fault) {
+	server.mu.Lock()
+	server.faults = append(server.faults, &injection{
+		command: strings.ToUpper(command),
+		count:   count,
+		fault:   fault,
+	})
+
+	server.mu.Unlock()
+}
+
+// ClearFaults removes every injected fault.
+func (server *Server) ClearFaults() {
+	server.mu.Lock()
+	server.faults = nil
+	server.mu.Unlock()
+}
+
+// inject applies the first fault matching the command.
+// It returns the error to reply instead of executing the command or if the connection should be dropped.
+func (server *Server) inject(command string) (reply interface{}, drop bool) {
+	server.mu.Lock()
+
+	var fault *Fault
+	for i, item := range server.faults {
+		if item.command != "" && item.command != command {
+			continue
+		}
+
+		fault = &item.fault
+		if item.count != 0 {
+			if item.count--; item.count == 0 {
+				server.faults = append(server.faults[:i:i], server.faults[i+1:]...)
+			}
+		}
+
+		break
+	}
+
+	server.mu.Unlock()
+
+	if fault == nil {
+		return
+	}
+
+	time.Sleep(fault.Delay)
+
+	if fault.Error != "" {
+		reply = replyError(fault.Error)
+	}
+
+	drop = fault.Drop
+	return
+}
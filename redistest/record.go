@@ -0,0 +1,264 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+// Recorder implements a proxy to a Redis server that records every command and its reply.
+// The record is written as RESP frames with each command followed by its reply and can be replayed with NewReplayServer.
+// Commands of a connection are forwarded one at a time.
+type Recorder struct {
+	listener net.Listener
+	address  *url.URL
+
+	mu sync.Mutex
+	w  io.Writer
+
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewRecorder starts a proxy on a random local port that forwards commands to the server at the address e.g. tcp://127.0.0.1:6379.
+func NewRecorder(address string, w io.Writer) (recorder *Recorder, err error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return
+	}
+
+	listener, err := listen()
+	if err != nil {
+		return
+	}
+
+	recorder = &Recorder{
+		listener: listener,
+		address:  u,
+		w:        w,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	recorder.wg.Add(1)
+	go recorder.accept()
+	return
+}
+
+// Address returns the address of the proxy to use with the client e.g. tcp://127.0.0.1:6379.
+func (recorder *Recorder) Address() string {
+	return "tcp://" + recorder.listener.Addr().String()
+}
+
+// Close stops the proxy once every connection is closed.
+func (recorder *Recorder) Close() {
+	recorder.listener.Close()
+
+	recorder.mu.Lock()
+	for conn := range recorder.conns {
+		conn.Close()
+	}
+
+	recorder.mu.Unlock()
+	recorder.wg.Wait()
+}
+
+func (recorder *Recorder) accept() {
+	defer recorder.wg.Done()
+
+	for {
+		conn, err := recorder.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		recorder.wg.Add(1)
+		go recorder.serve(conn)
+	}
+}
+
+func (recorder *Recorder) serve(conn net.Conn) {
+	defer recorder.wg.Done()
+	defer conn.Close()
+
+	target, err := net.Dial(recorder.address.Scheme, recorder.address.Host+recorder.address.Path)
+	if err != nil {
+		return
+	}
+
+	defer target.Close()
+
+	recorder.mu.Lock()
+	recorder.conns[conn] = struct{}{}
+	recorder.conns[target] = struct{}{}
+	recorder.mu.Unlock()
+
+	defer func() {
+		recorder.mu.Lock()
+		delete(recorder.conns, conn)
+		delete(recorder.conns, target)
+		recorder.mu.Unlock()
+	}()
+
+	decoder := redis.NewDecoder(conn)
+	writer := bufio.NewWriter(conn)
+	encoder := redis.NewEncoder(target)
+	replies := redis.NewDecoder(target)
+
+	for {
+		request, err := decoder.Decode()
+		if err != nil {
+			return
+		}
+
+		items, ok := request.([]interface{})
+		if !ok || len(items) == 0 {
+			return
+		}
+
+		name := fmt.Sprintf("%s", items[0])
+		if err = encoder.Encode(name, items[1:]...); err != nil {
+			return
+		}
+
+		result, err := replies.Decode()
+		reply, ok := fromResult(result, err)
+		if !ok {
+			return
+		}
+
+		// keep each command next to its reply in the record
+		var frame bytes.Buffer
+		writeReply(&frame, toArray(items))
+		writeReply(&frame, reply)
+
+		recorder.mu.Lock()
+		recorder.w.Write(frame.Bytes())
+		recorder.mu.Unlock()
+
+		writeReply(writer, reply)
+		if err = writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// fromResult converts a decoded reply back to a reply of the server.
+// It returns false when the reply couldn't be read.
+func fromResult(result interface{}, err error) (reply interface{}, ok bool) {
+	if err != nil {
+		e, isReply := err.(redis.ReplyError)
+		reply, ok = replyError(e), isReply
+		return
+	}
+
+	switch result := result.(type) {
+	case string:
+		reply = status(strings.TrimPrefix(result, "+"))
+	case []interface{}:
+		items := make([]interface{}, len(result))
+		for i := range result {
+			if items[i], ok = fromResult(result[i], nil); !ok {
+				return
+			}
+		}
+
+		reply = items
+	default:
+		reply = result
+	}
+
+	ok = true
+	return
+}
+
+// toArray returns the command with its name in upper case like it is matched by the replay server.
+func toArray(items []interface{}) []interface{} {
+	result := make([]interface{}, len(items))
+	for i := range items {
+		result[i] = []byte(fmt.Sprintf("%s", items[i]))
+	}
+
+	result[0] = bytes.ToUpper(result[0].([]byte))
+	return result
+}
+
+// replay holds the recorded commands and their replies.
+type replay struct {
+	commands [][]byte
+	replies  []interface{}
+	used     []bool
+}
+
+// NewReplayServer starts a server that replies to each command with the reply recorded for it by a Recorder.
+// Identical commands get their replies in the order they were recorded, whatever the order of the other commands.
+// Commands that weren't recorded fail with an error.
+func NewReplayServer(r io.Reader) (server *Server, err error) {
+	records := new(replay)
+	decoder := redis.NewDecoder(r)
+
+	for {
+		var command interface{}
+		if command, err = decoder.Decode(); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+
+			return
+		}
+
+		items, ok := command.([]interface{})
+		if !ok {
+			err = fmt.Errorf("invalid record '%v'", command)
+			return
+		}
+
+		var buffer bytes.Buffer
+		writeReply(&buffer, items)
+
+		reply, ok := fromResult(decoder.Decode())
+		if !ok {
+			err = fmt.Errorf("missing reply of '%s'", redis.NewRequest(fmt.Sprintf("%s", items[0]), items[1:]...))
+			return
+		}
+
+		records.commands = append(records.commands, buffer.Bytes())
+		records.replies = append(records.replies, reply)
+		records.used = append(records.used, false)
+	}
+
+	if server, err = NewServer(); err == nil {
+		server.replay = records
+	}
+
+	return
+}
+
+func (r *replay) reply(name string, args [][]byte) interface{} {
+	items := make([]interface{}, len(args)+1)
+	items[0] = []byte(name)
+	for i := range args {
+		items[i+1] = args[i]
+	}
+
+	var buffer bytes.Buffer
+	writeReply(&buffer, items)
+
+	for i := range r.commands {
+		if !r.used[i] && bytes.Equal(r.commands[i], buffer.Bytes()) {
+			r.used[i] = true
+			return r.replies[i]
+		}
+	}
+
+	return replyError(fmt.Sprintf("ERR no recorded reply for '%s'", name))
+}
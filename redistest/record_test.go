@@ -0,0 +1,68 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	var record bytes.Buffer
+	recorder, err := NewRecorder(server.Address(), &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands := [][]interface{}{
+		{"set", "foo", "bar"},
+		{"GET", "foo"},
+		{"HSET", "h", "a", "1"},
+		{"HGETALL", "h"},
+		{"GET", "h"},
+		{"GET", "missing"},
+	}
+
+	run := func(address string) (results []interface{}) {
+		client := &redis.Client{
+			Address: []string{address},
+		}
+
+		defer client.Close()
+
+		for _, args := range commands {
+			result, err := client.Do(args[0].(string), args[1:]...)
+			results = append(results, result, err)
+		}
+
+		return
+	}
+
+	recorded := run(recorder.Address())
+	recorder.Close()
+
+	replayer, err := NewReplayServer(&record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer replayer.Close()
+
+	if replayed := run(replayer.Address()); !reflect.DeepEqual(recorded, replayed) {
+		t.Fatal(recorded, replayed)
+	}
+
+	if _, err := redis.Dial("tcp", replayer.Host()).Do("GET", "foo"); err == nil {
+		t.Fatal("every reply should have been used")
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+// Package redistest implements a miniature in-memory Redis server to test clients without a real database.
+// It supports strings, hashes, lists and sets with expiry, the commands used by the client to discover a cluster
+// and the injection of failures like MOVED, ASK or timeouts.
+// Simulation runs such servers in memory as a whole cluster with scripted redirections for each slot.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+// Server implements an in-memory Redis server listening on a local TCP port.
+type Server struct {
+	listener net.Listener
+	id       string
+
+	mu     sync.Mutex
+	data   map[string]*entry
+	slots  []slotRange
+	faults []*injection
+	replay *replay
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type slotRange struct {
+	first int
+	last  int
+	owner *Server
+}
+
+// NewServer starts a server on a random local port.
+func NewServer() (server *Server, err error) {
+	listener, err := listen()
+	if err != nil {
+		return
+	}
+
+	server = &Server{
+		listener: listener,
+		data:     make(map[string]*entry),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	server.id = fmt.Sprintf("%040x", listener.Addr().(*net.TCPAddr).Port)
+
+	server.wg.Add(1)
+	go server.accept()
+	return
+}
+
+// Address returns the address of the server to use with the client e.g. tcp://127.0.0.1:6379.
+func (server *Server) Address() string {
+	return "tcp://" + server.Host()
+}
+
+// Host returns the host:port of the server like it appears in redirections.
+func (server *Server) Host() string {
+	return server.listener.Addr().String()
+}
+
+// Close stops the server and closes all connections.
+func (server *Server) Close() {
+	server.mu.Lock()
+	server.closed = true
+	server.listener.Close()
+	for conn := range server.conns {
+		conn.Close()
+	}
+
+	server.mu.Unlock()
+	server.wg.Wait()
+}
+
+// FlushAll removes every key.
+func (server *Server) FlushAll() {
+	server.mu.Lock()
+	server.data = make(map[string]*entry)
+	server.mu.Unlock()
+}
+
+// AddSlots declares that the slots from first to last are served by the owner in the reply of CLUSTER SLOTS.
+// A server without slots replies like a single node serving every slot.
+func (server *Server) AddSlots(first, last int, owner *Server) {
+	server.mu.Lock()
+	server.slots = append(server.slots, slotRange{first, last, owner})
+	server.mu.Unlock()
+}
+
+func listen() (net.Listener, error) {
+	return net.Listen("tcp", "127.0.0.1:0")
+}
+
+func (server *Server) accept() {
+	defer server.wg.Done()
+
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		server.mu.Lock()
+		if server.closed {
+			server.mu.Unlock()
+			conn.Close()
+			return
+		}
+
+		server.conns[conn] = struct{}{}
+		server.wg.Add(1)
+		server.mu.Unlock()
+
+		go server.serve(conn)
+	}
+}
+
+func (server *Server) serve(conn net.Conn) {
+	defer func() {
+		server.mu.Lock()
+		delete(server.conns, conn)
+		server.mu.Unlock()
+
+		conn.Close()
+		server.wg.Done()
+	}()
+
+	decoder := redis.NewDecoder(conn)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		request, err := decoder.Decode()
+		if err != nil {
+			return
+		}
+
+		items, ok := request.([]interface{})
+		if !ok || len(items) == 0 {
+			writeReply(writer, replyError("ERR protocol error"))
+			writer.Flush()
+			continue
+		}
+
+		args := make([][]byte, len(items))
+		for i := range items {
+			if args[i], ok = items[i].([]byte); !ok {
+				args[i] = []byte(fmt.Sprint(items[i]))
+			}
+		}
+
+		name := strings.ToUpper(string(args[0]))
+
+		reply, drop := server.inject(name)
+		if drop {
+			return
+		}
+
+		if reply == nil {
+			reply = server.execute(name, args[1:])
+		}
+
+		writeReply(writer, reply)
+		if err = writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs the command while holding the lock of the database.
+func (server *Server) execute(name string, args [][]byte) interface{} {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.replay != nil {
+		return server.replay.reply(name, args)
+	}
+
+	c, ok := commands[name]
+	if !ok {
+		return replyError(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+	}
+
+	if len(args) < c.arity || c.variadic == 0 && len(args) != c.arity || c.variadic > 1 && (len(args)-c.arity)%c.variadic != 0 {
+		return replyError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+	}
+
+	return c.f(server, args)
+}
+
+// status is a simple string reply like +OK.
+type status string
+
+// replyError is an error reply like -ERR.
+type replyError string
+
+var (
+	ok        = status("OK")
+	wrongType = replyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	notInt    = replyError("ERR value is not an integer or out of range")
+	syntax    = replyError("ERR syntax error")
+)
+
+// nilArray is replied as *-1.
+type nilArray struct{}
+
+func writeReply(w io.Writer, reply interface{}) {
+	switch reply := reply.(type) {
+	case status:
+		fmt.Fprintf(w, "+%s\r\n", reply)
+	case replyError:
+		fmt.Fprintf(w, "-%s\r\n", reply)
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", reply)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", reply)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(reply), reply)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(reply), reply)
+	case nil:
+		fmt.Fprintf(w, "$-1\r\n")
+	case nilArray:
+		fmt.Fprintf(w, "*-1\r\n")
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(reply))
+		for _, item := range reply {
+			writeReply(w, item)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected reply %#v", reply))
+	}
+}
+
+func parseInt(arg []byte) (n int64, ok bool) {
+	n, err := strconv.ParseInt(string(arg), 10, 64)
+	ok = err == nil
+	return
+}
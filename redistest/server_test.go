@@ -0,0 +1,164 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+func TestServer(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	client := &redis.Client{
+		Address: []string{server.Address()},
+	}
+
+	defer client.Close()
+
+	tests := []struct {
+		args   []interface{}
+		result interface{}
+	}{
+		{[]interface{}{"PING"}, "PONG"},
+		{[]interface{}{"SET", "foo", "bar"}, redis.OK},
+		{[]interface{}{"GET", "foo"}, []byte("bar")},
+		{[]interface{}{"INCR", "n"}, int64(1)},
+		{[]interface{}{"INCRBY", "n", 41}, int64(42)},
+		{[]interface{}{"HSET", "h", "a", "1", "b", "2"}, int64(2)},
+		{[]interface{}{"HGETALL", "h"}, []interface{}{[]byte("a"), []byte("1"), []byte("b"), []byte("2")}},
+		{[]interface{}{"RPUSH", "l", "a", "b", "c"}, int64(3)},
+		{[]interface{}{"LRANGE", "l", 1, -1}, []interface{}{[]byte("b"), []byte("c")}},
+		{[]interface{}{"LPOP", "l"}, []byte("a")},
+		{[]interface{}{"SADD", "s", "x", "y", "x"}, int64(2)},
+		{[]interface{}{"SMEMBERS", "s"}, []interface{}{[]byte("x"), []byte("y")}},
+		{[]interface{}{"DEL", "foo", "missing"}, int64(1)},
+		{[]interface{}{"EXISTS", "foo"}, int64(0)},
+		{[]interface{}{"TYPE", "h"}, "hash"},
+	}
+
+	for i, test := range tests {
+		result, err := client.Do(test.args[0].(string), test.args[1:]...)
+		if err != nil || !reflect.DeepEqual(result, test.result) {
+			t.Fatal(i, err, result)
+		}
+	}
+
+	if _, err := client.Do("GET", "h"); err == nil {
+		t.Fatal("expecting WRONGTYPE")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	client := &redis.Client{
+		Address: []string{server.Address()},
+	}
+
+	defer client.Close()
+
+	if _, err := client.Do("SET", "foo", "bar", "PX", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl, err := client.Do("PTTL", "foo"); err != nil || ttl.(int64) <= 0 {
+		t.Fatal(err, ttl)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if result, err := client.Do("GET", "foo"); err != nil || result != nil {
+		t.Fatal(err, result)
+	}
+}
+
+func TestInjectMoved(t *testing.T) {
+	a, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer a.Close()
+
+	b, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer b.Close()
+
+	// b serves every slot
+	a.AddSlots(0, 16383, b)
+	b.AddSlots(0, 16383, b)
+
+	client := &redis.Client{
+		Address: []string{a.Address()},
+	}
+
+	defer client.Close()
+
+	a.Inject("GET", 1, Moved(redis.Slot("foo"), b.Host()))
+
+	if _, err := client.Do("SET", "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := client.Do("GET", "foo"); err != nil || result != nil {
+		t.Fatal(err, result)
+	}
+
+	// the client now knows the cluster
+	if _, err := client.Do("SET", "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := redis.Dial("tcp", b.Host()).Do("GET", "foo"); err != nil || string(result.([]byte)) != "baz" {
+		t.Fatal(err, result)
+	}
+}
+
+func TestInjectTimeout(t *testing.T) {
+	server, err := NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	client := &redis.Client{
+		Address:     []string{server.Address()},
+		ReadTimeout: 10 * time.Millisecond,
+	}
+
+	defer client.Close()
+
+	server.Inject("PING", 1, Timeout(100*time.Millisecond))
+
+	if _, err := client.Do("PING"); !redis.IsTimeout(err) {
+		t.Fatal(err)
+	}
+
+	server.Inject("", 0, Fault{Drop: true})
+	if _, err := client.Do("ECHO", "x"); err == nil {
+		t.Fatal("expecting an error")
+	}
+
+	server.ClearFaults()
+	if result, err := client.Do("ECHO", "x"); err != nil || string(result.([]byte)) != "x" {
+		t.Fatal(err, result)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/datacratic/goredis/redis"
+)
+
+// Simulation implements a cluster of nodes held in memory, which the client reaches through its Dialer without any network.
+// Nodes are named by their host:port and share the same data while each slot is served by one of them.
+// A node replies MOVED for the slots it doesn't serve unless the command follows ASKING, like during a migration.
+type Simulation struct {
+	store *Server
+
+	mu     sync.Mutex
+	owners [16384]string
+	down   map[string]bool
+	steps  map[int][]Step
+	calls  []Call
+	conns  map[net.Conn]string
+}
+
+// Step scripts how a node replies to the next command with a key in a slot of a simulation.
+type Step struct {
+	fault Fault
+	moved string
+	asked string
+}
+
+// Call is a command received by a node of a simulation.
+type Call struct {
+	Node string
+	Args []string
+}
+
+// Moves migrates the slot to the node, which makes the node that received the command reply MOVED.
+func Moves(node string) Step {
+	return Step{moved: node}
+}
+
+// Asks makes the node reply ASK to redirect the command to the node.
+func Asks(node string) Step {
+	return Step{asked: node}
+}
+
+// Fails makes the node fail the command with the fault.
+func Fails(fault Fault) Step {
+	return Step{fault: fault}
+}
+
+// Succeeds lets the node execute the command normally.
+func Succeeds() Step {
+	return Step{}
+}
+
+// NewSimulation returns a simulation of the nodes with the slots split evenly between them.
+func NewSimulation(nodes ...string) *Simulation {
+	sim := &Simulation{
+		store: &Server{
+			data:  make(map[string]*entry),
+			conns: make(map[net.Conn]struct{}),
+		},
+		down:  make(map[string]bool),
+		steps: make(map[int][]Step),
+		conns: make(map[net.Conn]string),
+	}
+
+	k := 16384 / len(nodes)
+	for i, node := range nodes {
+		last := k*i + k - 1
+		if i == len(nodes)-1 {
+			last = 16383
+		}
+
+		sim.Assign(k*i, last, node)
+	}
+
+	return sim
+}
+
+// Client returns a client connected to the simulation through the first node.
+func (sim *Simulation) Client() *redis.Client {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	return &redis.Client{
+		Address: []string{"tcp://" + sim.owners[0]},
+		Dialer:  sim.Dial,
+	}
+}
+
+// Dial connects to the node at the address e.g. tcp://127.0.0.1:6379.
+func (sim *Simulation) Dial(address string) (net.Conn, error) {
+	node := strings.TrimPrefix(address, "tcp://")
+
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+
+	if sim.down[node] {
+		return nil, fmt.Errorf("dial %s: connection refused", node)
+	}
+
+	client, server := net.Pipe()
+	sim.conns[server] = node
+	go sim.serve(node, server)
+	return client, nil
+}
+
+// Assign makes the node serve the slots from first to last.
+func (sim *Simulation) Assign(first, last int, node string) {
+	sim.mu.Lock()
+	for slot := first; slot <= last; slot++ {
+		sim.owners[slot] = node
+	}
+
+	sim.mu.Unlock()
+}
+
+// Script appends steps followed by the next commands with a key in the slot, whichever node receives them.
+// Commands are executed normally once the steps are exhausted.
+func (sim *Simulation) Script(slot int, steps ...Step) {
+	sim.mu.Lock()
+	sim.steps[slot] = append(sim.steps[slot], steps...)
+	sim.mu.Unlock()
+}
+
+// Stop closes the connections to the node and refuses new ones until Start.
+func (sim *Simulation) Stop(node string) {
+	sim.mu.Lock()
+	sim.down[node] = true
+	for conn, name := range sim.conns {
+		if name == node {
+			conn.Close()
+		}
+	}
+
+	sim.mu.Unlock()
+}
+
+// Start accepts the connections to the node again.
+func (sim *Simulation) Start(node string) {
+	sim.mu.Lock()
+	delete(sim.down, node)
+	sim.mu.Unlock()
+}
+
+// Calls returns the commands received so far by the nodes in order.
+func (sim *Simulation) Calls() []Call {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return append([]Call(nil), sim.calls...)
+}
+
+// Close closes every connection.
+func (sim *Simulation) Close() {
+	sim.mu.Lock()
+	for conn := range sim.conns {
+		conn.Close()
+	}
+
+	sim.mu.Unlock()
+}
+
+func (sim *Simulation) serve(node string, conn net.Conn) {
+	defer func() {
+		sim.mu.Lock()
+		delete(sim.conns, conn)
+		sim.mu.Unlock()
+
+		conn.Close()
+	}()
+
+	decoder := redis.NewDecoder(conn)
+	writer := bufio.NewWriter(conn)
+
+	asking := false
+	for {
+		request, err := decoder.Decode()
+		if err != nil {
+			return
+		}
+
+		items, _ := request.([]interface{})
+		args := make([][]byte, len(items))
+		for i := range items {
+			var ok bool
+			if args[i], ok = items[i].([]byte); !ok {
+				args[i] = []byte(fmt.Sprint(items[i]))
+			}
+		}
+
+		if len(args) == 0 {
+			writeReply(writer, replyError("ERR protocol error"))
+			writer.Flush()
+			continue
+		}
+
+		name := strings.ToUpper(string(args[0]))
+		reply, drop := sim.execute(node, name, args, asking)
+		if drop {
+			return
+		}
+
+		asking = name == "ASKING"
+
+		writeReply(writer, reply)
+		if err = writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// execute replies to the command received by the node according to the script and the owner of its slot.
+func (sim *Simulation) execute(node, name string, args [][]byte, asking bool) (reply interface{}, drop bool) {
+	sim.mu.Lock()
+
+	call := Call{Node: node, Args: make([]string, len(args))}
+	for i := range args {
+		call.Args[i] = string(args[i])
+	}
+
+	sim.calls = append(sim.calls, call)
+
+	if name == "CLUSTER" && len(args) > 1 && strings.ToUpper(string(args[1])) == "SLOTS" {
+		reply = sim.slots()
+		sim.mu.Unlock()
+		return
+	}
+
+	if keyless[name] || len(args) < 2 {
+		sim.mu.Unlock()
+		reply = sim.store.execute(name, args[1:])
+		return
+	}
+
+	slot := redis.Slot(string(args[1]))
+
+	var fault Fault
+	if steps := sim.steps[slot]; len(steps) != 0 && !asking {
+		step := steps[0]
+		sim.steps[slot] = steps[1:]
+
+		switch {
+		case step.moved != "":
+			sim.owners[slot] = step.moved
+		case step.asked != "":
+			fault = Ask(slot, step.asked)
+		default:
+			fault = step.fault
+		}
+	}
+
+	owner := sim.owners[slot]
+	sim.mu.Unlock()
+
+	if fault.Delay != 0 {
+		time.Sleep(fault.Delay)
+	}
+
+	switch {
+	case fault.Drop:
+		drop = true
+	case fault.Error != "":
+		reply = replyError(fault.Error)
+	case owner != node && !asking:
+		reply = replyError(fmt.Sprintf("MOVED %d %s", slot, owner))
+	default:
+		reply = sim.store.execute(name, args[1:])
+	}
+
+	return
+}
+
+// slots returns the reply of CLUSTER SLOTS and must be called with the lock held.
+func (sim *Simulation) slots() interface{} {
+	var result []interface{}
+	for first := 0; first < len(sim.owners); {
+		last := first
+		for last+1 < len(sim.owners) && sim.owners[last+1] == sim.owners[first] {
+			last++
+		}
+
+		host, port, _ := net.SplitHostPort(sim.owners[first])
+		n, _ := strconv.Atoi(port)
+		result = append(result, []interface{}{first, last, []interface{}{host, n}})
+		first = last + 1
+	}
+
+	return result
+}
+
+// keyless holds the commands without a key, which any node executes.
+var keyless = map[string]bool{
+	"PING":     true,
+	"ECHO":     true,
+	"SELECT":   true,
+	"AUTH":     true,
+	"ASKING":   true,
+	"READONLY": true,
+	"KEYS":     true,
+	"DBSIZE":   true,
+	"FLUSHALL": true,
+	"FLUSHDB":  true,
+	"CLUSTER":  true,
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2015 Datacratic. All rights reserved.
+
+package redistest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimulation(t *testing.T) {
+	const a, b, c = "10.0.0.1:6379", "10.0.0.2:6379", "10.0.0.3:6379"
+
+	slot := 12182 // of foo, served by c
+
+	tests := []struct {
+		name  string
+		steps []Step
+		nodes []string
+		fails bool
+	}{
+		{"direct", nil, []string{c}, false},
+		{"moved", []Step{Moves(a)}, []string{c, a}, false},
+		{"moved twice", []Step{Moves(b), Moves(a)}, []string{c, b, a}, false},
+		// the client doesn't send ASKING so the importing node redirects it back
+		{"ask", []Step{Asks(b)}, []string{c, b, c}, false},
+		{"error", []Step{Fails(Fault{Error: "TRYAGAIN"})}, []string{c}, true},
+		{"drop", []Step{Fails(Fault{Drop: true})}, []string{c}, true},
+	}
+
+	for _, test := range tests {
+		sim := NewSimulation(a, b, c)
+		client := sim.Client()
+
+		if _, err := client.Do("SET", "foo", "bar"); err != nil {
+			t.Fatal(test.name, err)
+		}
+
+		n := len(sim.Calls())
+		sim.Script(slot, test.steps...)
+
+		result, err := client.Do("GET", "foo")
+		if test.fails != (err != nil) || err == nil && string(result.([]byte)) != "bar" {
+			t.Fatal(test.name, result, err)
+		}
+
+		var nodes []string
+		for _, call := range sim.Calls()[n:] {
+			if strings.ToUpper(call.Args[0]) == "GET" {
+				nodes = append(nodes, call.Node)
+			}
+		}
+
+		if !reflect.DeepEqual(nodes, test.nodes) {
+			t.Fatal(test.name, nodes)
+		}
+
+		client.Close()
+		sim.Close()
+	}
+}